@@ -44,6 +44,8 @@ func newCompletionCmd() *cobra.Command {
 		Use:   "completion",
 		Short: "Outputs shell completions for the openshift-install command",
 		Long:  completionLong,
+
+		Annotations: plainOutput,
 	}
 
 	bashCompletionCmd := &cobra.Command{
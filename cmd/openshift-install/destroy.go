@@ -1,16 +1,13 @@
 package main
 
 import (
-	"github.com/pkg/errors"
+	"context"
+
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
-	assetstore "github.com/openshift/installer/pkg/asset/store"
-	"github.com/openshift/installer/pkg/destroy"
-	_ "github.com/openshift/installer/pkg/destroy/azure"
 	"github.com/openshift/installer/pkg/destroy/bootstrap"
-	_ "github.com/openshift/installer/pkg/destroy/libvirt"
-	_ "github.com/openshift/installer/pkg/destroy/openstack"
+	"github.com/openshift/installer/pkg/installer"
 )
 
 func newDestroyCmd() *cobra.Command {
@@ -45,30 +42,7 @@ func newDestroyClusterCmd() *cobra.Command {
 }
 
 func runDestroyCmd(directory string) error {
-	destroyer, err := destroy.New(logrus.StandardLogger(), directory)
-	if err != nil {
-		return errors.Wrap(err, "Failed while preparing to destroy cluster")
-	}
-	if err := destroyer.Run(); err != nil {
-		return errors.Wrap(err, "Failed to destroy cluster")
-	}
-
-	store, err := assetstore.NewStore(directory)
-	if err != nil {
-		return errors.Wrap(err, "failed to create asset store")
-	}
-	for _, asset := range clusterTarget.assets {
-		if err := store.Destroy(asset); err != nil {
-			return errors.Wrapf(err, "failed to destroy asset %q", asset.Name())
-		}
-	}
-	// delete the state file as well
-	err = store.DestroyState()
-	if err != nil {
-		return errors.Wrap(err, "failed to remove state file")
-	}
-
-	return nil
+	return installer.Destroy(context.Background(), directory, installer.DestroyOptions{})
 }
 
 func newDestroyBootstrapCmd() *cobra.Command {
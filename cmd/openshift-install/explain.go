@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openshift/installer/pkg/explain"
+)
+
+func newExplainCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "explain FIELD",
+		Short: "Document the fields of the install config",
+		Long: `Document the fields of the install config.
+
+FIELD is the path of the field from the install config down, like
+installconfig.platform.aws.region. Its type, description, default and
+allowed values are printed, along with the summary of its own fields, so
+that installconfig alone lists the top-level fields.`,
+		Example: `  openshift-install explain installconfig
+  openshift-install explain installconfig.platform.aws.region`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			field, err := explain.Lookup(args[0])
+			if err != nil {
+				return err
+			}
+			return explain.Print(os.Stdout, field)
+		},
+		Annotations: plainOutput,
+	}
+}
@@ -1,29 +1,28 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"strings"
+	"sort"
+	"syscall"
 	"time"
 
-	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
-	"github.com/openshift/installer/pkg/asset/installconfig"
-	assetstore "github.com/openshift/installer/pkg/asset/store"
+	"github.com/openshift/installer/pkg/asset/cluster"
+	"github.com/openshift/installer/pkg/destroy"
+	"github.com/openshift/installer/pkg/gather"
+	gatherbootstrap "github.com/openshift/installer/pkg/gather/bootstrap"
 	"github.com/openshift/installer/pkg/gather/ssh"
-	"github.com/openshift/installer/pkg/terraform"
-	gatheraws "github.com/openshift/installer/pkg/terraform/gather/aws"
-	gatherazure "github.com/openshift/installer/pkg/terraform/gather/azure"
-	gatherlibvirt "github.com/openshift/installer/pkg/terraform/gather/libvirt"
-	gatheropenstack "github.com/openshift/installer/pkg/terraform/gather/openstack"
-	"github.com/openshift/installer/pkg/types"
+	"github.com/openshift/installer/pkg/installer"
+	tfgather "github.com/openshift/installer/pkg/terraform/gather"
 	awstypes "github.com/openshift/installer/pkg/types/aws"
-	azuretypes "github.com/openshift/installer/pkg/types/azure"
-	libvirttypes "github.com/openshift/installer/pkg/types/libvirt"
-	openstacktypes "github.com/openshift/installer/pkg/types/openstack"
 )
 
 func newGatherCmd() *cobra.Command {
@@ -40,15 +39,21 @@ to debug the installation failures`,
 		},
 	}
 	cmd.AddCommand(newGatherBootstrapCmd())
+	cmd.AddCommand(newGatherClusterCmd())
+	cmd.AddCommand(newGatherAnalyzeCmd())
+	cmd.AddCommand(newGatherDiffCmd())
+	cmd.AddCommand(newGatherDestroyCmd())
+	cmd.AddCommand(newGatherSerialConsoleCmd())
 	return cmd
 }
 
 var (
-	gatherBootstrapOpts struct {
-		bootstrap string
-		masters   []string
-		sshKeys   []string
-	}
+	gatherBootstrapOpts gatherbootstrap.Options
+	gatherClusterOpts   gatherbootstrap.ClusterOptions
+
+	// gatherLogFormat is the format of the log of the gather, "text" or
+	// "json".
+	gatherLogFormat string
 )
 
 func newGatherBootstrapCmd() *cobra.Command {
@@ -56,132 +61,334 @@ func newGatherBootstrapCmd() *cobra.Command {
 		Use:   "bootstrap",
 		Short: "Gather debugging data for a failing-to-bootstrap control plane",
 		Args:  cobra.ExactArgs(0),
-		Run: func(_ *cobra.Command, _ []string) {
-			cleanup := setupFileHook(rootOpts.dir)
-			defer cleanup()
-			err := runGatherBootstrapCmd(rootOpts.dir)
-			if err != nil {
-				logrus.Fatal(err)
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := gatherBootstrap(); err != nil {
+				exitWithCode(gather.ExitCode(err))
 			}
+			return nil
 		},
 	}
-	cmd.PersistentFlags().StringVar(&gatherBootstrapOpts.bootstrap, "bootstrap", "", "Hostname or IP of the bootstrap host")
-	cmd.PersistentFlags().StringArrayVar(&gatherBootstrapOpts.masters, "master", []string{}, "Hostnames or IPs of all control plane hosts")
-	cmd.PersistentFlags().StringArrayVar(&gatherBootstrapOpts.sshKeys, "key", []string{}, "Path to SSH private keys that should be used for authentication. If no key was provided, SSH private keys from user's environment will be used")
+	cmd.PersistentFlags().StringVar(&gatherBootstrapOpts.Bootstrap, "bootstrap", "", "Hostname or IP of the bootstrap host, optionally followed by :port to override the SSH port. Without terraform state, the addresses are otherwise discovered from the cloud API by the infrastructure ID in metadata.json, on AWS")
+	cmd.PersistentFlags().StringArrayVar(&gatherBootstrapOpts.Masters, "master", []string{}, "Hostnames or IPs of all control plane hosts, optionally followed by :port for the hosts connected to directly. IPv6 addresses with a port are enclosed in brackets")
+	cmd.PersistentFlags().StringVar(&gatherBootstrapOpts.HostsFile, "hosts-file", "", "Path to a YAML or JSON file listing the bootstrap host under 'bootstrap' and the control plane hosts under 'masters', each with an 'address' and an optional 'port' and 'key', instead of --bootstrap and --master. The keys are added to the --key keys")
+	cmd.PersistentFlags().StringSliceVar(&gatherBootstrapOpts.Only, "only", []string{}, "Collect only the bootstrap and control plane hosts with these names or addresses, like bootstrap, master-1 or 10.0.1.7, out of those found. The control plane hosts are named by their index in the terraform state. Can be repeated or comma-separated")
+	cmd.PersistentFlags().StringArrayVar(&gatherBootstrapOpts.Hosts, "host", []string{}, "Additional host to collect directly, given as role=address (e.g. etcd=10.0.0.5). Each host is collected into the directory of its role in the bundle")
+	cmd.PersistentFlags().StringVar(&gatherBootstrapOpts.TFShowJSON, "tf-show-json", "", "Read the host addresses from a file holding the output of 'terraform show -json' instead of the terraform state in the assets directory. The values of the managed resources under values.root_module and its child_modules are read")
+	cmd.PersistentFlags().StringVar(&gatherBootstrapOpts.StateURL, "state-url", "", "Read the host addresses from the terraform state stored in S3 (s3://bucket/key) or GCS (gs://bucket/prefix/workspace.tfstate) instead of the assets directory. Query parameters are passed to the terraform backend, e.g. ?region=us-east-1; the region of an AWS cluster is used by default. Without this flag, the state is read from the remote backend recorded in .terraform/terraform.tfstate when there is no local state")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.IgnorePlatformMismatch, "ignore-platform-mismatch", false, "Read the host addresses from a terraform state whose resources are for another platform than the one of the install config, after a warning, instead of failing")
+	cmd.PersistentFlags().StringVar(&gatherBootstrapOpts.ViaKubeconfig, "via-kubeconfig", "", "Collect the nodes listed by the API using this kubeconfig, or auth/kubeconfig in the assets directory when no value is given, instead of the hosts in the terraform state. Each node is collected directly, using its internal address, into the directory of its role")
+	cmd.PersistentFlags().Lookup("via-kubeconfig").NoOptDefVal = gatherbootstrap.ViaAssetsKubeconfig
+	cmd.PersistentFlags().StringVar(&gatherBootstrapOpts.Metadata, "metadata", "", "Path to the metadata.json of the cluster to discover the bootstrap and control plane hosts from with the cloud API, for when the rest of the assets directory is gone. Only AWS supports the discovery, other platforms need --bootstrap and --master")
+	cmd.PersistentFlags().StringArrayVar(&gatherBootstrapOpts.SSHKeys, "key", []string{}, "Path to SSH private keys that should be used for authentication. If no key was provided, the keys of the SSH agent at SSH_AUTH_SOCK are used, or else ~/.ssh/id_rsa, ~/.ssh/id_ecdsa and ~/.ssh/id_ed25519. The passphrases of encrypted PEM keys are asked for on the terminal, or with SSH_ASKPASS")
+	cmd.PersistentFlags().StringArrayVar(&gatherBootstrapOpts.KeyPassphraseFiles, "key-passphrase-file", []string{}, "Path to a file holding the passphrase of the encrypted --key, for non-interactive runs. Give it once for all keys, or once per --key, in the same order")
+	cmd.PersistentFlags().DurationVar(&gatherBootstrapOpts.StepTimeout, "collect-timeout-per-step", time.Minute, "Time after which a single diagnostic step is abandoned and recorded as timed out. This does not apply to the installer-gather.sh run (0 disables the timeout)")
+	cmd.PersistentFlags().DurationVar(&gatherBootstrapOpts.Timeout, "timeout", 10*time.Minute, "Time after which running installer-gather.sh on the bootstrap host and pulling the bundle it creates is abandoned (0 disables the timeout)")
+	cmd.PersistentFlags().DurationVar(&gatherBootstrapOpts.DialTimeout, "dial-timeout", 30*time.Second, "Time after which connecting to a host is abandoned and the host recorded as unreachable (0 disables the timeout)")
+	cmd.PersistentFlags().IntVar(&gatherBootstrapOpts.Retries, "retries", 3, "Number of times pulling the bundle created by installer-gather.sh is retried, with backoff and over a new SSH connection, when the transfer fails. installer-gather.sh is not run again")
+	cmd.PersistentFlags().StringVar(&gatherLogFormat, "log-format", "text", "Format of the log on stderr and in .openshift_install.log: text, or json for one JSON object per line with the time, level and msg fields, and the platform, phase and host fields of the gather")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.KeepRemote, "keep-remote", false, "Leave the bundle created by installer-gather.sh, and its staging directory, on the host once pulled, for debugging. By default they are removed, so that a later gather cannot pull a stale bundle")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.Quiet, "quiet", false, "Do not log the output of installer-gather.sh as it runs. The output is logged at the debug level instead")
+	cmd.PersistentFlags().StringVar(&gatherBootstrapOpts.GatherScript, "gather-script", "", "Run this gather script instead of /usr/local/bin/installer-gather.sh. A local file is uploaded to a temporary file on the host, which is removed afterwards; otherwise the value is an absolute path on the host")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.NoScript, "no-script", false, "Collect the bootstrap journals and container logs directly over SSH instead of running installer-gather.sh, for images where the script is missing or broken. This is slower and does not collect the control plane hosts")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.DryRun, "dry-run", false, "Print the platform, the bootstrap and control plane hosts, the SSH user and keys and the remote command the gather resolved to stdout, one \"key: value\" line each, then exit without connecting to any host or writing any file")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.Estimate, "estimate", false, "Estimate the size of the bootstrap host bundle and the time to pull it, using the size of its logs and assets and the throughput of a 1 MiB probe transfer, then exit without collecting. The estimate is logged, and printed to stdout as JSON")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.Minimal, "minimal", false, "Collect only the bundle created by installer-gather.sh, skipping every optional diagnostic step, local artifact and summary. This overrides any --include-* flags")
+	cmd.PersistentFlags().Int64Var(&gatherBootstrapOpts.MaxUnitLogBytes, "max-unit-log-bytes", 0, "Truncate the journal of each unit, the kube-apiserver audit log and the CoreDNS pod logs, to its most recent (tail) bytes of this size. 0 keeps whole logs")
+	cmd.PersistentFlags().StringVar(&gatherBootstrapOpts.SSHUser, "ssh-user", tfgather.DefaultSSHUser, "User to log in to the bootstrap and control plane hosts, and the bastion, as. Windows nodes are always logged in to as Administrator")
+	cmd.PersistentFlags().IntVar(&gatherBootstrapOpts.SSHPort, "ssh-port", 0, "Port to connect to the bootstrap host on. Defaults to the port of the platform, which is 22 except on Azure, where the public load balancer forwards 2200 to the bootstrap host")
+	cmd.PersistentFlags().StringVar(&gatherBootstrapOpts.RemoteShell, "remote-shell", ssh.DefaultShell, "Remote command that every command run on the hosts is passed to as a single quoted argument, so that commands work whatever the login shell of the SSH user is. An empty value passes commands to the login shell as they are. Windows nodes always use their login shell")
+	cmd.PersistentFlags().StringVar(&gatherBootstrapOpts.HTTPSProxy, "https-proxy", "", "HTTP proxy ([user:password@]host:port) to tunnel the SSH connections through using CONNECT. With --bastion, only the connection to the bastion goes through the proxy")
+	cmd.PersistentFlags().StringVar(&gatherBootstrapOpts.ProxyCommand, "proxy-command", "", "Command to connect to the hosts through, like the OpenSSH ProxyCommand: the SSH connection is made over its standard input and output, and %h and %p are replaced with the host and port. The command is run with /bin/sh -c, and its standard error is shown when the connection fails. With --bastion, only the connection to the bastion goes through the command")
+	cmd.PersistentFlags().StringVar(&gatherBootstrapOpts.Bastion, "bastion", "", "Jump host ([user@]host[:port]) to tunnel the SSH connections through, for clusters whose hosts are not reachable directly. The user defaults to core")
+	cmd.PersistentFlags().StringArrayVar(&gatherBootstrapOpts.BastionKeys, "bastion-key", []string{}, "Path to SSH private keys that should be used to log in to the bastion, when they differ from those of the cluster hosts. Defaults to the --key keys")
+	cmd.PersistentFlags().StringVar(&gatherBootstrapOpts.BastionCA, "bastion-ca", "", "Path to the public key of the SSH certificate authority which signs the host certificate of the bastion. When set, the bastion is only trusted if it presents a host certificate signed by it")
+	cmd.PersistentFlags().StringVar(&gatherBootstrapOpts.KnownHosts, "known-hosts", "", "Path to a known_hosts file to verify the host keys of the hosts and the bastion against. Hosts missing from the file are added to it, and hosts whose key differs are not connected to. Without this flag, host keys are not verified")
+	cmd.PersistentFlags().IntVar(&gatherBootstrapOpts.APIPort, "api-port", 6443, "Port of the Kubernetes API, for clusters whose load balancer moves it off the default. It replaces the port of the server in auth/kubeconfig when listing nodes")
+	cmd.PersistentFlags().IntVar(&gatherBootstrapOpts.MachineConfigPort, "machine-config-port", 22623, "Port of the machine config server, used to request the worker Ignition config")
+	cmd.PersistentFlags().StringVar(&gatherBootstrapOpts.Output, "output", "", "Path to write the log bundle to, instead of log-bundle-<timestamp>.tar.gz in the assets directory. Missing directories are created. An existing file is only replaced with --force")
+	cmd.PersistentFlags().StringVar(&gatherBootstrapOpts.UploadTo, "upload-to", "", "Upload the log bundle to object storage, given as s3://bucket/prefix or gs://bucket/prefix, once it is written. S3 uses the AWS credentials of the installer and GCS the application default credentials. The local bundle is kept")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.Force, "force", false, "Replace the file given by --output if it exists")
+	cmd.PersistentFlags().StringVar(&gatherBootstrapOpts.EncryptTo, "encrypt-to", "", "Encrypt the bundle for a single recipient, given as an age public key (age1...), a file of age or SSH public keys (both need the age command), or an OpenPGP public key file, user ID or fingerprint (needs the gpg command). The bundle is streamed through the encryption and gets a .age or .gpg extension")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.Resume, "resume", false, "Keep the collected data in the assets directory until the gather completes, so that re-running after a failure skips the hosts that were already collected. The kept data is discarded if the host addresses change")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.AllowHealthy, "allow-healthy", false, "Allow gathering from a cluster which finished bootstrapping, for baselines. When the bootstrap host was destroyed, or --bootstrap is not given, the control plane hosts are collected directly, and the bootstrap-only data, like the installer-gather.sh bundle, cluster-bootstrap progress and bootstrap audit log, is missing from the bundle")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.IncludeMastersLive, "include-masters-live", false, "Also connect to the control plane hosts directly, concurrently with the bootstrap host, and collect their journals, containers and diagnostics. This works when the bootstrap host cannot reach them")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.ParallelMasters, "parallel-masters", false, "Collect the control plane hosts directly and in parallel, instead of one after the other through installer-gather.sh on the bootstrap host, so that an unreachable host does not stall the others. This is always done when the bootstrap host is unreachable")
+	cmd.PersistentFlags().IntVar(&gatherBootstrapOpts.MaxParallel, "max-parallel-hosts", 5, "Maximum number of control plane hosts, and of --host hosts, collected directly at the same time. 0 removes the limit")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.FirstReachableMaster, "first-reachable-master", false, "Probe the control plane hosts in order and collect only the first one that accepts SSH, directly and through installer-gather.sh, instead of all of them. This is much faster when only one host is needed for triage, and the summary names the host used")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.IncludeMustGather, "include-must-gather", false, "Also run must-gather from the bootstrap host when the API is up, using the must-gather image of the release image")
+	cmd.PersistentFlags().DurationVar(&gatherBootstrapOpts.MustGatherTimeout, "must-gather-timeout", 5*time.Minute, "Time after which must-gather is stopped and its partial output collected")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.IncludeClusterDNS, "include-cluster-dns", false, "Also collect the DNS operator and CoreDNS state, config maps and pod logs through the API from the bootstrap host, when the API is up")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.IncludeOptOpenShift, "include-opt-openshift", false, "Also copy the assets the bootstrap host rendered and applied under /opt/openshift. Private keys and kubeconfigs are left out and Secret data is redacted, but the copy can be large")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.DecodeIgnition, "decode-ignition", false, "Also store a readable listing of the worker Ignition config served by the machine config server, with the files it writes and their decoded contents, the units it configures and the users it creates. The contents of files that may hold credentials are redacted")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.IncludeStaticPods, "include-static-pods", false, "Also pull the static pod manifests, like those of kube-apiserver and etcd, from the bootstrap host and any directly collected hosts, and list the resources of each static pod revision. The summary names the revision of each static pod. Manifests that may hold credentials are redacted")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.IncludeEtcdSnapshot, "include-etcd-snapshot", false, "Also pull the newest etcd snapshot, or the etcd member database, from the bootstrap host and any directly collected hosts. WARNING: snapshots hold every Secret of the cluster unencrypted, so consider --encrypt-to")
+	cmd.PersistentFlags().Int64Var(&gatherBootstrapOpts.MaxBundleSize, "max-bundle-size", 0, "Split a log bundle larger than this size in bytes into numbered parts (log-bundle-<timestamp>.tar.gz.partNN), with a README.txt next to them on how to reassemble it, for easier transfer. Each part is uploaded with --upload-to. 0 keeps the bundle whole")
+	cmd.PersistentFlags().IntVar(&gatherBootstrapOpts.APIConcurrency, "api-concurrency", tfgather.DefaultAPIConcurrency, "Number of cloud API calls made at the same time when looking up the hosts with the AWS or Azure API. Throttled calls are retried with an exponential backoff, while authentication errors fail at once")
+	cmd.PersistentFlags().Int64Var(&gatherBootstrapOpts.EtcdSnapshotMaxBytes, "etcd-snapshot-max-bytes", 1<<30, "Skip etcd snapshots larger than this size. 0 pulls snapshots of any size")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.IncludeTerraformLog, "include-terraform-log", false, "Also include the terraform provider debug log, which the installer writes when run with TF_LOG=debug and TF_LOG_PATH set, from TF_LOG_PATH or else terraform.log in the assets directory. The bundle is collected even when the bootstrap host was not created, for infrastructure creation failures")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.SerialLog, "serial-log", false, "Also fetch the serial console output of the bootstrap and control plane hosts in the terraform state from the cloud API, using the installer credentials, for hosts which never booted far enough to accept SSH. Supported on AWS, Azure and OpenStack")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.NoSerialFallback, "no-serial-fallback", false, "Do not fetch the serial console output of the hosts from the cloud API when the bootstrap host is unreachable over SSH, which is otherwise done without --serial-log")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.IncludeWindowsNodes, "include-windows-nodes", false, "Also collect event logs and Windows Machine Config Operator artifacts from the Windows nodes listed by the cluster, using Windows OpenSSH")
+	cmd.PersistentFlags().BoolVar(&gatherBootstrapOpts.IncludeManifests, "include-manifests", false, "Include the manifests rendered in the assets directory, with Secret data redacted")
 	return cmd
 }
 
-func runGatherBootstrapCmd(directory string) error {
-	tfStateFilePath := filepath.Join(directory, terraform.StateFileName)
-	_, err := os.Stat(tfStateFilePath)
-	if os.IsNotExist(err) {
-		return unSupportedPlatformGather(directory)
+// gatherBootstrap runs `gather bootstrap` and logs its error, which the
+// exit code is chosen from. The error is returned once the log file is
+// closed, so that exiting does not lose it.
+func gatherBootstrap() error {
+	switch gatherLogFormat {
+	case "text":
+	case "json":
+		gatherBootstrapOpts.StructuredLogs = true
+		setLogFormatter(newJSONFormatter())
+	default:
+		err := fmt.Errorf("%w: invalid --log-format %q, use text or json", gather.ErrUsage, gatherLogFormat)
+		logrus.Error(err)
+		return err
 	}
-	if err != nil {
+	if (gatherBootstrapOpts.DryRun || gatherBootstrapOpts.Estimate) && rootOpts.outputFormat != outputFormatText {
+		// The plan and the estimate are written to stdout, where they
+		// would be interleaved with the events.
+		err := fmt.Errorf("%w: --dry-run and --estimate write plain text to stdout and only support --output-format %s", gather.ErrUsage, outputFormatText)
+		logrus.Error(err)
 		return err
 	}
-
-	assetStore, err := assetstore.NewStore(directory)
+	if !gatherBootstrapOpts.DryRun {
+		var cleanup func()
+		if gatherBootstrapOpts.StructuredLogs {
+			cleanup = setupFileHookWithFormatter(rootOpts.dir, newJSONFormatter())
+		} else {
+			cleanup = setupFileHook(rootOpts.dir)
+		}
+		defer cleanup()
+	}
+	ctx, cancel := signalContext(context.Background())
+	defer cancel()
+	err := runGatherBootstrapCmd(ctx, rootOpts.dir)
 	if err != nil {
-		return errors.Wrap(err, "failed to create asset store")
+		logrus.Error(err)
 	}
+	return err
+}
+
+func runGatherBootstrapCmd(ctx context.Context, directory string) error {
+	_, err := installer.GatherBootstrap(ctx, directory, installer.GatherOptions{Options: gatherBootstrapOpts})
+	return err
+}
+
+// signalContext returns a context which is cancelled on SIGINT or SIGTERM.
+// Further signals are handled as usual, so a second interrupt still kills
+// the installer.
+func signalContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case sig := <-signals:
+			logrus.Warnf("Received %s, stopping the gather", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+func newGatherAnalyzeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "analyze BUNDLE",
+		Short: "Analyze a previously gathered log bundle",
+		Long: `Analyze a previously gathered log bundle.
 
-	config := &installconfig.InstallConfig{}
-	if err := assetStore.Fetch(config); err != nil {
-		return errors.Wrapf(err, "failed to fetch %s", config.Name())
+The analyzers that summarize a bundle improve over time, so running this command
+from a newer installer over an old bundle may produce more useful findings than
+the summary recorded in the bundle itself.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			cleanup := setupFileHook(rootOpts.dir)
+			defer cleanup()
+			err := runGatherAnalyzeCmd(args[0])
+			if err != nil {
+				logrus.Fatal(err)
+			}
+		},
 	}
+}
 
-	tfstate, err := terraform.ReadState(tfStateFilePath)
+func runGatherAnalyzeCmd(bundle string) error {
+	analyzers := gather.DefaultAnalyzers()
+	files, err := gather.ReadBundle(analyzers, bundle)
 	if err != nil {
-		return errors.Wrapf(err, "failed to read state from %q", tfStateFilePath)
+		return fmt.Errorf("failed to read log bundle: %w", err)
 	}
-	bootstrap, port, masters, err := extractHostAddresses(config.Config, tfstate)
-	if err != nil {
-		if err2, ok := err.(errUnSupportedGatherPlatform); ok {
-			logrus.Error(err2)
-			return unSupportedPlatformGather(directory)
-		}
-		return errors.Wrapf(err, "failed to get bootstrap and control plane host addresses from %q", tfStateFilePath)
+
+	summary := &gather.Summary{}
+	gather.Analyze(analyzers, files, summary)
+	logrus.Infof("Analyzer version %d", gather.AnalyzerVersion)
+	for _, line := range summary.Lines() {
+		logrus.Info(line)
 	}
+	return nil
+}
+
+func newGatherDiffCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff BUNDLE_A BUNDLE_B",
+		Short: "Compare two previously gathered log bundles",
+		Long: `Compare two previously gathered log bundles, like those of a passing and a
+failing install.
 
-	return logGatherBootstrap(bootstrap, port, masters, directory)
+Each line of the report describes a difference. Lines starting with "-" describe
+BUNDLE_A, lines starting with "+" describe BUNDLE_B and lines starting with "~"
+describe members whose size changed significantly between them. The bundles are
+compared by their members, by the findings of the analyzers and by the steps
+which failed while gathering them.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(_ *cobra.Command, args []string) {
+			cleanup := setupFileHook(rootOpts.dir)
+			defer cleanup()
+			err := runGatherDiffCmd(args[0], args[1])
+			if err != nil {
+				logrus.Fatal(err)
+			}
+		},
+		Annotations: plainOutput,
+	}
 }
 
-func logGatherBootstrap(bootstrap string, port int, masters []string, directory string) error {
-	logrus.Info("Pulling debug logs from the bootstrap machine")
-	client, err := ssh.NewClient("core", fmt.Sprintf("%s:%d", bootstrap, port), gatherBootstrapOpts.sshKeys)
+func runGatherDiffCmd(a, b string) error {
+	report, err := gather.DiffBundles(a, b, gather.DefaultAnalyzers())
 	if err != nil {
-		return errors.Wrap(err, "failed to create SSH client")
+		return fmt.Errorf("failed to compare log bundles: %w", err)
 	}
-	if err := ssh.Run(client, fmt.Sprintf("/usr/local/bin/installer-gather.sh %s", strings.Join(masters, " "))); err != nil {
-		return errors.Wrap(err, "failed to run remote command")
+	if len(report) == 0 {
+		logrus.Info("The log bundles do not differ")
 	}
-	file := filepath.Join(directory, fmt.Sprintf("log-bundle-%s.tar.gz", time.Now().Format("20060102150405")))
-	if err := ssh.PullFileTo(client, "/home/core/log-bundle.tar.gz", file); err != nil {
-		return errors.Wrap(err, "failed to pull log file from remote")
+	for _, line := range report {
+		fmt.Println(line)
 	}
-	logrus.Infof("Bootstrap gather logs captured here %q", file)
 	return nil
 }
 
-func extractHostAddresses(config *types.InstallConfig, tfstate *terraform.State) (bootstrap string, port int, masters []string, err error) {
-	port = 22
-	switch config.Platform.Name() {
-	case awstypes.Name:
-		bootstrap, err = gatheraws.BootstrapIP(tfstate)
-		if err != nil {
-			return bootstrap, port, masters, err
-		}
-		masters, err = gatheraws.ControlPlaneIPs(tfstate)
-		if err != nil {
-			logrus.Error(err)
-		}
-	case azuretypes.Name:
-		port = 2200
-		bootstrap, err = gatherazure.BootstrapIP(tfstate)
-		if err != nil {
-			return bootstrap, port, masters, err
-		}
-		masters, err = gatherazure.ControlPlaneIPs(tfstate)
-		if err != nil {
-			logrus.Error(err)
-		}
-	case libvirttypes.Name:
-		bootstrap, err = gatherlibvirt.BootstrapIP(tfstate)
-		if err != nil {
-			return bootstrap, port, masters, err
-		}
-		masters, err = gatherlibvirt.ControlPlaneIPs(tfstate)
-		if err != nil {
-			logrus.Error(err)
-		}
-	case openstacktypes.Name:
-		bootstrap, err = gatheropenstack.BootstrapIP(tfstate)
-		if err != nil {
-			return bootstrap, port, masters, err
-		}
-		masters, err = gatheropenstack.ControlPlaneIPs(tfstate)
-		if err != nil {
-			logrus.Error(err)
-		}
-	default:
-		return "", port, nil, errUnSupportedGatherPlatform{Message: fmt.Sprintf("Cannot fetch the bootstrap and control plane host addresses from state file for %s platform", config.Platform.Name())}
+// orphanedResourcesFileName is the file in the assets directory that
+// gather destroy writes the remaining resources to.
+const orphanedResourcesFileName = "orphaned-resources.json"
+
+func newGatherClusterCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cluster",
+		Short: "Gather debugging data for a cluster which bootstrapped but failed to install",
+		Long: `Gather debugging data for a cluster which bootstrapped but failed to install.
+
+The cluster version, the cluster operators, the nodes, the warning events of the
+openshift-* namespaces, and the pods there which are not running, with the end
+of their logs, are collected through the API with the kubeconfig in the assets
+directory. must-gather is run too with the oc binary in PATH. Everything is
+stored in a cluster-bundle-<timestamp>.tar.gz in the assets directory.
+
+Use "gather bootstrap" when the cluster failed to bootstrap.`,
+		Args: cobra.ExactArgs(0),
+		Run: func(_ *cobra.Command, _ []string) {
+			cleanup := setupFileHook(rootOpts.dir)
+			defer cleanup()
+			ctx, cancel := signalContext(context.Background())
+			defer cancel()
+			bundle, err := gatherbootstrap.GatherCluster(ctx, rootOpts.dir, gatherClusterOpts)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			logrus.Infof("Cluster gather logs captured here %q", bundle)
+		},
 	}
-	return bootstrap, port, masters, nil
+	cmd.PersistentFlags().StringVar(&gatherClusterOpts.Kubeconfig, "kubeconfig", "", "Path of the kubeconfig of the cluster. Defaults to auth/kubeconfig in the assets directory")
+	cmd.PersistentFlags().BoolVar(&gatherClusterOpts.SkipMustGather, "skip-must-gather", false, "Collect only the installer resources, without running must-gather, which is faster and smaller")
+	cmd.PersistentFlags().DurationVar(&gatherClusterOpts.MustGatherTimeout, "must-gather-timeout", 10*time.Minute, "Time after which must-gather is stopped and its partial output collected")
+	return cmd
 }
 
-type errUnSupportedGatherPlatform struct {
-	Message string
+func newGatherSerialConsoleCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "serial-console",
+		Short: "Fetch the serial console output of the bootstrap and control plane hosts",
+		Long: `Fetch the serial console output of the bootstrap and control plane hosts.
+
+The output of the hosts in the terraform state of the assets directory is
+fetched from the cloud API of the platform in metadata.json, using the installer
+credentials, into a serial-console-<timestamp> directory in the assets
+directory. No SSH connection is made, so this works for hosts which never booted
+far enough to accept SSH. AWS, Azure and OpenStack are supported.`,
+		Args: cobra.ExactArgs(0),
+		Run: func(_ *cobra.Command, _ []string) {
+			cleanup := setupFileHook(rootOpts.dir)
+			defer cleanup()
+			ctx, cancel := signalContext(context.Background())
+			defer cancel()
+			dir, err := gatherbootstrap.GatherSerialConsole(ctx, rootOpts.dir)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			logrus.Infof("Serial console output captured here %q", dir)
+		},
+	}
 }
 
-func (e errUnSupportedGatherPlatform) Error() string {
-	return e.Message
+func newGatherDestroyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "destroy",
+		Short: "List the cloud resources left behind by a failed destroy",
+		Long: `List the cloud resources left behind by a failed destroy.
+
+The resources still tagged with the infrastructure ID in the metadata.json of
+the assets directory are listed by type in orphaned-resources.json in the assets
+directory, for cleaning up after "destroy cluster" fails. Nothing is deleted.
+Only AWS is supported.`,
+		Args: cobra.ExactArgs(0),
+		Run: func(_ *cobra.Command, _ []string) {
+			cleanup := setupFileHook(rootOpts.dir)
+			defer cleanup()
+			err := runGatherDestroyCmd(rootOpts.dir)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+		},
+	}
 }
 
-func unSupportedPlatformGather(directory string) error {
-	if gatherBootstrapOpts.bootstrap == "" || len(gatherBootstrapOpts.masters) == 0 {
-		return errors.New("boostrap host address and at least one control plane host address must be provided")
+func runGatherDestroyCmd(directory string) error {
+	metadata, err := cluster.LoadMetadata(directory)
+	if err != nil {
+		return err
+	}
+	if platform := metadata.Platform(); platform != awstypes.Name {
+		return fmt.Errorf("%w %q for listing resources", gather.ErrUnsupportedPlatform, platform)
 	}
 
-	return logGatherBootstrap(gatherBootstrapOpts.bootstrap, 22, gatherBootstrapOpts.masters, directory)
+	resources, err := destroy.ListAWS(logrus.StandardLogger(), metadata)
+	if err != nil {
+		return fmt.Errorf("failed to list the resources of %s: %w", metadata.InfraID, err)
+	}
+	data, err := json.MarshalIndent(resources, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(directory, orphanedResourcesFileName)
+	if err := ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+
+	kinds := make([]string, 0, len(resources))
+	for kind := range resources {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		logrus.Infof("%s: %d remaining", kind, len(resources[kind]))
+	}
+	if len(resources) == 0 {
+		logrus.Infof("No resources tagged with %s remain", metadata.InfraID)
+	}
+	logrus.Infof("Remaining resources listed in %q", path)
+	return nil
 }
@@ -33,6 +33,10 @@ func newGraphCmd() *cobra.Command {
 }
 
 func runGraphCmd(cmd *cobra.Command, args []string) error {
+	if graphOpts.outputFile == "" && rootOpts.outputFormat != outputFormatText {
+		return fmt.Errorf("--output-format %s requires --output-file, the graph is otherwise written to stdout", rootOpts.outputFormat)
+	}
+
 	g := gographviz.NewGraph()
 	g.SetName("G")
 	g.SetDir(true)
@@ -4,6 +4,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -47,6 +48,17 @@ func (h *fileHook) Fire(entry *logrus.Entry) error {
 }
 
 func setupFileHook(baseDir string) func() {
+	return setupFileHookWithFormatter(baseDir, &logrus.TextFormatter{
+		DisableColors:          true,
+		DisableTimestamp:       false,
+		FullTimestamp:          true,
+		DisableLevelTruncation: false,
+	})
+}
+
+// setupFileHookWithFormatter is like setupFileHook, but writes the log file
+// with formatter.
+func setupFileHookWithFormatter(baseDir string, formatter logrus.Formatter) func() {
 	if err := os.MkdirAll(baseDir, 0755); err != nil {
 		logrus.Fatal(errors.Wrap(err, "failed to create base directory for logs"))
 	}
@@ -60,12 +72,7 @@ func setupFileHook(baseDir string) func() {
 	for k, v := range logrus.StandardLogger().Hooks {
 		originalHooks[k] = v
 	}
-	logrus.AddHook(newFileHook(logfile, logrus.TraceLevel, &logrus.TextFormatter{
-		DisableColors:          true,
-		DisableTimestamp:       false,
-		FullTimestamp:          true,
-		DisableLevelTruncation: false,
-	}))
+	logrus.AddHook(newFileHook(logfile, logrus.TraceLevel, formatter))
 
 	logrus.Debugf(version.String)
 	if version.Commit != "" {
@@ -77,3 +84,29 @@ func setupFileHook(baseDir string) func() {
 		logrus.StandardLogger().ReplaceHooks(originalHooks)
 	}
 }
+
+// newJSONFormatter returns the formatter of --log-format json, which writes
+// each entry as a JSON object with the time, level and msg fields, along
+// with the fields of the entry.
+func newJSONFormatter() logrus.Formatter {
+	return &logrus.JSONFormatter{
+		TimestampFormat: time.RFC3339Nano,
+		FieldMap: logrus.FieldMap{
+			logrus.FieldKeyTime:  "time",
+			logrus.FieldKeyLevel: "level",
+			logrus.FieldKeyMsg:   "msg",
+		},
+	}
+}
+
+// setLogFormatter makes the hooks writing the log, like the one writing to
+// stderr, use formatter.
+func setLogFormatter(formatter logrus.Formatter) {
+	for _, hooks := range logrus.StandardLogger().Hooks {
+		for _, hook := range hooks {
+			if h, ok := hook.(*fileHook); ok {
+				h.formatter = formatter
+			}
+		}
+	}
+}
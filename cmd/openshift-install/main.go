@@ -17,8 +17,9 @@ import (
 
 var (
 	rootOpts struct {
-		dir      string
-		logLevel string
+		dir          string
+		logLevel     string
+		outputFormat string
 	}
 )
 
@@ -50,6 +51,7 @@ func installerMain() {
 		newGatherCmd(),
 		newVersionCmd(),
 		newGraphCmd(),
+		newExplainCmd(),
 		newCompletionCmd(),
 	} {
 		rootCmd.AddCommand(subCmd)
@@ -62,15 +64,17 @@ func installerMain() {
 
 func newRootCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:              "openshift-install",
-		Short:            "Creates OpenShift clusters",
-		Long:             "",
-		PersistentPreRun: runRootCmd,
-		SilenceErrors:    true,
-		SilenceUsage:     true,
+		Use:               "openshift-install",
+		Short:             "Creates OpenShift clusters",
+		Long:              "",
+		PersistentPreRun:  runRootCmd,
+		PersistentPostRun: runRootPostCmd,
+		SilenceErrors:     true,
+		SilenceUsage:      true,
 	}
 	cmd.PersistentFlags().StringVar(&rootOpts.dir, "dir", ".", "assets directory")
 	cmd.PersistentFlags().StringVar(&rootOpts.logLevel, "log-level", "info", "log level (e.g. \"debug | info | warn | error\")")
+	cmd.PersistentFlags().StringVar(&rootOpts.outputFormat, "output-format", outputFormatText, "format of the events of the command written to stdout, with their phase, time, message and exit code (e.g. \"text | json | yaml\")")
 	return cmd
 }
 
@@ -97,4 +101,12 @@ func runRootCmd(cmd *cobra.Command, args []string) {
 	if err != nil {
 		logrus.Fatal(errors.Wrap(err, "invalid log-level"))
 	}
+
+	if err := setupOutput(cmd, os.Stdout, level, rootOpts.outputFormat); err != nil {
+		logrus.Fatal(errors.Wrap(err, "invalid output-format"))
+	}
+}
+
+func runRootPostCmd(cmd *cobra.Command, args []string) {
+	outputDone()
 }
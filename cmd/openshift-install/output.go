@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// The values of --output-format.
+const (
+	outputFormatText = "text"
+	outputFormatJSON = "json"
+	outputFormatYAML = "yaml"
+)
+
+// plainOutputAnnotation marks the commands, and their subcommands, whose
+// result is plain text written to stdout, like version. They reject the
+// json and yaml --output-format, whose events would be interleaved with
+// that text.
+const plainOutputAnnotation = "openshift-install/plain-output"
+
+// plainOutput are the annotations of the commands writing plain text to
+// stdout.
+var plainOutput = map[string]string{plainOutputAnnotation: "true"}
+
+// event is what --output-format json and yaml write to stdout for each log
+// entry, and for the exit of the command. Its fields are a stable API for
+// the wrappers of the installer, unlike the log messages.
+type event struct {
+	Time    string                 `json:"time"`
+	Level   string                 `json:"level"`
+	Command string                 `json:"command"`
+	Phase   string                 `json:"phase"`
+	Message string                 `json:"message"`
+	Error   string                 `json:"error,omitempty"`
+	Code    *int                   `json:"code,omitempty"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// eventFormatter formats the log entries as the events of a command.
+type eventFormatter struct {
+	// command is the path of the command under openshift-install, like
+	// "create cluster".
+	command string
+	format  string
+}
+
+func (f *eventFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	phase := currentPhase
+	if phase == "" {
+		phase = f.command
+	}
+	ev := event{
+		Time:    entry.Time.UTC().Format(time.RFC3339Nano),
+		Level:   entry.Level.String(),
+		Command: f.command,
+		Phase:   phase,
+		Message: entry.Message,
+	}
+	for key, value := range entry.Data {
+		switch key {
+		case "phase":
+			ev.Phase = fmt.Sprint(value)
+		case logrus.ErrorKey:
+			if err, ok := value.(error); ok {
+				ev.Error = err.Error()
+			} else {
+				ev.Error = fmt.Sprint(value)
+			}
+		case "code":
+			if code, ok := value.(int); ok {
+				ev.Code = &code
+				continue
+			}
+			fallthrough
+		default:
+			if ev.Fields == nil {
+				ev.Fields = map[string]interface{}{}
+			}
+			if err, ok := value.(error); ok {
+				value = err.Error()
+			}
+			ev.Fields[key] = value
+		}
+	}
+	if entry.Level <= logrus.FatalLevel && ev.Code == nil {
+		code := 1
+		ev.Code = &code
+	}
+
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return nil, err
+	}
+	if f.format == outputFormatYAML {
+		data, err = yaml.JSONToYAML(data)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte("---\n"), data...), nil
+	}
+	return append(data, '\n'), nil
+}
+
+// currentPhase is the phase of the events, which is the command when
+// empty.
+var currentPhase string
+
+// setPhase sets the phase of the events of the following log entries, like
+// "bootstrap" while create cluster waits for the bootstrap to complete. An
+// entry can also have its own phase with a "phase" field.
+func setPhase(phase string) {
+	currentPhase = phase
+}
+
+// outputHook writes the events of --output-format to stdout. It is not a
+// fileHook so that setLogFormatter, for --log-format, leaves it alone.
+type outputHook struct {
+	fileHook
+
+	// exited is whether the event of a fatal entry, which carries the
+	// exit code, was written.
+	exited bool
+}
+
+func (h *outputHook) Fire(entry *logrus.Entry) error {
+	if entry.Level <= logrus.FatalLevel {
+		h.exited = true
+	}
+	return h.fileHook.Fire(entry)
+}
+
+// setupOutput makes the log entries of cmd at level or above, and its exit
+// code, written to out as the events of format, which is one of the
+// --output-format values. The text format writes nothing, the log on
+// stderr being the output.
+func setupOutput(cmd *cobra.Command, out io.Writer, level logrus.Level, format string) error {
+	switch format {
+	case outputFormatText:
+		return nil
+	case outputFormatJSON, outputFormatYAML:
+	default:
+		return fmt.Errorf("%q is not one of %s, %s or %s", format, outputFormatText, outputFormatJSON, outputFormatYAML)
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if _, ok := c.Annotations[plainOutputAnnotation]; ok {
+			return fmt.Errorf("%s writes plain text to stdout and only supports %s", cmd.CommandPath(), outputFormatText)
+		}
+	}
+
+	command := strings.TrimPrefix(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()), " ")
+	hook := &outputHook{fileHook: *newFileHook(out, level, &eventFormatter{command: command, format: format})}
+	logrus.AddHook(hook)
+
+	// The entry of logrus.Fatal already carries its code. The other exits,
+	// like those of gather bootstrap with its exit codes, including 1 for
+	// invalid options, get an event of their own, which is not logged on
+	// stderr.
+	logger := logrus.StandardLogger()
+	logger.ExitFunc = func(code int) {
+		if !hook.exited {
+			hook.exit(code)
+		}
+		os.Exit(code)
+	}
+	outputDone = func() { hook.exit(0) }
+	return nil
+}
+
+// outputDone reports the success of the command with --output-format.
+var outputDone = func() {}
+
+// exit writes the event of the exit of the command with code.
+func (h *outputHook) exit(code int) {
+	entry := logrus.WithField("code", code)
+	entry.Time = time.Now()
+	entry.Level = logrus.ErrorLevel
+	entry.Message = "Failed"
+	if code == 0 {
+		entry.Level = logrus.InfoLevel
+		entry.Message = "Completed"
+	}
+	if err := h.Fire(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write the exit event: %v\n", err)
+	}
+}
+
+// exitWithCode ends the command with code, through the logger so that
+// --output-format reports the code.
+func exitWithCode(code int) {
+	logrus.StandardLogger().Exit(code)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configclient "github.com/openshift/client-go/config/clientset/versioned"
+	cov1helpers "github.com/openshift/library-go/pkg/config/clusteroperator/v1helpers"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+// installProgress is the progress of the cluster operators towards the
+// version of the cluster.
+type installProgress struct {
+	// message is the message of the Progressing condition of the cluster
+	// version.
+	message string
+
+	// done is the number of operators which are available at the desired
+	// version, without progressing nor being degraded, out of seen.
+	done int
+
+	// seen is the number of operators reported so far. It is not the
+	// total of the release, as the cluster version operator creates the
+	// operators as it goes through the payload.
+	seen int
+
+	progressing, degraded, unavailable []string
+}
+
+// clusterProgress returns the progress of operators towards the desired
+// version of cv.
+func clusterProgress(cv *configv1.ClusterVersion, operators []configv1.ClusterOperator) installProgress {
+	progress := installProgress{seen: len(operators)}
+	if cond := cov1helpers.FindStatusCondition(cv.Status.Conditions, configv1.OperatorProgressing); cond != nil && cond.Status == configv1.ConditionTrue {
+		progress.message = cond.Message
+	}
+	for _, operator := range operators {
+		conditions := operator.Status.Conditions
+		available := cov1helpers.IsStatusConditionTrue(conditions, configv1.OperatorAvailable)
+		progressing := cov1helpers.IsStatusConditionTrue(conditions, configv1.OperatorProgressing)
+		degraded := cov1helpers.IsStatusConditionTrue(conditions, configv1.OperatorDegraded)
+		if !available {
+			progress.unavailable = append(progress.unavailable, operator.Name)
+		}
+		if progressing {
+			progress.progressing = append(progress.progressing, operator.Name)
+		}
+		if degraded {
+			progress.degraded = append(progress.degraded, operator.Name)
+		}
+		if available && !progressing && !degraded && atVersion(operator, cv.Status.Desired.Version) {
+			progress.done++
+		}
+	}
+	sort.Strings(progress.progressing)
+	sort.Strings(progress.degraded)
+	sort.Strings(progress.unavailable)
+	return progress
+}
+
+// atVersion returns whether operator reports version as its own, which is
+// assumed when it reports no version or there is no desired version yet.
+func atVersion(operator configv1.ClusterOperator, version string) bool {
+	if version == "" {
+		return true
+	}
+	for _, v := range operator.Status.Versions {
+		if v.Name == "operator" {
+			return v.Version == version
+		}
+	}
+	return true
+}
+
+// reportProgress logs the progress of the cluster operators every interval
+// until ctx is done or the returned function is called. The remaining time
+// is estimated from the rate at which the operators have been done since
+// the first report, for the operators seen so far, so it is a lower bound.
+func reportProgress(ctx context.Context, config *rest.Config, interval time.Duration) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	cc, err := configclient.NewForConfig(config)
+	if err != nil {
+		logrus.Debugf("Not reporting the progress of the cluster operators: failed to create a config client: %v", err)
+		return cancel
+	}
+
+	go func() {
+		start := time.Now()
+		startDone := -1
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			cv, err := cc.ConfigV1().ClusterVersions().Get("version", metav1.GetOptions{})
+			if err != nil {
+				logrus.Debugf("Failed to get the cluster version for the progress: %v", err)
+				continue
+			}
+			operators, err := cc.ConfigV1().ClusterOperators().List(metav1.ListOptions{})
+			if err != nil {
+				logrus.Debugf("Failed to list the cluster operators for the progress: %v", err)
+				continue
+			}
+			progress := clusterProgress(cv, operators.Items)
+			if startDone < 0 {
+				start, startDone = time.Now(), progress.done
+			}
+
+			fields := logrus.Fields{
+				"done": progress.done,
+				"seen": progress.seen,
+			}
+			for key, names := range map[string][]string{
+				"progressing": progress.progressing,
+				"degraded":    progress.degraded,
+				"unavailable": progress.unavailable,
+			} {
+				if len(names) > 0 {
+					fields[key] = strings.Join(names, ",")
+				}
+			}
+			estimate := ""
+			if progress.done > startDone && progress.done < progress.seen {
+				elapsed := time.Since(start)
+				remaining := time.Duration(float64(elapsed) * float64(progress.seen-progress.done) / float64(progress.done-startDone)).Round(time.Minute)
+				fields["remaining"] = remaining.String()
+				estimate = fmt.Sprintf(", at least %s remaining", remaining)
+			}
+			if progress.message != "" {
+				fields["cluster_version"] = progress.message
+			}
+			logrus.WithFields(fields).Infof("Cluster operators: %d done of the %d seen so far%s", progress.done, progress.seen, estimate)
+		}
+	}()
+	return cancel
+}
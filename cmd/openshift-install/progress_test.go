@@ -0,0 +1,146 @@
+package main
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// clusterOperator returns the operator name reporting version, when not
+// empty, with the conditions of the given types true and the others false.
+func clusterOperator(name, version string, trueConditions ...configv1.ClusterStatusConditionType) configv1.ClusterOperator {
+	operator := configv1.ClusterOperator{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if version != "" {
+		operator.Status.Versions = []configv1.OperandVersion{{Name: "operator", Version: version}}
+	}
+	for _, conditionType := range []configv1.ClusterStatusConditionType{configv1.OperatorAvailable, configv1.OperatorProgressing, configv1.OperatorDegraded} {
+		status := configv1.ConditionFalse
+		for _, t := range trueConditions {
+			if t == conditionType {
+				status = configv1.ConditionTrue
+			}
+		}
+		operator.Status.Conditions = append(operator.Status.Conditions, configv1.ClusterOperatorStatusCondition{Type: conditionType, Status: status})
+	}
+	return operator
+}
+
+func TestClusterProgress(t *testing.T) {
+	cases := []struct {
+		name      string
+		version   string
+		message   string
+		operators []configv1.ClusterOperator
+		expected  installProgress
+	}{
+		{
+			name:     "no operators",
+			version:  "4.3.0",
+			expected: installProgress{},
+		},
+		{
+			name:    "all done",
+			version: "4.3.0",
+			operators: []configv1.ClusterOperator{
+				clusterOperator("dns", "4.3.0", configv1.OperatorAvailable),
+				clusterOperator("network", "4.3.0", configv1.OperatorAvailable),
+			},
+			expected: installProgress{done: 2, seen: 2},
+		},
+		{
+			name:    "progressing, degraded and unavailable",
+			version: "4.3.0",
+			message: "Working towards 4.3.0: 45% complete",
+			operators: []configv1.ClusterOperator{
+				clusterOperator("network", "4.3.0", configv1.OperatorAvailable),
+				clusterOperator("kube-apiserver", "4.3.0", configv1.OperatorAvailable, configv1.OperatorProgressing),
+				clusterOperator("ingress", "4.3.0", configv1.OperatorDegraded),
+				clusterOperator("authentication", ""),
+			},
+			expected: installProgress{
+				message:     "Working towards 4.3.0: 45% complete",
+				done:        1,
+				seen:        4,
+				progressing: []string{"kube-apiserver"},
+				degraded:    []string{"ingress"},
+				unavailable: []string{"authentication", "ingress"},
+			},
+		},
+		{
+			name:    "previous version",
+			version: "4.3.0",
+			operators: []configv1.ClusterOperator{
+				clusterOperator("dns", "4.2.0", configv1.OperatorAvailable),
+				clusterOperator("network", "4.3.0", configv1.OperatorAvailable),
+			},
+			expected: installProgress{done: 1, seen: 2},
+		},
+		{
+			name: "no desired version",
+			operators: []configv1.ClusterOperator{
+				clusterOperator("dns", "4.2.0", configv1.OperatorAvailable),
+			},
+			expected: installProgress{done: 1, seen: 1},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cv := &configv1.ClusterVersion{}
+			cv.Status.Desired.Version = tc.version
+			if tc.message != "" {
+				cv.Status.Conditions = []configv1.ClusterOperatorStatusCondition{{
+					Type:    configv1.OperatorProgressing,
+					Status:  configv1.ConditionTrue,
+					Message: tc.message,
+				}}
+			}
+			assert.Equal(t, tc.expected, clusterProgress(cv, tc.operators))
+		})
+	}
+}
+
+func TestAtVersion(t *testing.T) {
+	cases := []struct {
+		name     string
+		versions []configv1.OperandVersion
+		version  string
+		expected bool
+	}{
+		{
+			name:     "same version",
+			versions: []configv1.OperandVersion{{Name: "operator", Version: "4.3.0"}},
+			version:  "4.3.0",
+			expected: true,
+		},
+		{
+			name:     "other version",
+			versions: []configv1.OperandVersion{{Name: "operator", Version: "4.2.0"}},
+			version:  "4.3.0",
+		},
+		{
+			name:     "only operand versions",
+			versions: []configv1.OperandVersion{{Name: "kube-apiserver", Version: "1.16.2"}},
+			version:  "4.3.0",
+			expected: true,
+		},
+		{
+			name:     "no versions",
+			version:  "4.3.0",
+			expected: true,
+		},
+		{
+			name:     "no desired version",
+			versions: []configv1.OperandVersion{{Name: "operator", Version: "4.2.0"}},
+			expected: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			operator := configv1.ClusterOperator{}
+			operator.Status.Versions = tc.versions
+			assert.Equal(t, tc.expected, atVersion(operator, tc.version))
+		})
+	}
+}
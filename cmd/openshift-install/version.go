@@ -17,6 +17,8 @@ func newVersionCmd() *cobra.Command {
 		Long:  "",
 		Args:  cobra.ExactArgs(0),
 		RunE:  runVersionCmd,
+
+		Annotations: plainOutput,
 	}
 }
 
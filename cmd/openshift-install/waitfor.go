@@ -3,13 +3,28 @@ package main
 import (
 	"context"
 	"path/filepath"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/openshift/installer/pkg/installer"
 )
 
+var (
+	waitForInstallCompleteOpts struct {
+		// progress reports the progress of the cluster operators every
+		// progressInterval.
+		progress bool
+	}
+)
+
+// progressInterval is the interval of the reports of
+// wait-for install-complete --progress.
+const progressInterval = time.Minute
+
 func newWaitForCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "wait-for",
@@ -44,7 +59,7 @@ func newWaitForBootstrapCompleteCmd() *cobra.Command {
 				logrus.Fatal(errors.Wrap(err, "loading kubeconfig"))
 			}
 
-			err = waitForBootstrapComplete(ctx, config, rootOpts.dir)
+			err = installer.WaitForBootstrapComplete(ctx, config, rootOpts.dir)
 			if err != nil {
 				logrus.Info("Use the following commands to gather logs from the cluster")
 				logrus.Info("openshift-install gather bootstrap --help")
@@ -57,7 +72,7 @@ func newWaitForBootstrapCompleteCmd() *cobra.Command {
 }
 
 func newWaitForInstallCompleteCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "install-complete",
 		Short: "Wait until the cluster is ready",
 		Args:  cobra.ExactArgs(0),
@@ -72,10 +87,17 @@ func newWaitForInstallCompleteCmd() *cobra.Command {
 				logrus.Fatal(errors.Wrap(err, "loading kubeconfig"))
 			}
 
-			err = waitForInstallComplete(ctx, config, rootOpts.dir)
+			if waitForInstallCompleteOpts.progress {
+				stop := reportProgress(ctx, config, progressInterval)
+				defer stop()
+			}
+
+			_, err = installer.WaitForInstallComplete(ctx, config, rootOpts.dir)
 			if err != nil {
 				logrus.Fatal(err)
 			}
 		},
 	}
+	cmd.Flags().BoolVar(&waitForInstallCompleteOpts.progress, "progress", false, "Report every minute how many cluster operators are done, which ones are still progressing, degraded or unavailable, and the estimated remaining time")
+	return cmd
 }
@@ -1,12 +1,24 @@
 #!/usr/bin/env bash
 
 ARTIFACTS="/tmp/artifacts"
+# Truncate each unit journal to its most recent MAX_UNIT_LOG_BYTES bytes; 0 keeps it whole.
+MAX_UNIT_LOG_BYTES="${MAX_UNIT_LOG_BYTES:-0}"
+# Skip the control plane hosts when 0, for callers which collect them directly.
+GATHER_MASTERS="${GATHER_MASTERS:-1}"
+
+function unit_journal() {
+    if [[ "${MAX_UNIT_LOG_BYTES}" -gt 0 ]]; then
+        journalctl --boot --no-pager --output=short --unit="${1}" | tail --bytes="${MAX_UNIT_LOG_BYTES}"
+    else
+        journalctl --boot --no-pager --output=short --unit="${1}"
+    fi
+}
 
 echo "Gathering bootstrap journals ..."
 mkdir -p "${ARTIFACTS}/bootstrap/journals"
 for service in bootkube openshift kubelet crio approve-csr
 do
-    journalctl --boot --no-pager --output=short --unit="${service}" > "${ARTIFACTS}/bootstrap/journals/${service}.log"
+    unit_journal "${service}" > "${ARTIFACTS}/bootstrap/journals/${service}.log"
 done
 
 echo "Gathering bootstrap containers ..."
@@ -96,7 +108,9 @@ wait
 
 echo "Gather remote logs"
 export MASTERS=()
-if [ "$#" -ne 0 ]; then
+if [[ "${GATHER_MASTERS}" -eq 0 ]]; then
+    echo "Skipping the control plane hosts"
+elif [ "$#" -ne 0 ]; then
     MASTERS=( "$@" )
 elif test -s "${ARTIFACTS}/resources/masters.list"; then
     mapfile -t MASTERS < "${ARTIFACTS}/resources/masters.list"
@@ -112,7 +126,7 @@ do
   echo "Collecting info from ${master}"
   scp -o PreferredAuthentications=publickey -o StrictHostKeyChecking=false -o UserKnownHostsFile=/dev/null -q /usr/local/bin/installer-masters-gather.sh "core@${master}:"
   mkdir -p "${ARTIFACTS}/control-plane/${master}"
-  ssh -o PreferredAuthentications=publickey -o StrictHostKeyChecking=false -o UserKnownHostsFile=/dev/null "core@${master}" -C 'sudo ./installer-masters-gather.sh' </dev/null
+  ssh -o PreferredAuthentications=publickey -o StrictHostKeyChecking=false -o UserKnownHostsFile=/dev/null "core@${master}" -C "sudo MAX_UNIT_LOG_BYTES=${MAX_UNIT_LOG_BYTES} ./installer-masters-gather.sh" </dev/null
   scp -o PreferredAuthentications=publickey -o StrictHostKeyChecking=false -o UserKnownHostsFile=/dev/null -r -q "core@${master}:/tmp/artifacts/*" "${ARTIFACTS}/control-plane/${master}/"
 done
 tar cz -C /tmp/artifacts . > ~/log-bundle.tar.gz
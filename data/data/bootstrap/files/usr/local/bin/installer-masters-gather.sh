@@ -1,13 +1,23 @@
 #!/usr/bin/env bash
 
 ARTIFACTS="${1:-/tmp/artifacts}"
+# Truncate each unit journal to its most recent MAX_UNIT_LOG_BYTES bytes; 0 keeps it whole.
+MAX_UNIT_LOG_BYTES="${MAX_UNIT_LOG_BYTES:-0}"
 mkdir -p "${ARTIFACTS}"
 
+function unit_journal() {
+    if [[ "${MAX_UNIT_LOG_BYTES}" -gt 0 ]]; then
+        journalctl --boot --no-pager --output=short --unit="${1}" | tail --bytes="${MAX_UNIT_LOG_BYTES}"
+    else
+        journalctl --boot --no-pager --output=short --unit="${1}"
+    fi
+}
+
 echo "Gathering master journals ..."
 mkdir -p "${ARTIFACTS}/journals"
 for service in kubelet crio
 do
-    journalctl --boot --no-pager --output=short --unit="${service}" > "${ARTIFACTS}/journals/${service}.log"
+    unit_journal "${service}" > "${ARTIFACTS}/journals/${service}.log"
 done
 
 echo "Gathering master containers ..."
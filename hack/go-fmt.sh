@@ -9,6 +9,6 @@ else
     --env IS_CONTAINER=TRUE \
     --volume "${PWD}:/go/src/github.com/openshift/installer:z" \
     --workdir /go/src/github.com/openshift/installer \
-    docker.io/openshift/origin-release:golang-1.10 \
+    docker.io/openshift/origin-release:golang-1.13 \
     ./hack/go-fmt.sh "${@}"
 fi
@@ -0,0 +1,128 @@
+package cluster
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/installer/pkg/asset"
+	"github.com/openshift/installer/pkg/terraform"
+)
+
+// CheckpointFileName is the name of the file which records that the
+// Terraform apply of the cluster failed, so that the next create cluster
+// resumes it from the Terraform state file instead of refusing to run over
+// an existing cluster.
+const CheckpointFileName = "terraform.checkpoint.json"
+
+// stageApply is the stage of the checkpoint of a failed Terraform apply,
+// the only one the cluster is created with.
+const stageApply = "apply"
+
+// checkpoint is the content of CheckpointFileName.
+type checkpoint struct {
+	// Stage is the Terraform stage which failed.
+	Stage string `json:"stage"`
+	Error string `json:"error"`
+	Time  string `json:"time"`
+}
+
+// Checkpoint is an asset that loads the Terraform state of a cluster whose
+// creation failed, when the checkpoint file is in the target directory, for
+// the Cluster asset to resume from. It is consumed once the cluster is
+// created.
+type Checkpoint struct {
+	File *asset.File
+
+	// state is the Terraform state to resume from. It is not kept in the
+	// state file, being loaded with the checkpoint file.
+	state []byte
+}
+
+var _ asset.WritableAsset = (*Checkpoint)(nil)
+
+// Name returns the human-friendly name of the asset.
+func (c *Checkpoint) Name() string {
+	return "Terraform Checkpoint"
+}
+
+// Dependencies returns no dependencies.
+func (c *Checkpoint) Dependencies() []asset.Asset {
+	return []asset.Asset{}
+}
+
+// Generate does nothing, there is no checkpoint until the Terraform apply
+// fails.
+func (c *Checkpoint) Generate(parents asset.Parents) error {
+	return nil
+}
+
+// Files returns the checkpoint file, if any.
+func (c *Checkpoint) Files() []*asset.File {
+	if c.File != nil {
+		return []*asset.File{c.File}
+	}
+	return []*asset.File{}
+}
+
+// Load returns the checkpoint and the Terraform state from disk. A corrupt
+// or stale checkpoint is ignored.
+func (c *Checkpoint) Load(f asset.FileFetcher) (found bool, err error) {
+	file, state, err := loadCheckpoint(f)
+	if err != nil || file == nil {
+		return false, err
+	}
+
+	c.File, c.state = file, state
+	return true, nil
+}
+
+// loadCheckpoint returns the checkpoint file and the Terraform state to
+// resume from, or no file when there is no checkpoint. A checkpoint which
+// cannot be read, or is stale, without a Terraform state to resume from,
+// is ignored with a warning.
+func loadCheckpoint(f asset.FileFetcher) (*asset.File, []byte, error) {
+	file, err := f.FetchByName(CheckpointFileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	var cp checkpoint
+	if err := json.Unmarshal(file.Data, &cp); err != nil {
+		logrus.Warnf("Ignoring the corrupt %s: %v", CheckpointFileName, err)
+		return nil, nil, nil
+	}
+	if cp.Stage != stageApply {
+		logrus.Warnf("Ignoring %s, which records the unknown stage %q", CheckpointFileName, cp.Stage)
+		return nil, nil, nil
+	}
+	state, err := f.FetchByName(terraform.StateFileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			logrus.Warnf("Ignoring the stale %s, there is no %s to resume the %s stage from", CheckpointFileName, terraform.StateFileName, cp.Stage)
+			return nil, nil, nil
+		}
+		return nil, nil, errors.Wrapf(err, "failed to load the Terraform state to resume the %s stage from", cp.Stage)
+	}
+	return file, state.Data, nil
+}
+
+// newCheckpointFile returns the checkpoint file of the failure of stage
+// with stageErr.
+func newCheckpointFile(stage string, stageErr error) (*asset.File, error) {
+	data, err := json.MarshalIndent(checkpoint{
+		Stage: stage,
+		Error: stageErr.Error(),
+		Time:  time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &asset.File{Filename: CheckpointFileName, Data: data}, nil
+}
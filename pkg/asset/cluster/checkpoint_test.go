@@ -0,0 +1,169 @@
+package cluster
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/installer/pkg/asset"
+	"github.com/openshift/installer/pkg/asset/mock"
+	"github.com/openshift/installer/pkg/terraform"
+)
+
+const validCheckpoint = `{"stage": "apply", "error": "failed to create cluster", "time": "2019-10-01T00:00:00Z"}`
+
+// dirFetcher fetches the files of a directory, like the asset store.
+type dirFetcher string
+
+func (d dirFetcher) FetchByName(name string) (*asset.File, error) {
+	data, err := ioutil.ReadFile(filepath.Join(string(d), name))
+	if err != nil {
+		return nil, err
+	}
+	return &asset.File{Filename: name, Data: data}, nil
+}
+
+func (d dirFetcher) FetchByPattern(pattern string) ([]*asset.File, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestCheckpointLoad(t *testing.T) {
+	notExist := &os.PathError{Op: "open", Path: "missing", Err: os.ErrNotExist}
+	cases := []struct {
+		name          string
+		checkpoint    string
+		checkpointErr error
+		state         string
+		stateErr      error
+		expectedFound bool
+		expectedError bool
+	}{
+		{
+			name:          "no checkpoint",
+			checkpointErr: notExist,
+		},
+		{
+			name:          "checkpoint",
+			checkpoint:    validCheckpoint,
+			state:         `{"version": 4}`,
+			expectedFound: true,
+		},
+		{
+			name:       "corrupt checkpoint",
+			checkpoint: `{"stage": `,
+			state:      `{"version": 4}`,
+		},
+		{
+			name:       "unknown stage",
+			checkpoint: `{"stage": "bootstrap"}`,
+			state:      `{"version": 4}`,
+		},
+		{
+			name:       "stale checkpoint",
+			checkpoint: validCheckpoint,
+			stateErr:   notExist,
+		},
+		{
+			name:          "unreadable state",
+			checkpoint:    validCheckpoint,
+			stateErr:      errors.New("permission denied"),
+			expectedError: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			fileFetcher := mock.NewMockFileFetcher(mockCtrl)
+			fileFetcher.EXPECT().FetchByName(CheckpointFileName).
+				Return(&asset.File{Filename: CheckpointFileName, Data: []byte(tc.checkpoint)}, tc.checkpointErr)
+			fileFetcher.EXPECT().FetchByName(terraform.StateFileName).
+				Return(&asset.File{Filename: terraform.StateFileName, Data: []byte(tc.state)}, tc.stateErr).
+				AnyTimes()
+
+			checkpoint := &Checkpoint{}
+			found, err := checkpoint.Load(fileFetcher)
+			assert.Equal(t, tc.expectedFound, found, "unexpected found value returned from Load")
+			if tc.expectedError {
+				assert.Error(t, err, "expected error from Load")
+			} else {
+				assert.NoError(t, err, "unexpected error from Load")
+			}
+			if tc.expectedFound {
+				assert.Equal(t, []byte(tc.state), checkpoint.state)
+				assert.Len(t, checkpoint.Files(), 1)
+			} else {
+				assert.Empty(t, checkpoint.Files())
+			}
+		})
+	}
+}
+
+func TestClusterCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cluster-checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, "apply", terraform.StateFileName)
+	if err := os.Mkdir(filepath.Dir(stateFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(stateFile, []byte(`{"version": 4}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	assets := filepath.Join(dir, "assets")
+
+	// The failed apply writes the checkpoint with the state.
+	failed := &Cluster{}
+	assert.Error(t, failed.addApplyFiles(stateFile, errors.New("rate limited")))
+	if assert.Len(t, failed.Files(), 2) {
+		assert.Equal(t, CheckpointFileName, failed.Files()[0].Filename)
+		assert.Contains(t, string(failed.Files()[0].Data), "rate limited")
+		assert.Equal(t, terraform.StateFileName, failed.Files()[1].Filename)
+	}
+	if err := asset.PersistToFile(failed, assets); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next create cluster resumes from the state.
+	checkpoint := &Checkpoint{}
+	found, err := checkpoint.Load(dirFetcher(assets))
+	assert.NoError(t, err)
+	assert.True(t, found, "the checkpoint was not loaded")
+	assert.Equal(t, []byte(`{"version": 4}`), checkpoint.state)
+	found, err = (&Cluster{}).Load(dirFetcher(assets))
+	assert.NoError(t, err)
+	assert.False(t, found, "the cluster with a checkpoint was not generated again")
+
+	// Once the apply succeeds, the consumed checkpoint is purged.
+	created := &Cluster{}
+	assert.NoError(t, created.addApplyFiles(stateFile, nil))
+	if assert.Len(t, created.Files(), 1) {
+		assert.Equal(t, terraform.StateFileName, created.Files()[0].Filename)
+	}
+	if err := asset.DeleteAssetFromDisk(checkpoint, assets); err != nil {
+		t.Fatal(err)
+	}
+	if err := asset.PersistToFile(created, assets); err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.Stat(filepath.Join(assets, CheckpointFileName))
+	assert.True(t, os.IsNotExist(err), "the checkpoint was not purged")
+	found, err = (&Cluster{}).Load(dirFetcher(assets))
+	assert.Error(t, err, "the created cluster was generated again")
+	assert.True(t, found)
+
+	// A corrupt checkpoint does not let the cluster be created again.
+	if err := ioutil.WriteFile(filepath.Join(assets, CheckpointFileName), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = (&Cluster{}).Load(dirFetcher(assets))
+	assert.Error(t, err, "the cluster with a corrupt checkpoint was generated again")
+}
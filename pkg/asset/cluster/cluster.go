@@ -11,6 +11,7 @@ import (
 
 	"github.com/openshift/installer/pkg/asset"
 	"github.com/openshift/installer/pkg/asset/installconfig"
+	awsconfig "github.com/openshift/installer/pkg/asset/installconfig/aws"
 	"github.com/openshift/installer/pkg/asset/password"
 	"github.com/openshift/installer/pkg/terraform"
 )
@@ -40,6 +41,7 @@ func (c *Cluster) Dependencies() []asset.Asset {
 		&installconfig.PlatformCredsCheck{},
 		&TerraformVariables{},
 		&password.KubeadminPassword{},
+		&Checkpoint{},
 	}
 }
 
@@ -48,12 +50,23 @@ func (c *Cluster) Generate(parents asset.Parents) (err error) {
 	clusterID := &installconfig.ClusterID{}
 	installConfig := &installconfig.InstallConfig{}
 	terraformVariables := &TerraformVariables{}
-	parents.Get(clusterID, installConfig, terraformVariables)
+	checkpoint := &Checkpoint{}
+	parents.Get(clusterID, installConfig, terraformVariables, checkpoint)
 
 	if installConfig.Config.Platform.None != nil {
 		return errors.New("cluster cannot be created with platform set to 'none'")
 	}
 
+	if installConfig.Config.Platform.AWS != nil {
+		// The credentials of the providers are kept out of the Terraform
+		// variables, which are written to the assets directory.
+		restoreEnv, err := awsconfig.SetProvidedCredentialsEnv()
+		if err != nil {
+			return err
+		}
+		defer restoreEnv()
+	}
+
 	// Copy the terraform.tfvars to a temp directory where the terraform will be invoked within.
 	tmpDir, err := ioutil.TempDir("", "openshift-install-")
 	if err != nil {
@@ -69,16 +82,39 @@ func (c *Cluster) Generate(parents asset.Parents) (err error) {
 		extraArgs = append(extraArgs, fmt.Sprintf("-var-file=%s", filepath.Join(tmpDir, file.Filename)))
 	}
 
-	logrus.Infof("Creating infrastructure resources...")
+	if checkpoint.state != nil {
+		// Terraform creates only the resources missing from the state of
+		// the failed apply.
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, terraform.StateFileName), checkpoint.state, 0600); err != nil {
+			return err
+		}
+		logrus.Infof("Resuming the creation of the infrastructure resources which failed before...")
+	} else {
+		logrus.Infof("Creating infrastructure resources...")
+	}
 	stateFile, err := terraform.Apply(tmpDir, installConfig.Config.Platform.Name(), extraArgs...)
-	if err != nil {
-		err = errors.Wrap(err, "failed to create cluster")
+	return c.addApplyFiles(stateFile, err)
+}
+
+// addApplyFiles adds the Terraform state file written by the apply to the
+// files of the cluster, along with the checkpoint to resume from when the
+// apply failed with applyErr, and returns the error of the generation.
+func (c *Cluster) addApplyFiles(stateFile string, applyErr error) error {
+	var err error
+	if applyErr != nil {
+		err = errors.Wrap(applyErr, "failed to create cluster")
 		if stateFile == "" {
 			return err
 		}
 		// Store the error from the apply, but continue with the
 		// generation so that the Terraform state file is recovered from
-		// the temporary directory.
+		// the temporary directory, along with the checkpoint for the next
+		// create cluster to resume from.
+		if file, err2 := newCheckpointFile(stageApply, err); err2 == nil {
+			c.FileList = append(c.FileList, file)
+		} else {
+			logrus.Errorf("Failed to create the Terraform checkpoint: %v", err2)
+		}
 	}
 
 	data, err2 := ioutil.ReadFile(stateFile)
@@ -102,7 +138,8 @@ func (c *Cluster) Files() []*asset.File {
 }
 
 // Load returns error if the tfstate file is already on-disk, because we want to
-// prevent user from accidentally re-launching the cluster.
+// prevent user from accidentally re-launching the cluster, unless it is the state
+// of a failed creation to resume from, with the Terraform checkpoint.
 func (c *Cluster) Load(f asset.FileFetcher) (found bool, err error) {
 	_, err = f.FetchByName(terraform.StateFileName)
 	if err != nil {
@@ -112,5 +149,11 @@ func (c *Cluster) Load(f asset.FileFetcher) (found bool, err error) {
 		return false, err
 	}
 
+	if checkpoint, _, err := loadCheckpoint(f); err != nil {
+		return false, err
+	} else if checkpoint != nil {
+		return false, nil
+	}
+
 	return true, errors.Errorf("%q already exists.  There may already be a running cluster", terraform.StateFileName)
 }
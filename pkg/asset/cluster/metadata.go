@@ -101,7 +101,11 @@ func (m *Metadata) Load(f asset.FileFetcher) (found bool, err error) {
 
 // LoadMetadata loads the cluster metadata from an asset directory.
 func LoadMetadata(dir string) (*types.ClusterMetadata, error) {
-	path := filepath.Join(dir, metadataFileName)
+	return LoadMetadataFile(filepath.Join(dir, metadataFileName))
+}
+
+// LoadMetadataFile loads the cluster metadata from the file at path.
+func LoadMetadataFile(path string) (*types.ClusterMetadata, error) {
 	raw, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, err
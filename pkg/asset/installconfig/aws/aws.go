@@ -12,6 +12,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/defaults"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
+	installcreds "github.com/openshift/installer/pkg/asset/installconfig/credentials"
 	"github.com/openshift/installer/pkg/types/aws"
 	"github.com/openshift/installer/pkg/types/aws/validation"
 	"github.com/openshift/installer/pkg/version"
@@ -86,21 +87,30 @@ func Platform() (*aws.Platform, error) {
 	}, nil
 }
 
-// GetSession returns an AWS session by checking credentials
-// and, if no creds are found, asks for them and stores them on disk in a config file
+// GetSession returns an AWS session by checking credentials, first those of
+// the credential providers, and, if no creds are found, asks for them and
+// stores them on disk in a config file
 func GetSession() (*session.Session, error) {
 	ssn := session.Must(session.NewSessionWithOptions(session.Options{
 		SharedConfigState: session.SharedConfigEnable,
 	}))
-	ssn.Config.Credentials = credentials.NewChainCredentials([]credentials.Provider{
-		&credentials.EnvProvider{},
-		&credentials.SharedCredentialsProvider{},
-	})
-	_, err := ssn.Config.Credentials.Get()
-	if err == credentials.ErrNoValidProvidersFoundInChain {
-		err = getCredentials()
-		if err != nil {
-			return nil, err
+	provided, err := ProvidedCredentials()
+	if err != nil {
+		return nil, err
+	}
+	if provided != nil {
+		ssn.Config.Credentials = credentials.NewStaticCredentialsFromCreds(*provided)
+	} else {
+		ssn.Config.Credentials = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvProvider{},
+			&credentials.SharedCredentialsProvider{},
+		})
+		_, err := ssn.Config.Credentials.Get()
+		if err == credentials.ErrNoValidProvidersFoundInChain {
+			err = getCredentials()
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 	ssn.Handlers.Build.PushBackNamed(request.NamedHandler{
@@ -110,6 +120,72 @@ func GetSession() (*session.Session, error) {
 	return ssn, nil
 }
 
+// ProvidedCredentials returns the AWS credentials of the credential
+// providers, under the aws_access_key_id, aws_secret_access_key and
+// optional aws_session_token keys, or nil when none has them.
+func ProvidedCredentials() (*credentials.Value, error) {
+	values, provider, err := installcreds.Lookup(aws.Name)
+	if err == installcreds.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := installcreds.Require(values, aws.Name, provider, "aws_access_key_id", "aws_secret_access_key"); err != nil {
+		return nil, err
+	}
+	logrus.Debugf("Using the AWS credentials from %s", provider)
+	return &credentials.Value{
+		AccessKeyID:     values["aws_access_key_id"],
+		SecretAccessKey: values["aws_secret_access_key"],
+		SessionToken:    values["aws_session_token"],
+		ProviderName:    provider,
+	}, nil
+}
+
+// SetProvidedCredentialsEnv sets the AWS credentials of the credential
+// providers, if any, in the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN environment variables, which Terraform and its AWS
+// provider inherit, so that they are not written to the Terraform
+// variables. It returns the function restoring the environment.
+func SetProvidedCredentialsEnv() (func(), error) {
+	provided, err := ProvidedCredentials()
+	if err != nil || provided == nil {
+		return func() {}, err
+	}
+	env := map[string]string{
+		"AWS_ACCESS_KEY_ID":     provided.AccessKeyID,
+		"AWS_SECRET_ACCESS_KEY": provided.SecretAccessKey,
+		"AWS_SESSION_TOKEN":     provided.SessionToken,
+	}
+	var restores []func()
+	for key, value := range env {
+		restores = append(restores, setenv(key, value))
+	}
+	return func() {
+		for _, restore := range restores {
+			restore()
+		}
+	}, nil
+}
+
+// setenv sets key to value, or unsets it when value is empty, and returns
+// the function restoring its previous value.
+func setenv(key, value string) func() {
+	old, ok := os.LookupEnv(key)
+	if value == "" {
+		os.Unsetenv(key)
+	} else {
+		os.Setenv(key, value)
+	}
+	return func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+}
+
 func getCredentials() error {
 	var keyID string
 	err := survey.Ask([]*survey.Question{
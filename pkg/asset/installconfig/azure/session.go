@@ -9,6 +9,8 @@ import (
 	"github.com/Azure/go-autorest/autorest"
 	azureenv "github.com/Azure/go-autorest/autorest/azure"
 	"github.com/Azure/go-autorest/autorest/azure/auth"
+	installcreds "github.com/openshift/installer/pkg/asset/installconfig/credentials"
+	"github.com/openshift/installer/pkg/types/azure"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/AlecAivazis/survey.v1"
@@ -32,9 +34,14 @@ type Credentials struct {
 	TenantID       string `json:"tenantId,omitempty"`
 }
 
-// GetSession returns an azure session by using credentials found in ~/.azure/osServicePrincipal.json
-// and, if no creds are found, asks for them and stores them on disk in a config file
+// GetSession returns an azure session by using credentials of the credential providers or found in
+// ~/.azure/osServicePrincipal.json and, if no creds are found, asks for them and stores them on disk in a config file
 func GetSession() (*Session, error) {
+	session, err := newSessionFromProviders()
+	if err != nil || session != nil {
+		return session, err
+	}
+
 	authFile := defaultAuthFilePath
 	if f := os.Getenv(azureAuthEnv); len(f) > 0 {
 		authFile = f
@@ -42,6 +49,39 @@ func GetSession() (*Session, error) {
 	return newSessionFromFile(authFile)
 }
 
+// newSessionFromProviders returns a session with the credentials of the credential providers, under the
+// keys of osServicePrincipal.json, or nil when none has them.
+func newSessionFromProviders() (*Session, error) {
+	values, provider, err := installcreds.Lookup(azure.Name)
+	if err == installcreds.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := installcreds.Require(values, azure.Name, provider, "subscriptionId", "clientId", "clientSecret", "tenantId"); err != nil {
+		return nil, err
+	}
+	logrus.Debugf("Using the azure credentials from %s", provider)
+
+	credentials := Credentials{
+		SubscriptionID: values["subscriptionId"],
+		ClientID:       values["clientId"],
+		ClientSecret:   values["clientSecret"],
+		TenantID:       values["tenantId"],
+	}
+	config := auth.NewClientCredentialsConfig(credentials.ClientID, credentials.ClientSecret, credentials.TenantID)
+	config.Resource = azureenv.PublicCloud.ResourceManagerEndpoint
+	authorizer, err := config.Authorizer()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get client credentials authorizer from the credentials of %s", provider)
+	}
+
+	return &Session{
+		Authorizer:  authorizer,
+		Credentials: credentials,
+	}, nil
+}
+
 func newSessionFromFile(authFilePath string) (*Session, error) {
 	// NewAuthorizerFromFileWithResource uses `auth.GetSettingsFromFile`, which uses the `azureAuthEnv` to fetch the auth credentials.
 	// therefore setting the local env here to authFilePath allows NewAuthorizerFromFileWithResource to load credentials.
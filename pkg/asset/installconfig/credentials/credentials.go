@@ -0,0 +1,137 @@
+// Package credentials provides the sources of the platform credentials
+// other than the files and environment variables each platform reads by
+// default, like secret stores.
+package credentials
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// CommandEnv is the environment variable with the command of the command
+// provider. The command is run by sh with the name of the platform in
+// PlatformEnv, and writes the credentials to its stdout as a JSON object of
+// strings, which is empty when it has none for the platform.
+const CommandEnv = "OPENSHIFT_INSTALL_CREDENTIALS_COMMAND"
+
+// PlatformEnv is the environment variable with the name of the platform
+// whose credentials the command of CommandEnv is asked for.
+const PlatformEnv = "OPENSHIFT_INSTALL_PLATFORM"
+
+// ErrNotFound is returned by the providers which have no credentials for a
+// platform.
+var ErrNotFound = errors.New("no credentials found")
+
+// Provider is a source of platform credentials.
+type Provider interface {
+	// Name identifies the provider in the logs and errors.
+	Name() string
+
+	// Credentials returns the credentials of platform, keyed as the
+	// platform documents, or ErrNotFound when the provider has none for
+	// it.
+	Credentials(platform string) (map[string]string, error)
+}
+
+var (
+	mu        sync.Mutex
+	providers []Provider
+	cache     = map[string]lookup{}
+)
+
+type lookup struct {
+	values   map[string]string
+	provider string
+	err      error
+}
+
+// Register adds provider to the providers which Lookup asks, after the ones
+// already registered.
+func Register(provider Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers = append(providers, provider)
+	cache = map[string]lookup{}
+}
+
+// Lookup returns the credentials of platform from the first provider which
+// has them, asking the registered providers and then the command of
+// CommandEnv, along with the name of the provider. It returns ErrNotFound
+// when none has them, in which case the platform reads its files and
+// environment variables as usual. The result is kept for the following
+// lookups of platform.
+func Lookup(platform string) (map[string]string, string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if l, ok := cache[platform]; ok {
+		return l.values, l.provider, l.err
+	}
+
+	l := lookup{err: ErrNotFound}
+	candidates := providers
+	if command := os.Getenv(CommandEnv); command != "" {
+		candidates = append(candidates[:len(candidates):len(candidates)], &commandProvider{command: command})
+	}
+	for _, provider := range candidates {
+		values, err := provider.Credentials(platform)
+		if err == ErrNotFound {
+			continue
+		}
+		l = lookup{values: values, provider: provider.Name(), err: err}
+		if err != nil {
+			l.err = errors.Wrapf(err, "failed to get the %s credentials from %s", platform, provider.Name())
+		}
+		break
+	}
+	cache[platform] = l
+	return l.values, l.provider, l.err
+}
+
+// Require returns an error naming the keys missing from values, the
+// credentials of platform from provider.
+func Require(values map[string]string, platform, provider string, keys ...string) error {
+	var missing []string
+	for _, key := range keys {
+		if values[key] == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("the %s credentials from %s are missing %q", platform, provider, missing)
+	}
+	return nil
+}
+
+// commandProvider runs the command of CommandEnv.
+type commandProvider struct {
+	command string
+}
+
+func (p *commandProvider) Name() string {
+	return CommandEnv
+}
+
+func (p *commandProvider) Credentials(platform string) (map[string]string, error) {
+	var stdout bytes.Buffer
+	cmd := exec.Command("sh", "-c", p.command)
+	cmd.Env = append(os.Environ(), PlatformEnv+"="+platform)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "failed to run %q", p.command)
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal(stdout.Bytes(), &values); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the output of %q as a JSON object of strings", p.command)
+	}
+	if len(values) == 0 {
+		return nil, ErrNotFound
+	}
+	return values, nil
+}
@@ -0,0 +1,121 @@
+package credentials
+
+import (
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeProvider struct {
+	name   string
+	values map[string]map[string]string
+	err    error
+	calls  int
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) Credentials(platform string) (map[string]string, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	if values, ok := p.values[platform]; ok {
+		return values, nil
+	}
+	return nil, ErrNotFound
+}
+
+func TestLookup(t *testing.T) {
+	cases := []struct {
+		name      string
+		providers []Provider
+		command   string
+		expected  map[string]string
+		provider  string
+		err       string
+	}{{
+		name: "none",
+		err:  ErrNotFound.Error(),
+	}, {
+		name: "first with the platform",
+		providers: []Provider{
+			&fakeProvider{name: "vault", values: map[string]map[string]string{"azure": {"clientId": "a"}}},
+			&fakeProvider{name: "sso", values: map[string]map[string]string{"aws": {"aws_access_key_id": "b"}}},
+			&fakeProvider{name: "other", values: map[string]map[string]string{"aws": {"aws_access_key_id": "c"}}},
+		},
+		expected: map[string]string{"aws_access_key_id": "b"},
+		provider: "sso",
+	}, {
+		name:      "provider error",
+		providers: []Provider{&fakeProvider{name: "vault", err: errors.New("permission denied")}},
+		err:       "failed to get the aws credentials from vault: permission denied",
+	}, {
+		name:     "command",
+		command:  `echo "{\"aws_access_key_id\": \"$OPENSHIFT_INSTALL_PLATFORM\"}"`,
+		expected: map[string]string{"aws_access_key_id": "aws"},
+		provider: CommandEnv,
+	}, {
+		name:      "registered before command",
+		providers: []Provider{&fakeProvider{name: "vault", values: map[string]map[string]string{"aws": {"aws_access_key_id": "a"}}}},
+		command:   `echo '{"aws_access_key_id": "b"}'`,
+		expected:  map[string]string{"aws_access_key_id": "a"},
+		provider:  "vault",
+	}, {
+		name:    "command without credentials",
+		command: "echo '{}'",
+		err:     ErrNotFound.Error(),
+	}, {
+		name:    "command failure",
+		command: "exit 3",
+		err:     `failed to get the aws credentials from OPENSHIFT_INSTALL_CREDENTIALS_COMMAND: failed to run "exit 3": exit status 3`,
+	}, {
+		name:    "command output",
+		command: "echo secret",
+		err:     `failed to get the aws credentials from OPENSHIFT_INSTALL_CREDENTIALS_COMMAND: failed to parse the output of "echo secret" as a JSON object of strings: invalid character 's' looking for beginning of value`,
+	}}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			providers = nil
+			cache = map[string]lookup{}
+			for _, p := range tc.providers {
+				Register(p)
+			}
+			os.Setenv(CommandEnv, tc.command)
+			defer os.Unsetenv(CommandEnv)
+
+			values, provider, err := Lookup("aws")
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, values)
+			assert.Equal(t, tc.provider, provider)
+		})
+	}
+}
+
+func TestLookupCached(t *testing.T) {
+	providers = nil
+	cache = map[string]lookup{}
+	p := &fakeProvider{name: "vault", values: map[string]map[string]string{"aws": {"aws_access_key_id": "a"}}}
+	Register(p)
+	for i := 0; i < 2; i++ {
+		_, _, err := Lookup("aws")
+		assert.NoError(t, err)
+		_, _, err = Lookup("azure")
+		assert.Equal(t, ErrNotFound, err)
+	}
+	assert.Equal(t, 2, p.calls)
+}
+
+func TestRequire(t *testing.T) {
+	values := map[string]string{"clientId": "a", "clientSecret": ""}
+	assert.NoError(t, Require(values, "azure", "vault", "clientId"))
+	assert.EqualError(t, Require(values, "azure", "vault", "clientId", "clientSecret", "tenantId"), `the azure credentials from vault are missing ["clientSecret" "tenantId"]`)
+}
@@ -9,6 +9,20 @@ import (
 
 // NewAWS returns an AWS destroyer from ClusterMetadata.
 func NewAWS(logger logrus.FieldLogger, metadata *types.ClusterMetadata) (Destroyer, error) {
+	return newAWSUninstaller(logger, metadata)
+}
+
+// ListAWS returns the AWS resources of the cluster described by
+// ClusterMetadata which still exist, keyed by their type.
+func ListAWS(logger logrus.FieldLogger, metadata *types.ClusterMetadata) (map[string][]string, error) {
+	uninstaller, err := newAWSUninstaller(logger, metadata)
+	if err != nil {
+		return nil, err
+	}
+	return uninstaller.List()
+}
+
+func newAWSUninstaller(logger logrus.FieldLogger, metadata *types.ClusterMetadata) (*aws.ClusterUninstaller, error) {
 	filters := make([]aws.Filter, 0, len(metadata.ClusterPlatformMetadata.AWS.Identifier))
 	for _, filter := range metadata.ClusterPlatformMetadata.AWS.Identifier {
 		filters = append(filters, filter)
@@ -77,35 +77,11 @@ func (o *ClusterUninstaller) Run() error {
 		return err
 	}
 
-	awsConfig := &aws.Config{Region: aws.String(o.Region)}
-	awsSession := o.Session
-	if awsSession == nil {
-		// Relying on appropriate AWS ENV vars (eg AWS_PROFILE, AWS_ACCESS_KEY_ID, etc)
-		awsSession, err = session.NewSession(awsConfig)
-		if err != nil {
-			return err
-		}
-	} else {
-		awsSession = awsSession.Copy(awsConfig)
-	}
-	awsSession.Handlers.Build.PushBackNamed(request.NamedHandler{
-		Name: "openshiftInstaller.OpenshiftInstallerUserAgentHandler",
-		Fn:   request.MakeAddToUserAgentHandler("OpenShift/4.x Destroyer", version.Raw),
-	})
-
-	tagClients := []*resourcegroupstaggingapi.ResourceGroupsTaggingAPI{
-		resourcegroupstaggingapi.New(awsSession),
-	}
-	tagClientNames := map[*resourcegroupstaggingapi.ResourceGroupsTaggingAPI]string{
-		tagClients[0]: o.Region,
-	}
-	if o.Region != "us-east-1" {
-		tagClient := resourcegroupstaggingapi.New(
-			awsSession, aws.NewConfig().WithRegion("us-east-1"),
-		)
-		tagClients = append(tagClients, tagClient)
-		tagClientNames[tagClient] = "us-east-1"
+	awsSession, err := o.session()
+	if err != nil {
+		return err
 	}
+	tagClients, tagClientNames := o.tagClients(awsSession)
 
 	deleted := map[string]struct{}{}
 	iamClient := iam.New(awsSession)
@@ -238,6 +214,47 @@ func (o *ClusterUninstaller) Run() error {
 	return nil
 }
 
+// session returns the AWS session for the region of the cluster.
+func (o *ClusterUninstaller) session() (*session.Session, error) {
+	awsConfig := &aws.Config{Region: aws.String(o.Region)}
+	awsSession := o.Session
+	if awsSession == nil {
+		// Relying on appropriate AWS ENV vars (eg AWS_PROFILE, AWS_ACCESS_KEY_ID, etc)
+		var err error
+		awsSession, err = session.NewSession(awsConfig)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		awsSession = awsSession.Copy(awsConfig)
+	}
+	awsSession.Handlers.Build.PushBackNamed(request.NamedHandler{
+		Name: "openshiftInstaller.OpenshiftInstallerUserAgentHandler",
+		Fn:   request.MakeAddToUserAgentHandler("OpenShift/4.x Destroyer", version.Raw),
+	})
+	return awsSession, nil
+}
+
+// tagClients returns the tagging clients for the region of the cluster
+// and, for global resources like Route 53 zones, us-east-1, along with the
+// region of each client.
+func (o *ClusterUninstaller) tagClients(awsSession *session.Session) ([]*resourcegroupstaggingapi.ResourceGroupsTaggingAPI, map[*resourcegroupstaggingapi.ResourceGroupsTaggingAPI]string) {
+	tagClients := []*resourcegroupstaggingapi.ResourceGroupsTaggingAPI{
+		resourcegroupstaggingapi.New(awsSession),
+	}
+	tagClientNames := map[*resourcegroupstaggingapi.ResourceGroupsTaggingAPI]string{
+		tagClients[0]: o.Region,
+	}
+	if o.Region != "us-east-1" {
+		tagClient := resourcegroupstaggingapi.New(
+			awsSession, aws.NewConfig().WithRegion("us-east-1"),
+		)
+		tagClients = append(tagClients, tagClient)
+		tagClientNames[tagClient] = "us-east-1"
+	}
+	return tagClients, tagClientNames
+}
+
 func splitSlash(name string, input string) (base string, suffix string, err error) {
 	segments := strings.SplitN(input, "/", 2)
 	if len(segments) != 2 {
@@ -0,0 +1,100 @@
+package aws
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/pkg/errors"
+)
+
+// List returns the ARNs of the resources matching the filters, without
+// deleting them, keyed by their service and resource type (e.g.
+// "ec2:instance", or "s3" for buckets). It finds the same resources as
+// Run, apart from the untagged resources which Run deletes along with
+// their tagged parents.
+func (o *ClusterUninstaller) List() (map[string][]string, error) {
+	err := o.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	awsSession, err := o.session()
+	if err != nil {
+		return nil, err
+	}
+	tagClients, tagClientNames := o.tagClients(awsSession)
+
+	var arns []string
+	for _, tagClient := range tagClients {
+		for _, filter := range o.Filters {
+			o.Logger.Debugf("search for matching resources by tag in %s matching %#+v", tagClientNames[tagClient], filter)
+			tagFilters := make([]*resourcegroupstaggingapi.TagFilter, 0, len(filter))
+			for key, value := range filter {
+				tagFilters = append(tagFilters, &resourcegroupstaggingapi.TagFilter{
+					Key:    aws.String(key),
+					Values: []*string{aws.String(value)},
+				})
+			}
+			err = tagClient.GetResourcesPages(
+				&resourcegroupstaggingapi.GetResourcesInput{TagFilters: tagFilters},
+				func(results *resourcegroupstaggingapi.GetResourcesOutput, lastPage bool) bool {
+					for _, resource := range results.ResourceTagMappingList {
+						arns = append(arns, *resource.ResourceARN)
+					}
+					return !lastPage
+				},
+			)
+			if err != nil {
+				return nil, errors.Wrapf(err, "get tagged resources in %s", tagClientNames[tagClient])
+			}
+		}
+	}
+
+	iamClient := iam.New(awsSession)
+	o.Logger.Debug("search for IAM roles")
+	roleARNs, err := (&iamRoleSearch{client: iamClient, filters: o.Filters, logger: o.Logger}).arns()
+	if err != nil {
+		return nil, err
+	}
+	o.Logger.Debug("search for IAM users")
+	userARNs, err := (&iamUserSearch{client: iamClient, filters: o.Filters, logger: o.Logger}).arns()
+	if err != nil {
+		return nil, err
+	}
+	arns = append(append(arns, roleARNs...), userARNs...)
+
+	resources := map[string][]string{}
+	seen := map[string]struct{}{}
+	for _, arnString := range arns {
+		if _, ok := seen[arnString]; ok {
+			continue
+		}
+		seen[arnString] = exists
+		key := "unknown"
+		if parsed, err := arn.Parse(arnString); err == nil {
+			key = parsed.Service
+			if t := resourceType(parsed.Resource); t != "" {
+				key += ":" + t
+			}
+		}
+		resources[key] = append(resources[key], arnString)
+	}
+	for _, arns := range resources {
+		sort.Strings(arns)
+	}
+	return resources, nil
+}
+
+// resourceType returns the type of resource, the part of the resource of an
+// ARN before its ID (e.g. "instance" for "instance/i-0123"), or an empty
+// string for resources without a type, like S3 buckets.
+func resourceType(resource string) string {
+	if i := strings.IndexAny(resource, "/:"); i >= 0 {
+		return resource[:i]
+	}
+	return ""
+}
@@ -9,11 +9,30 @@ import (
 	"strings"
 
 	"github.com/openshift/installer/pkg/asset/cluster"
+	awsconfig "github.com/openshift/installer/pkg/asset/installconfig/aws"
 	"github.com/openshift/installer/pkg/terraform"
+	"github.com/openshift/installer/pkg/types/aws"
+	"github.com/openshift/installer/pkg/types/azure"
 	"github.com/openshift/installer/pkg/types/libvirt"
 	"github.com/pkg/errors"
 )
 
+// postDestroyHooks are, by platform, the hooks which remove the bootstrap
+// resources created outside of the bootstrap module, like the ingress rules
+// for SSH to the bootstrap host, once the module is destroyed. They are run
+// in the temporary Terraform directory dir, with extraArgs passing the
+// variable files.
+var postDestroyHooks = map[string]func(dir string, extraArgs []string) error{
+	azure.Name: destroyAzureBootstrapSSH,
+}
+
+// destroyAzureBootstrapSSH removes the NAT rule of the public load
+// balancer forwarding SSH to the bootstrap host, which is in the vnet
+// module to be created before the bootstrap network interface.
+func destroyAzureBootstrapSSH(dir string, extraArgs []string) error {
+	return terraform.Destroy(dir, azure.Name, append(extraArgs, "-target=module.vnet.azurerm_lb_nat_rule.bootstrap_ssh")...)
+}
+
 // Destroy uses Terraform to remove bootstrap resources.
 func Destroy(dir string) (err error) {
 	metadata, err := cluster.LoadMetadata(dir)
@@ -62,6 +81,14 @@ func Destroy(dir string) (err error) {
 		}
 	}
 
+	if platform == aws.Name {
+		restoreEnv, err := awsconfig.SetProvidedCredentialsEnv()
+		if err != nil {
+			return err
+		}
+		defer restoreEnv()
+	}
+
 	if platform == libvirt.Name {
 		_, err = terraform.Apply(tempDir, platform, extraArgs...)
 		if err != nil {
@@ -69,12 +96,17 @@ func Destroy(dir string) (err error) {
 		}
 	}
 
-	extraArgs = append(extraArgs, "-target=module.bootstrap")
-	err = terraform.Destroy(tempDir, platform, extraArgs...)
+	err = terraform.Destroy(tempDir, platform, append(extraArgs, "-target=module.bootstrap")...)
 	if err != nil {
 		return errors.Wrap(err, "Terraform destroy")
 	}
 
+	if hook, ok := postDestroyHooks[platform]; ok {
+		if err := hook(tempDir, extraArgs); err != nil {
+			return errors.Wrap(err, "failed to destroy the remaining bootstrap resources")
+		}
+	}
+
 	tempStateFilePath := filepath.Join(dir, terraform.StateFileName+".new")
 	err = copy(filepath.Join(tempDir, terraform.StateFileName), tempStateFilePath)
 	if err != nil {
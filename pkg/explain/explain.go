@@ -0,0 +1,130 @@
+//go:generate go run generate.go
+
+// Package explain documents the fields of the install config, from the
+// comments of the types in pkg/types, for openshift-install explain.
+package explain
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/openshift/installer/pkg/types"
+)
+
+// Field is a field of the install config, or the install config itself.
+type Field struct {
+	// Name is the name of the field in install-config.yaml.
+	Name string
+
+	// Type is the type of the field: string, bool, an integer type, object,
+	// or a map or list of those, like []object.
+	Type string
+
+	// Description is the documentation of the field.
+	Description string
+
+	// Default describes the value used when the field is not set.
+	Default string
+
+	// Allowed are the values the field accepts, when they are a known set.
+	Allowed []string
+
+	// Fields are the fields of the objects of the field.
+	Fields []*Field
+}
+
+// Lookup returns the field at path, the names of the fields from the install
+// config down, separated by dots, like installconfig.platform.aws.region.
+// The install config is installconfig, which may be left out, and the names
+// are matched ignoring case. The fields of the items of lists and maps are
+// looked up as the fields of the list or map.
+func Lookup(path string) (*Field, error) {
+	names := strings.Split(path, ".")
+	if strings.EqualFold(names[0], installConfig.Name) {
+		names = names[1:]
+	}
+
+	field := installConfig
+	for i, name := range names {
+		child := field.child(name)
+		if child == nil {
+			return nil, errors.Errorf("field %q does not exist in %s", name, strings.Join(append([]string{installConfig.Name}, names[:i]...), "."))
+		}
+		field = child
+	}
+	return field, nil
+}
+
+// child returns the field of f named name, ignoring case, or nil.
+func (f *Field) child(name string) *Field {
+	for _, child := range f.Fields {
+		if child.Name == name {
+			return child
+		}
+	}
+	for _, child := range f.Fields {
+		if strings.EqualFold(child.Name, name) {
+			return child
+		}
+	}
+	return nil
+}
+
+// Print writes the documentation of f to w, like kubectl explain: its
+// type, description, default and allowed values, and the summary of its
+// fields.
+func Print(w io.Writer, f *Field) error {
+	ew := &errWriter{w: w}
+	ew.printf("KIND:     InstallConfig\n")
+	ew.printf("VERSION:  %s\n\n", types.InstallConfigVersion)
+	if f != installConfig {
+		ew.printf("FIELD:    %s <%s>\n\n", f.Name, f.Type)
+	}
+	ew.section("DESCRIPTION", f.Description)
+	ew.section("DEFAULT", f.Default)
+	ew.section("ALLOWED VALUES", strings.Join(f.Allowed, ", "))
+	if len(f.Fields) > 0 {
+		ew.printf("FIELDS:\n")
+		for _, child := range f.Fields {
+			ew.printf("   %s <%s>\n", child.Name, child.Type)
+			ew.indent(child.Description, "     ")
+			ew.printf("\n")
+		}
+	}
+	return ew.err
+}
+
+// errWriter writes to w until a write fails, keeping the error.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err == nil {
+		_, ew.err = fmt.Fprintf(ew.w, format, args...)
+	}
+}
+
+// section writes text under title, unless text is empty.
+func (ew *errWriter) section(title, text string) {
+	if text == "" {
+		return
+	}
+	ew.printf("%s:\n", title)
+	ew.indent(text, "    ")
+	ew.printf("\n")
+}
+
+// indent writes the lines of text, each prefixed with prefix.
+func (ew *errWriter) indent(text, prefix string) {
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		ew.printf("%s%s\n", prefix, line)
+	}
+}
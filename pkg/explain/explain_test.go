@@ -0,0 +1,101 @@
+package explain
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup(t *testing.T) {
+	cases := []struct {
+		path     string
+		name     string
+		typ      string
+		allowed  []string
+		expected string
+	}{{
+		path: "installconfig",
+		name: "installconfig",
+		typ:  "object",
+	}, {
+		path: "installconfig.platform.aws.region",
+		name: "region",
+		typ:  "string",
+	}, {
+		path: "platform.aws.region",
+		name: "region",
+		typ:  "string",
+	}, {
+		path: "InstallConfig.Platform.AWS.Region",
+		name: "region",
+		typ:  "string",
+	}, {
+		path: "installconfig.compute",
+		name: "compute",
+		typ:  "[]object",
+	}, {
+		path:    "installconfig.compute.hyperthreading",
+		name:    "hyperthreading",
+		typ:     "string",
+		allowed: []string{"Enabled", "Disabled"},
+	}, {
+		path:     "installconfig.platform.gcp.region",
+		expected: `field "gcp" does not exist in installconfig.platform`,
+	}, {
+		path:     "installconfig.baseDomain.name",
+		expected: `field "name" does not exist in installconfig.baseDomain`,
+	}}
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			field, err := Lookup(tc.path)
+			if tc.expected != "" {
+				assert.EqualError(t, err, tc.expected)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.name, field.Name)
+			assert.Equal(t, tc.typ, field.Type)
+			assert.Equal(t, tc.allowed, field.Allowed)
+		})
+	}
+}
+
+func TestPrint(t *testing.T) {
+	field := &Field{
+		Name:        "pool",
+		Type:        "object",
+		Description: "Pool is a pool of machines.",
+		Default:     "Default is no pool.",
+		Fields: []*Field{{
+			Name:        "mode",
+			Type:        "string",
+			Description: "Mode is the mode of the machines.\nIt is set on all of them.",
+			Allowed:     []string{"Enabled", "Disabled"},
+		}, {
+			Name: "replicas",
+			Type: "int64",
+		}},
+	}
+	buf := &bytes.Buffer{}
+	assert.NoError(t, Print(buf, field))
+	assert.Equal(t, `KIND:     InstallConfig
+VERSION:  v1
+
+FIELD:    pool <object>
+
+DESCRIPTION:
+    Pool is a pool of machines.
+
+DEFAULT:
+    Default is no pool.
+
+FIELDS:
+   mode <string>
+     Mode is the mode of the machines.
+     It is set on all of them.
+
+   replicas <int64>
+
+`, buf.String())
+}
@@ -0,0 +1,341 @@
+// +build ignore
+
+// This program generates schema_generated.go, the fields of the install
+// config with the documentation from the comments of the types in pkg/types.
+// It is run from pkg/explain by go generate.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// modulePath is the import path of the repository, whose packages are
+	// read from root.
+	modulePath = "github.com/openshift/installer/"
+	root       = "../.."
+)
+
+// basicTypes are the Go types written to install-config.yaml as is.
+var basicTypes = map[string]bool{
+	"bool":    true,
+	"float32": true,
+	"float64": true,
+	"int":     true,
+	"int32":   true,
+	"int64":   true,
+	"string":  true,
+	"uint":    true,
+	"uint32":  true,
+	"uint64":  true,
+}
+
+// externalTypes are the fields of the types of other repositories, and of
+// the types with their own YAML representation.
+var externalTypes = map[string]func() *schemaField{
+	"github.com/openshift/installer/pkg/ipnet.IPNet": func() *schemaField {
+		return &schemaField{Type: "string", Description: "An IP address block in CIDR notation, like 10.0.0.0/16."}
+	},
+	"k8s.io/apimachinery/pkg/apis/meta/v1.TypeMeta": func() *schemaField {
+		return &schemaField{Type: "object", Fields: []*schemaField{{
+			Name:        "apiVersion",
+			Type:        "string",
+			Description: "APIVersion is the version of the install config.",
+			Allowed:     []string{"v1"},
+		}}}
+	},
+	"k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta": func() *schemaField {
+		return &schemaField{Type: "object", Description: "Metadata holds the name of the cluster.", Fields: []*schemaField{{
+			Name:        "name",
+			Type:        "string",
+			Description: "Name is the name of the cluster. The DNS records of the cluster are\ncreated under <name>.<baseDomain>.",
+		}}}
+	},
+}
+
+// schemaField is the explain.Field generated, which is not imported to
+// generate the package it is in.
+type schemaField struct {
+	Name        string
+	Type        string
+	Description string
+	Default     string
+	Allowed     []string
+	Fields      []*schemaField
+}
+
+// typeDecl is a type declared in a package.
+type typeDecl struct {
+	pkg  *pkg
+	doc  string
+	expr ast.Expr
+
+	// imports are the import paths of the file of the type, by name.
+	imports map[string]string
+}
+
+// pkg is a package read from source.
+type pkg struct {
+	path  string
+	types map[string]*typeDecl
+
+	// values are the values of the constants of each type.
+	values map[string][]string
+}
+
+var packages = map[string]*pkg{}
+
+// loadPackage reads the types and constants of the package at path, which
+// must be in this repository.
+func loadPackage(path string) *pkg {
+	if p, ok := packages[path]; ok {
+		return p
+	}
+	dir := filepath.Join(root, strings.TrimPrefix(path, modulePath))
+	parsed, err := parser.ParseDir(token.NewFileSet(), dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	p := &pkg{path: path, types: map[string]*typeDecl{}, values: map[string][]string{}}
+	for _, astPkg := range parsed {
+		for _, file := range astPkg.Files {
+			imports := map[string]string{}
+			for _, spec := range file.Imports {
+				importPath, _ := strconv.Unquote(spec.Path.Value)
+				name := filepath.Base(importPath)
+				if spec.Name != nil {
+					name = spec.Name.Name
+				}
+				imports[name] = importPath
+			}
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						doc := spec.Doc
+						if doc == nil {
+							doc = gen.Doc
+						}
+						p.types[spec.Name.Name] = &typeDecl{pkg: p, doc: doc.Text(), expr: spec.Type, imports: imports}
+					case *ast.ValueSpec:
+						typ, ok := spec.Type.(*ast.Ident)
+						if gen.Tok != token.CONST || !ok {
+							continue
+						}
+						for _, value := range spec.Values {
+							if lit, ok := value.(*ast.BasicLit); ok {
+								v, err := strconv.Unquote(lit.Value)
+								if err != nil {
+									v = lit.Value
+								}
+								p.values[typ.Name] = append(p.values[typ.Name], v)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	packages[path] = p
+	return p
+}
+
+// resolve returns the field of the type expr of decl, without its name and
+// documentation.
+func resolve(decl *typeDecl, expr ast.Expr) *schemaField {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return resolve(decl, expr.X)
+	case *ast.ArrayType:
+		field := resolve(decl, expr.Elt)
+		field.Type = "[]" + field.Type
+		return field
+	case *ast.MapType:
+		field := resolve(decl, expr.Value)
+		field.Type = "map[string]" + field.Type
+		return field
+	case *ast.Ident:
+		if basicTypes[expr.Name] {
+			return &schemaField{Type: expr.Name}
+		}
+		return named(decl.pkg, expr.Name)
+	case *ast.SelectorExpr:
+		path := decl.imports[expr.X.(*ast.Ident).Name]
+		if external, ok := externalTypes[path+"."+expr.Sel.Name]; ok {
+			return external()
+		}
+		if !strings.HasPrefix(path, modulePath) {
+			log.Fatalf("unsupported type %s.%s", path, expr.Sel.Name)
+		}
+		return named(loadPackage(path), expr.Sel.Name)
+	case *ast.StructType:
+		field := &schemaField{Type: "object"}
+		for _, f := range expr.Fields.List {
+			field.Fields = append(field.Fields, structFields(decl, f)...)
+		}
+		return field
+	}
+	log.Fatalf("unsupported type %T in %s", expr, decl.pkg.path)
+	return nil
+}
+
+// named returns the field of the type name of p, with the values of its
+// constants as the allowed values.
+func named(p *pkg, name string) *schemaField {
+	decl, ok := p.types[name]
+	if !ok {
+		log.Fatalf("type %s not found in %s", name, p.path)
+	}
+	field := resolve(decl, decl.expr)
+	if len(p.values[name]) > 0 {
+		field.Allowed = p.values[name]
+	}
+	return field
+}
+
+// structFields returns the fields of the struct field f of a type of decl,
+// which are the fields of its type when it is embedded without a name.
+func structFields(decl *typeDecl, f *ast.Field) []*schemaField {
+	var tag reflect.StructTag
+	if f.Tag != nil {
+		value, _ := strconv.Unquote(f.Tag.Value)
+		tag = reflect.StructTag(value)
+	}
+	name := strings.Split(tag.Get("json"), ",")[0]
+	if name == "-" {
+		return nil
+	}
+
+	goName := ""
+	if len(f.Names) > 0 {
+		goName = f.Names[0].Name
+	} else if star, ok := f.Type.(*ast.StarExpr); ok {
+		goName = typeName(star.X)
+	} else {
+		goName = typeName(f.Type)
+	}
+	if !ast.IsExported(goName) {
+		return nil
+	}
+
+	field := resolve(decl, f.Type)
+	if name == "" && len(f.Names) == 0 {
+		return field.Fields
+	}
+	if name == "" {
+		name = goName
+	}
+
+	field.Name = name
+	description, dflt := parseDoc(f.Doc.Text(), goName)
+	if description != "" {
+		field.Description = description
+	}
+	field.Default = dflt
+	return []*schemaField{field}
+}
+
+// typeName returns the name of the type expr, without its package.
+func typeName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.SelectorExpr:
+		return expr.Sel.Name
+	}
+	return ""
+}
+
+// parseDoc splits the comment of the field goName into its description and
+// the lines about its default, leaving out the markers like +optional. The
+// first line is left out when it is only the name of the field.
+func parseDoc(doc, goName string) (description, dflt string) {
+	var descriptionLines, defaultLines []string
+	inDefault := false
+	for i, line := range strings.Split(strings.TrimSpace(doc), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "+"):
+		case i == 0 && line == goName:
+		case strings.HasPrefix(strings.ToLower(line), "default") || strings.Contains(strings.ToLower(line), "the default is"):
+			inDefault = true
+			defaultLines = append(defaultLines, line)
+		case inDefault && line != "" && !strings.HasPrefix(line, "NOTE"):
+			defaultLines = append(defaultLines, line)
+		default:
+			inDefault = false
+			descriptionLines = append(descriptionLines, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(descriptionLines, "\n")), strings.Join(defaultLines, "\n")
+}
+
+// write writes the Go expression of field to buf.
+func write(buf *bytes.Buffer, field *schemaField) {
+	buf.WriteString("{\n")
+	for _, kv := range [][2]string{
+		{"Name", field.Name},
+		{"Type", field.Type},
+		{"Description", field.Description},
+		{"Default", field.Default},
+	} {
+		if kv[1] != "" {
+			fmt.Fprintf(buf, "%s: %s,\n", kv[0], strconv.Quote(kv[1]))
+		}
+	}
+	if len(field.Allowed) > 0 {
+		buf.WriteString("Allowed: []string{")
+		for _, value := range field.Allowed {
+			fmt.Fprintf(buf, "%s, ", strconv.Quote(value))
+		}
+		buf.WriteString("},\n")
+	}
+	if len(field.Fields) > 0 {
+		buf.WriteString("Fields: []*Field{")
+		for _, child := range field.Fields {
+			write(buf, child)
+			buf.WriteString(",")
+		}
+		buf.WriteString("},\n")
+	}
+	buf.WriteString("}")
+}
+
+func main() {
+	types := loadPackage(modulePath + "pkg/types")
+	installConfig := named(types, "InstallConfig")
+	installConfig.Name = "installconfig"
+	installConfig.Description, _ = parseDoc(types.types["InstallConfig"].doc, "")
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("// Code generated by generate.go. DO NOT EDIT.\n\npackage explain\n\n")
+	buf.WriteString("// installConfig is the install config, generated from pkg/types.\nvar installConfig = &Field")
+	write(buf, installConfig)
+	buf.WriteString("\n")
+	data, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("schema_generated.go", data, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
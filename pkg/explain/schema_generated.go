@@ -0,0 +1,452 @@
+// Code generated by generate.go. DO NOT EDIT.
+
+package explain
+
+// installConfig is the install config, generated from pkg/types.
+var installConfig = &Field{
+	Name:        "installconfig",
+	Type:        "object",
+	Description: "InstallConfig is the configuration for an OpenShift install.",
+	Fields: []*Field{{
+		Name:        "apiVersion",
+		Type:        "string",
+		Description: "APIVersion is the version of the install config.",
+		Allowed:     []string{"v1"},
+	}, {
+		Name:        "metadata",
+		Type:        "object",
+		Description: "Metadata holds the name of the cluster.",
+		Fields: []*Field{{
+			Name:        "name",
+			Type:        "string",
+			Description: "Name is the name of the cluster. The DNS records of the cluster are\ncreated under <name>.<baseDomain>.",
+		}},
+	}, {
+		Name:        "sshKey",
+		Type:        "string",
+		Description: "SSHKey is the public ssh key to provide access to instances.",
+	}, {
+		Name:        "baseDomain",
+		Type:        "string",
+		Description: "BaseDomain is the base domain to which the cluster should belong.",
+	}, {
+		Name:        "networking",
+		Type:        "object",
+		Description: "Networking defines the pod network provider in the cluster.",
+		Fields: []*Field{{
+			Name:        "machineCIDR",
+			Type:        "string",
+			Description: "MachineCIDR is the IP address space from which to assign machine IPs.",
+			Default:     "Default is 10.0.0.0/16 for all platforms other than Libvirt.\nFor Libvirt, the default is 192.168.126.0/24.",
+		}, {
+			Name:        "networkType",
+			Type:        "string",
+			Description: "NetworkType is the type of network to install.",
+			Default:     "Default is OpenShiftSDN.",
+		}, {
+			Name:        "clusterNetwork",
+			Type:        "[]object",
+			Description: "ClusterNetwork is the IP address pool to use for pod IPs.",
+			Default:     "Default is 10.128.0.0/14 and a host prefix of /23",
+			Fields: []*Field{{
+				Name:        "cidr",
+				Type:        "string",
+				Description: "The IP block address pool",
+			}, {
+				Name:        "hostPrefix",
+				Type:        "int32",
+				Description: "HostPrefix is the prefix size to allocate to each node from the CIDR.\nFor example, 24 would allocate 2^8=256 adresses to each node.",
+			}, {
+				Name:        "hostSubnetLength",
+				Type:        "int32",
+				Description: "The size of blocks to allocate from the larger pool.\nThis is the length in bits - so a 9 here will allocate a /23.",
+			}},
+		}, {
+			Name:        "serviceNetwork",
+			Type:        "[]string",
+			Description: "ServiceNetwork is the IP address pool to use for service IPs.\nNOTE: currently only one entry is supported.",
+			Default:     "Default is 172.30.0.0/16",
+		}, {
+			Name:        "type",
+			Type:        "string",
+			Description: "Deprecated name for NetworkType",
+		}, {
+			Name:        "serviceCIDR",
+			Type:        "string",
+			Description: "Depcreated name for ServiceNetwork",
+		}, {
+			Name:        "clusterNetworks",
+			Type:        "[]object",
+			Description: "Deprecated name for ClusterNetwork",
+			Fields: []*Field{{
+				Name:        "cidr",
+				Type:        "string",
+				Description: "The IP block address pool",
+			}, {
+				Name:        "hostPrefix",
+				Type:        "int32",
+				Description: "HostPrefix is the prefix size to allocate to each node from the CIDR.\nFor example, 24 would allocate 2^8=256 adresses to each node.",
+			}, {
+				Name:        "hostSubnetLength",
+				Type:        "int32",
+				Description: "The size of blocks to allocate from the larger pool.\nThis is the length in bits - so a 9 here will allocate a /23.",
+			}},
+		}},
+	}, {
+		Name:        "controlPlane",
+		Type:        "object",
+		Description: "ControlPlane is the configuration for the machines that comprise the\ncontrol plane.",
+		Fields: []*Field{{
+			Name:        "name",
+			Type:        "string",
+			Description: "Name is the name of the machine pool.\nFor the control plane machine pool, the name will always be \"master\".\nFor the compute machine pools, the only valid name is \"worker\".",
+		}, {
+			Name:        "replicas",
+			Type:        "int64",
+			Description: "Replicas is the count of machines for this machine pool.",
+		}, {
+			Name:        "platform",
+			Type:        "object",
+			Description: "Platform is configuration for machine pool specific to the platform.",
+			Fields: []*Field{{
+				Name:        "aws",
+				Type:        "object",
+				Description: "AWS is the configuration used when installing on AWS.",
+				Fields: []*Field{{
+					Name:        "zones",
+					Type:        "[]string",
+					Description: "Zones is list of availability zones that can be used.",
+				}, {
+					Name:        "type",
+					Type:        "string",
+					Description: "InstanceType defines the ec2 instance type.\neg. m4-large",
+				}, {
+					Name:        "rootVolume",
+					Type:        "object",
+					Description: "EC2RootVolume defines the storage for ec2 instance.",
+					Fields: []*Field{{
+						Name:        "iops",
+						Type:        "int",
+						Description: "IOPS defines the iops for the storage.",
+					}, {
+						Name:        "size",
+						Type:        "int",
+						Description: "Size defines the size of the storage.",
+					}, {
+						Name:        "type",
+						Type:        "string",
+						Description: "Type defines the type of the storage.",
+					}},
+				}},
+			}, {
+				Name:        "libvirt",
+				Type:        "object",
+				Description: "Libvirt is the configuration used when installing on libvirt.",
+			}, {
+				Name:        "openstack",
+				Type:        "object",
+				Description: "OpenStack is the configuration used when installing on OpenStack.",
+				Fields: []*Field{{
+					Name:        "type",
+					Type:        "string",
+					Description: "FlavorName defines the OpenStack Nova flavor.\neg. m1.large",
+				}},
+			}, {
+				Name:        "vsphere",
+				Type:        "object",
+				Description: "VSphere is the configuration used when installing on vSphere.",
+			}, {
+				Name:        "azure",
+				Type:        "object",
+				Description: "Azure is the configuration used when installing on OpenStack.",
+				Fields: []*Field{{
+					Name:        "zones",
+					Type:        "[]string",
+					Description: "Zones is list of availability zones that can be used.",
+				}, {
+					Name:        "type",
+					Type:        "string",
+					Description: "InstanceType defines the azure instance type.\neg. Standard_DS_V2",
+				}},
+			}},
+		}, {
+			Name:        "hyperthreading",
+			Type:        "string",
+			Description: "Hyperthreading determines the mode of hyperthreading that machines in this\npool will utilize.",
+			Default:     "Default is for hyperthreading to be enabled.",
+			Allowed:     []string{"Enabled", "Disabled"},
+		}},
+	}, {
+		Name:        "compute",
+		Type:        "[]object",
+		Description: "Compute is the list of compute MachinePools that need to be installed.",
+		Fields: []*Field{{
+			Name:        "name",
+			Type:        "string",
+			Description: "Name is the name of the machine pool.\nFor the control plane machine pool, the name will always be \"master\".\nFor the compute machine pools, the only valid name is \"worker\".",
+		}, {
+			Name:        "replicas",
+			Type:        "int64",
+			Description: "Replicas is the count of machines for this machine pool.",
+		}, {
+			Name:        "platform",
+			Type:        "object",
+			Description: "Platform is configuration for machine pool specific to the platform.",
+			Fields: []*Field{{
+				Name:        "aws",
+				Type:        "object",
+				Description: "AWS is the configuration used when installing on AWS.",
+				Fields: []*Field{{
+					Name:        "zones",
+					Type:        "[]string",
+					Description: "Zones is list of availability zones that can be used.",
+				}, {
+					Name:        "type",
+					Type:        "string",
+					Description: "InstanceType defines the ec2 instance type.\neg. m4-large",
+				}, {
+					Name:        "rootVolume",
+					Type:        "object",
+					Description: "EC2RootVolume defines the storage for ec2 instance.",
+					Fields: []*Field{{
+						Name:        "iops",
+						Type:        "int",
+						Description: "IOPS defines the iops for the storage.",
+					}, {
+						Name:        "size",
+						Type:        "int",
+						Description: "Size defines the size of the storage.",
+					}, {
+						Name:        "type",
+						Type:        "string",
+						Description: "Type defines the type of the storage.",
+					}},
+				}},
+			}, {
+				Name:        "libvirt",
+				Type:        "object",
+				Description: "Libvirt is the configuration used when installing on libvirt.",
+			}, {
+				Name:        "openstack",
+				Type:        "object",
+				Description: "OpenStack is the configuration used when installing on OpenStack.",
+				Fields: []*Field{{
+					Name:        "type",
+					Type:        "string",
+					Description: "FlavorName defines the OpenStack Nova flavor.\neg. m1.large",
+				}},
+			}, {
+				Name:        "vsphere",
+				Type:        "object",
+				Description: "VSphere is the configuration used when installing on vSphere.",
+			}, {
+				Name:        "azure",
+				Type:        "object",
+				Description: "Azure is the configuration used when installing on OpenStack.",
+				Fields: []*Field{{
+					Name:        "zones",
+					Type:        "[]string",
+					Description: "Zones is list of availability zones that can be used.",
+				}, {
+					Name:        "type",
+					Type:        "string",
+					Description: "InstanceType defines the azure instance type.\neg. Standard_DS_V2",
+				}},
+			}},
+		}, {
+			Name:        "hyperthreading",
+			Type:        "string",
+			Description: "Hyperthreading determines the mode of hyperthreading that machines in this\npool will utilize.",
+			Default:     "Default is for hyperthreading to be enabled.",
+			Allowed:     []string{"Enabled", "Disabled"},
+		}},
+	}, {
+		Name:        "platform",
+		Type:        "object",
+		Description: "Platform is the configuration for the specific platform upon which to\nperform the installation.",
+		Fields: []*Field{{
+			Name:        "aws",
+			Type:        "object",
+			Description: "AWS is the configuration used when installing on AWS.",
+			Fields: []*Field{{
+				Name:        "region",
+				Type:        "string",
+				Description: "Region specifies the AWS region where the cluster will be created.",
+			}, {
+				Name:        "userTags",
+				Type:        "map[string]string",
+				Description: "UserTags specifies additional tags for AWS resources created for the cluster.",
+			}, {
+				Name:    "defaultMachinePlatform",
+				Type:    "object",
+				Default: "DefaultMachinePlatform is the default configuration used when\ninstalling on AWS for machine pools which do not define their own\nplatform configuration.",
+				Fields: []*Field{{
+					Name:        "zones",
+					Type:        "[]string",
+					Description: "Zones is list of availability zones that can be used.",
+				}, {
+					Name:        "type",
+					Type:        "string",
+					Description: "InstanceType defines the ec2 instance type.\neg. m4-large",
+				}, {
+					Name:        "rootVolume",
+					Type:        "object",
+					Description: "EC2RootVolume defines the storage for ec2 instance.",
+					Fields: []*Field{{
+						Name:        "iops",
+						Type:        "int",
+						Description: "IOPS defines the iops for the storage.",
+					}, {
+						Name:        "size",
+						Type:        "int",
+						Description: "Size defines the size of the storage.",
+					}, {
+						Name:        "type",
+						Type:        "string",
+						Description: "Type defines the type of the storage.",
+					}},
+				}},
+			}},
+		}, {
+			Name:        "libvirt",
+			Type:        "object",
+			Description: "Libvirt is the configuration used when installing on libvirt.",
+			Fields: []*Field{{
+				Name:        "URI",
+				Type:        "string",
+				Description: "URI is the identifier for the libvirtd connection.  It must be\nreachable from both the host (where the installer is run) and the\ncluster (where the cluster-API controller pod will be running).",
+				Default:     "Default is qemu+tcp://192.168.122.1/system",
+			}, {
+				Name:    "defaultMachinePlatform",
+				Type:    "object",
+				Default: "DefaultMachinePlatform is the default configuration used when\ninstalling on libvirt for machine pools which do not define their\nown platform configuration.\nDefault will set the image field to the latest RHCOS image.",
+			}, {
+				Name: "network",
+				Type: "object",
+				Fields: []*Field{{
+					Name:    "if",
+					Type:    "string",
+					Default: "Default is tt0.",
+				}},
+			}},
+		}, {
+			Name:        "none",
+			Type:        "object",
+			Description: "None is the empty configuration used when installing on an unsupported\nplatform.",
+		}, {
+			Name:        "openstack",
+			Type:        "object",
+			Description: "OpenStack is the configuration used when installing on OpenStack.",
+			Fields: []*Field{{
+				Name:        "region",
+				Type:        "string",
+				Description: "Region specifies the OpenStack region where the cluster will be created.",
+			}, {
+				Name:    "defaultMachinePlatform",
+				Type:    "object",
+				Default: "DefaultMachinePlatform is the default configuration used when\ninstalling on OpenStack for machine pools which do not define their own\nplatform configuration.",
+				Fields: []*Field{{
+					Name:        "type",
+					Type:        "string",
+					Description: "FlavorName defines the OpenStack Nova flavor.\neg. m1.large",
+				}},
+			}, {
+				Name:        "cloud",
+				Type:        "string",
+				Description: "Name of OpenStack cloud to use from clouds.yaml",
+			}, {
+				Name:        "externalNetwork",
+				Type:        "string",
+				Description: "The OpenStack external network name to be used for installation.",
+			}, {
+				Name:        "computeFlavor",
+				Type:        "string",
+				Description: "The OpenStack compute flavor to use for servers.",
+			}, {
+				Name:        "lbFloatingIP",
+				Type:        "string",
+				Description: "Existing Floating IP to associate with the OpenStack load balancer.",
+			}, {
+				Name:        "trunkSupport",
+				Type:        "string",
+				Description: "Whether OpenStack ports can be trunked",
+			}},
+		}, {
+			Name:        "vsphere",
+			Type:        "object",
+			Description: "VSphere is the configuration used when installing on vSphere.",
+			Fields: []*Field{{
+				Name:        "vCenter",
+				Type:        "string",
+				Description: "VCenter is the domain name or IP address of the vCenter.",
+			}, {
+				Name:        "username",
+				Type:        "string",
+				Description: "Username is the name of the user to use to connect to the vCenter.",
+			}, {
+				Name:        "password",
+				Type:        "string",
+				Description: "Password is the password for the user to use to connect to the vCenter.",
+			}, {
+				Name:        "datacenter",
+				Type:        "string",
+				Description: "Datacenter is the name of the datacenter to use in the vCenter.",
+			}, {
+				Name:    "defaultDatastore",
+				Type:    "string",
+				Default: "DefaultDatastore is the default datastore to use for provisioning volumes.",
+			}},
+		}, {
+			Name:        "azure",
+			Type:        "object",
+			Description: "Azure is the configuration used when installing on Azure.",
+			Fields: []*Field{{
+				Name:        "region",
+				Type:        "string",
+				Description: "Region specifies the Azure region where the cluster will be created.",
+			}, {
+				Name:        "baseDomainResourceGroupName",
+				Type:        "string",
+				Description: "BaseDomainResourceGroupName specifies the resource group where the azure DNS zone for the base domain is found",
+			}, {
+				Name:        "userTags",
+				Type:        "map[string]string",
+				Description: "UserTags specifies additional tags for Azure resources created for the cluster.",
+			}, {
+				Name:    "defaultMachinePlatform",
+				Type:    "object",
+				Default: "DefaultMachinePlatform is the default configuration used when\ninstalling on Azure for machine pools which do not define their own\nplatform configuration.",
+				Fields: []*Field{{
+					Name:        "zones",
+					Type:        "[]string",
+					Description: "Zones is list of availability zones that can be used.",
+				}, {
+					Name:        "type",
+					Type:        "string",
+					Description: "InstanceType defines the azure instance type.\neg. Standard_DS_V2",
+				}},
+			}},
+		}},
+	}, {
+		Name:        "pullSecret",
+		Type:        "string",
+		Description: "PullSecret is the secret to use when pulling images.",
+	}, {
+		Name:        "proxy",
+		Type:        "object",
+		Description: "Proxy defines the proxy settings for the cluster.\nIf unset, the cluster will not be configured to use a proxy.",
+		Fields: []*Field{{
+			Name:        "httpProxy",
+			Type:        "string",
+			Description: "HTTPProxy is the URL of the proxy for HTTP requests.",
+		}, {
+			Name:        "httpsProxy",
+			Type:        "string",
+			Description: "HTTPSProxy is the URL of the proxy for HTTPS requests.",
+		}, {
+			Name:        "noProxy",
+			Type:        "string",
+			Description: "NoProxy is a comma-separated list of domains and CIDRs for which the proxy should not be used.",
+		}},
+	}},
+}
@@ -0,0 +1,171 @@
+package gather
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// AnalyzerVersion identifies the set of analyzers returned by
+// DefaultAnalyzers. It must be bumped whenever an analyzer is added or its
+// verdicts change, so that summaries of the same bundle produced by
+// different installers can be told apart.
+const AnalyzerVersion = 9
+
+// Analyzer derives findings from the files of a bundle.
+type Analyzer struct {
+	// Name identifies the analyzer in the summary.
+	Name string
+
+	// Files are the path.Match patterns of the bundle members the analyzer
+	// reads, relative to the root of the bundle.
+	Files []string
+
+	// Analyze records findings about files, which holds the contents of the
+	// bundle members matching Files keyed by their path.
+	Analyze func(files map[string][]byte, summary *Summary)
+}
+
+// DefaultAnalyzers returns the analyzers run on every bundle.
+func DefaultAnalyzers() []Analyzer {
+	return []Analyzer{
+		TerraformAnalyzer(),
+		WorkerIgnitionAnalyzer(),
+		EtcdDataAnalyzer(),
+		FirstBootAnalyzer(),
+		ClusterBootstrapAnalyzer(),
+		CSRAnalyzer(),
+		ClusterDNSAnalyzer(),
+		StaticPodsAnalyzer(),
+		StorageAnalyzer(),
+		JournalBootsAnalyzer(),
+		AuditLogAnalyzer(),
+	}
+}
+
+// Analyze runs the analyzers over files, in order.
+func Analyze(analyzers []Analyzer, files map[string][]byte, summary *Summary) {
+	for _, analyzer := range analyzers {
+		matched := map[string][]byte{}
+		for name, data := range files {
+			if matchAny(analyzer.Files, name) {
+				matched[name] = data
+			}
+		}
+		if len(matched) > 0 {
+			analyzer.Analyze(matched, summary)
+		}
+	}
+}
+
+// ReadBundle returns the contents of the members needed by analyzers from
+// each of the paths, which are either gzipped tarballs or directories.
+func ReadBundle(analyzers []Analyzer, paths ...string) (map[string][]byte, error) {
+	var patterns []string
+	for _, analyzer := range analyzers {
+		patterns = append(patterns, analyzer.Files...)
+	}
+
+	files := map[string][]byte{}
+	for _, p := range paths {
+		err := walkBundle(p, func(name string, size int64, open func() (io.ReadCloser, error)) error {
+			if !matchAny(patterns, name) {
+				return nil
+			}
+			r, err := open()
+			if err != nil {
+				return err
+			}
+			defer r.Close()
+			data, err := ioutil.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			files[name] = data
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %q: %w", p, err)
+		}
+	}
+	return files, nil
+}
+
+// walkBundle calls visit with the name and size of each regular member of
+// the bundle at p, which is either a gzipped tarball or a directory. open
+// returns the contents of the member, and may only be called during visit.
+func walkBundle(p string, visit func(name string, size int64, open func() (io.ReadCloser, error)) error) error {
+	info, err := os.Stat(p)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return walkDir(p, visit)
+	}
+	return walkArchive(p, visit)
+}
+
+func walkArchive(file string, visit func(name string, size int64, open func() (io.ReadCloser, error)) error) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("only gzipped bundles can be read: %w", err)
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := visit(memberName(hdr.Name), hdr.Size, func() (io.ReadCloser, error) { return ioutil.NopCloser(tr), nil }); err != nil {
+			return err
+		}
+	}
+}
+
+func walkDir(dir string, visit func(name string, size int64, open func() (io.ReadCloser, error)) error) error {
+	return filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		return visit(memberName(filepath.ToSlash(rel)), info.Size(), func() (io.ReadCloser, error) { return os.Open(p) })
+	})
+}
+
+// memberName normalizes the name of a bundle member to a path relative to
+// the root of the bundle.
+func memberName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
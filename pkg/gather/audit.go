@@ -0,0 +1,45 @@
+package gather
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// auditLogPaths are the locations the bootstrap kube-apiserver has written
+// its audit log to across releases.
+var auditLogPaths = []string{
+	"/var/log/bootstrap-control-plane/kube-apiserver/audit.log",
+	"/var/log/kube-apiserver/audit.log",
+}
+
+// AuditLogStep returns a step that pulls the audit log of the bootstrap
+// kube-apiserver. When maxBytes is positive, only the most recent maxBytes
+// of the log are pulled.
+func AuditLogStep(maxBytes int64) Step {
+	return Step{
+		Name: "audit",
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			path, err := firstExisting(ctx, host, auditLogPaths...)
+			if err != nil {
+				return err
+			}
+			if path == "" {
+				return ioutil.WriteFile(filepath.Join(dir, "missing.txt"), []byte("no kube-apiserver audit log found\n"), 0644)
+			}
+			return pullPrivileged(ctx, host, path, filepath.Join(dir, "audit.log"), maxBytes)
+		},
+	}
+}
+
+// AuditLogAnalyzer returns an analyzer that reports when the bootstrap
+// kube-apiserver never wrote an audit log.
+func AuditLogAnalyzer() Analyzer {
+	return Analyzer{
+		Name:  "audit",
+		Files: []string{"audit/missing.txt"},
+		Analyze: func(files map[string][]byte, summary *Summary) {
+			summary.Addf("audit: no kube-apiserver audit log, the bootstrap kube-apiserver may never have started")
+		},
+	}
+}
@@ -0,0 +1,718 @@
+// Package bootstrap gathers the debugging data of a cluster which failed to
+// bootstrap into a log bundle, like `openshift-install gather bootstrap`.
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/openshift/installer/pkg/asset"
+	"github.com/openshift/installer/pkg/asset/cluster"
+	"github.com/openshift/installer/pkg/asset/installconfig"
+	"github.com/openshift/installer/pkg/asset/manifests"
+	assetstore "github.com/openshift/installer/pkg/asset/store"
+	"github.com/openshift/installer/pkg/asset/tls"
+	"github.com/openshift/installer/pkg/gather"
+	"github.com/openshift/installer/pkg/gather/ssh"
+	"github.com/openshift/installer/pkg/terraform"
+	tfgather "github.com/openshift/installer/pkg/terraform/gather"
+	"github.com/openshift/installer/pkg/types"
+)
+
+// ViaAssetsKubeconfig is the value of Options.ViaKubeconfig which selects
+// the kubeconfig in the assets directory.
+const ViaAssetsKubeconfig = "assets"
+
+// Options configures Gather. The zero value collects the hosts found in the
+// terraform state of the assets directory without any optional data, but
+// DefaultOptions holds better timeouts and ports.
+type Options struct {
+	// Bootstrap is the address of the bootstrap host, for platforms whose
+	// terraform state the addresses cannot be read from.
+	Bootstrap string
+
+	// Masters are the addresses of the control plane hosts, for platforms
+	// whose terraform state the addresses cannot be read from.
+	Masters []string
+
+	// Hosts are additional hosts to collect directly, as role=address.
+	Hosts []string
+
+	// Only restricts the bootstrap and control plane hosts collected to
+	// the ones with these names, like "bootstrap" or "master-1", or
+	// addresses.
+	Only []string
+
+	// SSHKeys are the paths of the private keys to authenticate with. The
+	// keys of the user are used when empty.
+	SSHKeys []string
+
+	// KeyPassphraseFiles are the files holding the passphrases of the
+	// encrypted SSHKeys, one for all keys or one per key. When empty, the
+	// passphrases are asked for on the terminal or with SSH_ASKPASS.
+	KeyPassphraseFiles []string
+
+	// SSHPort overrides the port of the bootstrap host of the platform.
+	SSHPort int
+
+	// SSHUser overrides the user of the platform to log in to the hosts,
+	// and the bastion, as. It must not contain whitespace.
+	SSHUser string
+
+	// StepTimeout is the time after which a diagnostic step is abandoned.
+	StepTimeout time.Duration
+
+	// Timeout is the time after which running installer-gather.sh and
+	// pulling its bundle is abandoned.
+	Timeout time.Duration
+
+	// DialTimeout is the time after which connecting to a host is
+	// abandoned.
+	DialTimeout time.Duration
+
+	// Retries is the number of times pulling the bundle is retried.
+	Retries int
+
+	// Minimal collects only the bundle created by installer-gather.sh.
+	Minimal bool
+
+	// Estimate estimates the size of the bundle instead of collecting it.
+	Estimate bool
+
+	// NoScript collects the bootstrap host without installer-gather.sh.
+	NoScript bool
+
+	// Quiet does not log the output of the gather script as it runs.
+	Quiet bool
+
+	// GatherScript is the gather script run instead of installer-gather.sh:
+	// a local file, which is uploaded to the host first, or else an
+	// absolute path on the host.
+	GatherScript string
+
+	// DryRun prints the plan of the gather, once the hosts are resolved,
+	// instead of connecting to them or writing any file.
+	DryRun bool
+
+	// TFShowJSON is the path of the output of `terraform show -json` to
+	// read the host addresses from instead of the terraform state.
+	TFShowJSON string
+
+	// StateURL is the s3:// or gs:// URL of the terraform state to read the
+	// host addresses from instead of the terraform state.
+	StateURL string
+
+	// ViaKubeconfig is the path of a kubeconfig whose nodes are collected
+	// instead of the hosts in the terraform state, or ViaAssetsKubeconfig.
+	ViaKubeconfig string
+
+	// IgnorePlatformMismatch reads the host addresses from a terraform
+	// state created for another platform than the one of the install
+	// config, after warning about it, instead of failing.
+	IgnorePlatformMismatch bool
+
+	// StructuredLogs attaches the platform, the phase of the gather and the
+	// host to the log entries as fields, for logs formatted as JSON.
+	StructuredLogs bool
+
+	// MaxBundleSize is the size in bytes above which the bundle is split
+	// into numbered parts, or 0 to keep it whole.
+	MaxBundleSize int64
+
+	// KeepRemote leaves the bundle, and the directory installer-gather.sh
+	// staged it in, on the host once pulled, instead of removing them.
+	KeepRemote bool
+
+	// APIConcurrency is the number of cloud API calls made at the same
+	// time when looking up hosts, or 0 for
+	// tfgather.DefaultAPIConcurrency.
+	APIConcurrency int
+
+	// HostsFile is the path of a gather.HostsFile holding the bootstrap
+	// and control plane hosts, instead of Bootstrap and Masters.
+	HostsFile string
+
+	// Metadata is the path of the metadata.json of the cluster to discover
+	// the hosts from with the cloud API, instead of reading the asset store
+	// and the terraform state of the assets directory.
+	Metadata string
+
+	// IncludeManifests collects the manifests rendered in the assets
+	// directory, with the Secret data redacted.
+	IncludeManifests bool
+
+	// IncludeWindowsNodes collects the Windows nodes listed by the
+	// cluster over Windows OpenSSH.
+	IncludeWindowsNodes bool
+
+	// IncludeMastersLive also collects the control plane hosts directly,
+	// at the same time as the bootstrap host, into control-plane-live.
+	IncludeMastersLive bool
+
+	// ParallelMasters collects the control plane hosts directly and in
+	// parallel, instead of one after the other through
+	// installer-gather.sh on the bootstrap host.
+	ParallelMasters bool
+
+	// FirstReachableMaster collects only the first control plane host
+	// which accepts SSH, instead of all of them.
+	FirstReachableMaster bool
+
+	// AllowHealthy gathers from a cluster which finished bootstrapping,
+	// collecting the control plane hosts directly when there is no
+	// bootstrap host.
+	AllowHealthy bool
+
+	// IncludeMustGather runs must-gather from the bootstrap host, for at
+	// most MustGatherTimeout, when the API is up.
+	IncludeMustGather bool
+	MustGatherTimeout time.Duration
+
+	// IncludeClusterDNS collects the state, config maps and pod logs of
+	// the cluster DNS through the API, when it is up.
+	IncludeClusterDNS bool
+
+	// IncludeOptOpenShift copies the assets rendered under /opt/openshift
+	// on the bootstrap host, without the private keys and kubeconfigs.
+	IncludeOptOpenShift bool
+
+	// DecodeIgnition stores a readable listing of the worker Ignition
+	// config served by the machine config server.
+	DecodeIgnition bool
+
+	// IncludeStaticPods pulls the static pod manifests, and lists the
+	// resources of each revision, from the hosts collected.
+	IncludeStaticPods bool
+
+	// IncludeEtcdSnapshot pulls the newest etcd snapshot, of at most
+	// EtcdSnapshotMaxBytes, from the hosts collected.
+	IncludeEtcdSnapshot bool
+
+	// IncludeTerraformLog includes the debug log of terraform, from
+	// TF_LOG_PATH or else terraform.log in the assets directory.
+	IncludeTerraformLog bool
+
+	// SerialLog fetches the serial console output of the hosts from the
+	// cloud API, which is otherwise only done when the bootstrap host is
+	// unreachable, unless NoSerialFallback.
+	SerialLog        bool
+	NoSerialFallback bool
+
+	// Resume keeps the collected data in the assets directory until the
+	// gather completes, so that a new run skips the hosts already
+	// collected.
+	Resume bool
+
+	// MaxParallel is the number of hosts collected directly at the same
+	// time, without a limit for 0.
+	MaxParallel int
+
+	// RemoteShell is the command every remote command is passed to.
+	RemoteShell string
+
+	// HTTPSProxy is the HTTP proxy the SSH connections are tunneled through.
+	HTTPSProxy string
+
+	// ProxyCommand is the command, in the format of the OpenSSH
+	// ProxyCommand, whose standard input and output the SSH connections
+	// are made over.
+	ProxyCommand string
+
+	// EncryptTo is the path of the public key the bundle is encrypted to.
+	EncryptTo string
+
+	// Bastion is the [user@]host[:port] of the jump host the SSH
+	// connections are tunneled through, with the BastionKeys, or else the
+	// SSHKeys, and its host certificate verified with BastionCA.
+	Bastion     string
+	BastionKeys []string
+	BastionCA   string
+
+	// Output is the path to write the bundle to, instead of a timestamped
+	// file in the assets directory. An existing file is only replaced with
+	// Force.
+	Output string
+	Force  bool
+
+	// UploadTo is the s3:// or gs:// URL of a bucket, with an optional
+	// prefix, that the bundle is uploaded to once written. The bundle is
+	// kept when the upload fails.
+	UploadTo string
+
+	// KnownHosts is the path of the known_hosts file the host keys are
+	// verified against, and unknown hosts added to. The host keys are not
+	// verified when empty.
+	KnownHosts string
+
+	// APIPort and MachineConfigPort are the ports of the Kubernetes API
+	// and of the machine config server.
+	APIPort           int
+	MachineConfigPort int
+
+	// MaxUnitLogBytes truncates the journal of each unit, the audit log
+	// and the CoreDNS pod logs to their last bytes of this size, or keeps
+	// them whole for 0.
+	MaxUnitLogBytes int64
+
+	// EtcdSnapshotMaxBytes is the size above which etcd snapshots are
+	// skipped, or 0 to pull snapshots of any size.
+	EtcdSnapshotMaxBytes int64
+}
+
+// DefaultOptions returns the options of `openshift-install gather
+// bootstrap` without any flags.
+func DefaultOptions() Options {
+	return Options{
+		StepTimeout:          time.Minute,
+		Timeout:              10 * time.Minute,
+		DialTimeout:          30 * time.Second,
+		Retries:              3,
+		MaxParallel:          5,
+		MustGatherTimeout:    5 * time.Minute,
+		RemoteShell:          ssh.DefaultShell,
+		APIPort:              6443,
+		MachineConfigPort:    22623,
+		EtcdSnapshotMaxBytes: 1 << 30,
+	}
+}
+
+const (
+	// defaultGatherScript is the gather script the bootstrap host is
+	// created with.
+	defaultGatherScript = "/usr/local/bin/installer-gather.sh"
+
+	// uploadedGatherScript is the mktemp template of the name a local
+	// --gather-script is uploaded to in /tmp.
+	uploadedGatherScript = "installer-gather.XXXXXX"
+)
+
+// bootstrapGather is a single run of Gather.
+type bootstrapGather struct {
+	Options
+	ctx   context.Context
+	start time.Time
+
+	// passphrases decrypts the encrypted SSH keys, asking for each
+	// passphrase once for all the connections of the gather.
+	passphrases *ssh.Passphrases
+
+	// apiLimiter bounds the cloud API calls of the gather, made with
+	// Options.APIConcurrency at the same time.
+	apiLimiter *tfgather.APILimiter
+
+	// platform is the name of the platform in the install config, when
+	// it was read.
+	platform string
+
+	// awsRegion is the region of the cluster in the install config, for
+	// AWS clusters.
+	awsRegion string
+
+	// openstackCloud is the cloud of the cluster in clouds.yaml, for
+	// OpenStack clusters.
+	openstackCloud string
+
+	// serialConsole fetches the serial console output of the hosts in the
+	// terraform state, when it was read on a platform which supports it.
+	serialConsole func(context.Context) (map[string][]byte, error)
+
+	// directMasters is whether the control plane hosts are collected
+	// directly and in parallel, so that installer-gather.sh only collects
+	// the host it runs on.
+	directMasters bool
+
+	// infraID is the infrastructure ID of the cluster in metadata.json,
+	// when it was read.
+	infraID string
+
+	// bootstrapVersions are the operating system versions read from the
+	// bootstrap host, when it was connected to.
+	bootstrapVersions gather.Versions
+
+	// dial is the dialer the hosts are connected to with, used to diagnose
+	// the hosts which cannot be connected to.
+	dial ssh.Dialer
+
+	// bootstrapPort and masterPorts are the ports given with --bootstrap
+	// and --master, which override the SSH port of the platform.
+	bootstrapPort int
+	masterPorts   map[string]int
+
+	// hostNames are the names of the bootstrap and control plane hosts, by
+	// address, for the per-host results.
+	hostNames map[string]string
+}
+
+// Gather collects the debugging data of the cluster whose assets are in dir
+// into a log bundle in dir, and returns its path. The path is empty when
+// no bundle was written, as with Options.Estimate and Options.DryRun. Data which cannot be
+// collected is recorded in the summary of the bundle rather than failing
+// the gather. A bundle split with Options.MaxBundleSize is returned as the
+// path of its first part.
+//
+// When ctx is done, the SSH sessions and transfers are abandoned and no
+// bundle is left in dir; with Options.Resume, the data collected so far is
+// kept for the next run.
+func Gather(ctx context.Context, dir string, opts Options) (string, error) {
+	if opts.SSHUser != "" && (strings.TrimSpace(opts.SSHUser) == "" || strings.ContainsAny(opts.SSHUser, " \t\n")) {
+		return "", fmt.Errorf("%w: invalid SSH user %q, it must not contain whitespace", gather.ErrUsage, opts.SSHUser)
+	}
+	if !opts.DryRun {
+		if err := checkOutput(opts.Output, opts.Force); err != nil {
+			return "", err
+		}
+	}
+	passphrase := ssh.PromptPassphrase
+	if len(opts.KeyPassphraseFiles) > 0 {
+		var err error
+		if passphrase, err = ssh.FilePassphrases(opts.SSHKeys, opts.KeyPassphraseFiles); err != nil {
+			return "", fmt.Errorf("%w: invalid --key-passphrase-file: %v", gather.ErrUsage, err)
+		}
+	}
+	if opts.GatherScript != "" && !isLocalFile(opts.GatherScript) && !path.IsAbs(opts.GatherScript) {
+		return "", fmt.Errorf("%w: --gather-script %q is neither a local file nor an absolute path on the hosts", gather.ErrUsage, opts.GatherScript)
+	}
+	if opts.Metadata != "" && (opts.ViaKubeconfig != "" || opts.TFShowJSON != "" || opts.StateURL != "") {
+		return "", fmt.Errorf("%w: --metadata cannot be used with --via-kubeconfig, --tf-show-json or --state-url", gather.ErrUsage)
+	}
+	if opts.MaxBundleSize < 0 {
+		return "", fmt.Errorf("%w: --max-bundle-size must not be negative", gather.ErrUsage)
+	}
+	if len(opts.Only) > 0 && opts.ViaKubeconfig != "" {
+		return "", fmt.Errorf("%w: --only cannot be used with --via-kubeconfig", gather.ErrUsage)
+	}
+	if opts.APIConcurrency < 0 {
+		return "", fmt.Errorf("%w: --api-concurrency must not be negative", gather.ErrUsage)
+	}
+	var uploadTo *gather.UploadURL
+	if opts.UploadTo != "" {
+		var err error
+		if uploadTo, err = gather.ParseUploadURL(opts.UploadTo); err != nil {
+			return "", fmt.Errorf("%w: invalid --upload-to: %v", gather.ErrUsage, err)
+		}
+	}
+	apiConcurrency := opts.APIConcurrency
+	if apiConcurrency == 0 {
+		apiConcurrency = tfgather.DefaultAPIConcurrency
+	}
+	g := &bootstrapGather{
+		Options:     opts,
+		ctx:         ssh.WithShell(ctx, opts.RemoteShell),
+		start:       time.Now(),
+		passphrases: ssh.NewPassphrases(passphrase),
+		apiLimiter:  tfgather.NewAPILimiter(apiConcurrency),
+	}
+	if err := g.parseHostFlags(); err != nil {
+		return "", err
+	}
+	bundle, err := g.run(dir)
+	if ctx.Err() != nil {
+		if bundle != "" {
+			if err := os.Remove(bundle); err != nil && !os.IsNotExist(err) {
+				logrus.Warnf("Failed to remove the partial log bundle %q: %v", bundle, err)
+			}
+		}
+		return "", fmt.Errorf("gather interrupted: %w", ctx.Err())
+	}
+	parts := []string{bundle}
+	if opts.MaxBundleSize > 0 && bundle != "" {
+		var splitErr error
+		if parts, splitErr = g.splitBundle(bundle, opts.MaxBundleSize); splitErr != nil {
+			logrus.Errorf("The log bundle was kept whole at %q: %v", bundle, splitErr)
+			parts = []string{bundle}
+		}
+	}
+	if uploadTo != nil && bundle != "" {
+		for _, part := range parts {
+			if uploadErr := g.upload(uploadTo, part); uploadErr != nil {
+				logrus.Errorf("The log bundle was kept at %q: %v", part, uploadErr)
+				if err == nil {
+					err = uploadErr
+				}
+			}
+		}
+	}
+	return parts[0], err
+}
+
+// splitBundle splits the bundle into parts of at most size bytes when it is
+// larger, with a note next to them on how to reassemble it, and returns the
+// paths of the parts.
+func (g *bootstrapGather) splitBundle(bundle string, size int64) ([]string, error) {
+	parts, err := gather.SplitFile(bundle, size)
+	if err != nil || len(parts) == 1 {
+		return parts, err
+	}
+	note := gather.ReassembleNote(bundle, parts)
+	if err := ioutil.WriteFile(bundle+".README.txt", []byte(note), 0644); err != nil {
+		logrus.Warnf("Failed to write the reassembly note: %v", err)
+	}
+	g.logger(phaseArchive, logrus.Fields{"bundle": parts[0]}).Infof("The log bundle was split into %d parts of at most %s, see %q to reassemble it", len(parts), gather.FormatBytes(size), bundle+".README.txt")
+	return parts, nil
+}
+
+func (g *bootstrapGather) run(directory string) (string, error) {
+	if g.ViaKubeconfig != "" {
+		return g.gatherViaKubeconfig(directory)
+	}
+	if g.Metadata != "" {
+		return g.gatherFromMetadataFile(directory, g.Metadata)
+	}
+
+	tfStateFilePath := filepath.Join(directory, terraform.StateFileName)
+	readState := terraform.ReadState
+	if g.TFShowJSON != "" {
+		tfStateFilePath, readState = g.TFShowJSON, terraform.ReadShowJSON
+	}
+	remoteState, err := g.gatherRemoteState(directory, tfStateFilePath)
+	if err != nil {
+		return "", err
+	}
+	if remoteState == nil {
+		_, err = os.Stat(tfStateFilePath)
+		if os.IsNotExist(err) && g.TFShowJSON == "" {
+			return g.gatherFromMetadata(directory)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	assetStore, err := assetstore.NewStore(directory)
+	if err != nil {
+		return "", fmt.Errorf("failed to create asset store: %w", err)
+	}
+
+	config := &installconfig.InstallConfig{}
+	if err := assetStore.Fetch(config); err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", config.Name(), err)
+	}
+	g.platform = config.Config.Platform.Name()
+	if config.Config.Platform.AWS != nil {
+		g.awsRegion = config.Config.Platform.AWS.Region
+	}
+	if config.Config.Platform.OpenStack != nil {
+		g.openstackCloud = config.Config.Platform.OpenStack.Cloud
+	}
+
+	if metadata, err := cluster.LoadMetadata(directory); err == nil {
+		g.infraID = metadata.InfraID
+	} else {
+		logrus.Debugf("Failed to read the cluster metadata, the hosts cannot be discovered with the cloud API: %v", err)
+	}
+
+	var tfstate *terraform.State
+	if remoteState != nil {
+		if config.Config.Platform.AWS != nil && remoteState.Backend == "s3" && remoteState.Config["region"] == nil {
+			remoteState.Config["region"] = config.Config.Platform.AWS.Region
+		}
+		tfStateFilePath = remoteState.Backend + " backend"
+		if tfstate, err = remoteState.Read(); err != nil {
+			return "", err
+		}
+	} else if tfstate, err = readState(tfStateFilePath); err != nil {
+		return "", fmt.Errorf("failed to read state from %q: %w", tfStateFilePath, err)
+	}
+	if err := g.checkStatePlatform(config.Config.Platform.Name(), tfstate); err != nil {
+		return "", err
+	}
+	bootstrap, user, port, masters, err := g.extractHostAddresses(config.Config, tfstate)
+	if err != nil {
+		if errors.Is(err, gather.ErrUnsupportedPlatform) {
+			logrus.Errorf("Cannot fetch the bootstrap and control plane host addresses from the state file: %v", err)
+			return g.unSupportedPlatformGather(directory)
+		}
+		return "", fmt.Errorf("failed to get bootstrap and control plane host addresses from %q: %w", tfStateFilePath, err)
+	}
+
+	var steps []gather.Step
+	if !g.Minimal {
+		steps = g.clusterGatherSteps(assetStore, config.Config)
+	}
+
+	if g.SSHPort != 0 {
+		port = g.SSHPort
+	}
+	targets, err := g.hostTargets()
+	if err != nil {
+		return "", err
+	}
+	if fetch, ok := serialConsole(g.platform, g.openstackCloud, g.apiLimiter); ok {
+		g.serialConsole = func(ctx context.Context) (map[string][]byte, error) {
+			return fetch(ctx, tfstate)
+		}
+	} else if g.SerialLog {
+		logrus.Warnf("Skipping the serial console gather: not supported on %s", g.platform)
+	}
+	return g.collect(bootstrap, user, port, masters, targets, directory, steps)
+}
+
+// gatherRemoteState returns the remote terraform state given by --state-url,
+// or else the one recorded in .terraform/terraform.tfstate in the assets
+// directory when there is no local state at tfStateFilePath. It returns nil
+// when the state is local.
+func (g *bootstrapGather) gatherRemoteState(directory, tfStateFilePath string) (*terraform.RemoteState, error) {
+	if g.StateURL != "" {
+		if g.TFShowJSON != "" {
+			return nil, errors.New("--state-url and --tf-show-json are mutually exclusive")
+		}
+		remoteState, err := terraform.ParseStateURL(g.StateURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --state-url: %w", err)
+		}
+		return remoteState, nil
+	}
+	if g.TFShowJSON != "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(tfStateFilePath); !os.IsNotExist(err) {
+		return nil, nil
+	}
+	backendConfig := filepath.Join(directory, ".terraform", terraform.BackendConfigFileName)
+	if _, err := os.Stat(backendConfig); os.IsNotExist(err) {
+		return nil, nil
+	}
+	remoteState, err := terraform.ReadBackendConfig(backendConfig)
+	if err != nil {
+		return nil, err
+	}
+	if remoteState != nil {
+		logrus.Infof("Reading the terraform state from the %s backend configured in %s", remoteState.Backend, backendConfig)
+	}
+	return remoteState, nil
+}
+
+// clusterGatherSteps returns the optional gather steps that need
+// information about the cluster from the asset store.
+func (g *bootstrapGather) clusterGatherSteps(assetStore asset.Store, config *types.InstallConfig) []gather.Step {
+	var steps []gather.Step
+
+	rootCA := &tls.RootCA{}
+	if err := assetStore.Fetch(rootCA); err != nil {
+		logrus.Warnf("Skipping worker ignition gather: failed to fetch %s: %v", rootCA.Name(), err)
+	} else {
+		endpoint := fmt.Sprintf("https://%s/config/worker", net.JoinHostPort("api-int."+config.ClusterDomain(), strconv.Itoa(g.MachineConfigPort)))
+		steps = append(steps, gather.WorkerIgnitionStep(endpoint, rootCA.Cert(), g.DecodeIgnition))
+	}
+
+	if g.IncludeManifests {
+		var files []*asset.File
+		for _, a := range []asset.WritableAsset{&manifests.Manifests{}, &manifests.Openshift{}} {
+			if err := assetStore.Fetch(a); err != nil {
+				logrus.Warnf("Skipping %s in the gather: %v", a.Name(), err)
+				continue
+			}
+			files = append(files, a.Files()...)
+		}
+		steps = append(steps, gather.ManifestsStep(files))
+	}
+
+	return steps
+}
+
+// sshUser returns the user to log in as, which is --ssh-user or else the
+// user of the platform.
+func (g *bootstrapGather) sshUser(platformUser string) string {
+	if g.SSHUser != "" {
+		return g.SSHUser
+	}
+	return platformUser
+}
+
+// checkOutput fails if output exists and may not be replaced, so that
+// nothing is collected for a bundle which cannot be written, and creates
+// the directory of output.
+func checkOutput(output string, force bool) error {
+	if output == "" {
+		return nil
+	}
+	if _, err := os.Stat(output); err == nil && !force {
+		return fmt.Errorf("%w: %s already exists, use --force to replace it", gather.ErrUsage, output)
+	}
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return fmt.Errorf("failed to create the directory of %s: %w", output, err)
+	}
+	return nil
+}
+
+// hostKeyCallback returns the callback verifying the host keys against
+// --known-hosts, or else accepting any host key.
+func (g *bootstrapGather) hostKeyCallback() (gossh.HostKeyCallback, error) {
+	if g.KnownHosts == "" {
+		logrus.Warn("Host key verification is disabled, use --known-hosts to verify the host keys")
+		return gossh.InsecureIgnoreHostKey(), nil
+	}
+	return ssh.KnownHostsCallback(g.KnownHosts)
+}
+
+// direct returns whether the SSH connections are made directly to the
+// hosts, rather than through a bastion or a proxy.
+func (g *bootstrapGather) direct() bool {
+	return g.Bastion == "" && g.HTTPSProxy == "" && g.ProxyCommand == ""
+}
+
+// gatherDialer returns the dialer used to open the SSH connections, which
+// tunnels through the bastion given by --bastion and the proxy given by
+// --https-proxy or --proxy-command, if any, along with a function closing the connection to
+// the bastion. The bastion is verified with --bastion-ca, or else with
+// hostKeyCallback.
+func (g *bootstrapGather) gatherDialer(hostKeyCallback gossh.HostKeyCallback) (ssh.Dialer, func(), error) {
+	dial := ssh.Dialer(net.Dial)
+	if g.ProxyCommand != "" {
+		if g.HTTPSProxy != "" {
+			return nil, nil, errors.New("--proxy-command and --https-proxy cannot be used together")
+		}
+		dial = ssh.CommandDialer(g.ProxyCommand)
+	}
+	if g.HTTPSProxy != "" {
+		proxyURL, err := ssh.ParseProxyURL(g.HTTPSProxy)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid --https-proxy: %w", err)
+		}
+		dial = ssh.HTTPConnectDialer(proxyURL, dial)
+	}
+	if g.DialTimeout > 0 {
+		dial = ssh.TimeoutDialer(dial, g.DialTimeout)
+	}
+	if g.Bastion == "" {
+		if g.BastionCA != "" || len(g.BastionKeys) > 0 {
+			return nil, nil, errors.New("--bastion-ca and --bastion-key require --bastion")
+		}
+		return dial, func() {}, nil
+	}
+
+	bastion, err := ssh.ParseBastion(g.Bastion, g.sshUser(tfgather.DefaultSSHUser))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid --bastion: %w", err)
+	}
+	if g.BastionCA != "" {
+		if hostKeyCallback, err = ssh.HostCertificateCallback(g.BastionCA); err != nil {
+			return nil, nil, err
+		}
+	}
+	keys := g.BastionKeys
+	if len(keys) == 0 {
+		keys = g.SSHKeys
+	}
+	logrus.Infof("Connecting through the bastion %s", bastion.Address)
+	dial, client, err := bastion.Dialer(keys, g.passphrases, hostKeyCallback, dial)
+	if err != nil {
+		return nil, nil, err
+	}
+	if g.DialTimeout > 0 {
+		dial = ssh.TimeoutDialer(dial, g.DialTimeout)
+	}
+	return dial, func() { client.Close() }, nil
+}
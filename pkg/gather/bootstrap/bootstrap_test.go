@@ -0,0 +1,188 @@
+package bootstrap
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/openshift/installer/pkg/gather"
+	"github.com/openshift/installer/pkg/gather/ssh"
+	tfgather "github.com/openshift/installer/pkg/terraform/gather"
+)
+
+// serveSSH serves SSH on address until the test ends, accepting any key.
+// Every command succeeds without output, but the ones containing fail,
+// which exit with 1.
+func serveSSH(t *testing.T, address, fail string) int {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := gossh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &gossh.ServerConfig{
+		PublicKeyCallback: func(gossh.ConnMetadata, gossh.PublicKey) (*gossh.Permissions, error) {
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(address, "0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSHConn(conn, config, fail)
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func serveSSHConn(conn net.Conn, config *gossh.ServerConfig, fail string) {
+	_, chans, reqs, err := gossh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go gossh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(gossh.UnknownChannelType, "only sessions are served")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer channel.Close()
+			for req := range requests {
+				if req.Type != "exec" {
+					req.Reply(req.Type != "subsystem", nil)
+					continue
+				}
+				var command struct{ Command string }
+				gossh.Unmarshal(req.Payload, &command)
+				req.Reply(true, nil)
+				status := struct{ Status uint32 }{}
+				if strings.Contains(command.Command, fail) {
+					status.Status = 1
+				}
+				channel.SendRequest("exit-status", false, gossh.Marshal(&status))
+				return
+			}
+		}()
+	}
+}
+
+func writeKey(t *testing.T, path string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// bundleFiles returns the names of the files in the gzipped tarball file.
+func bundleFiles(t *testing.T, file string) []string {
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return names
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+	}
+}
+
+func TestCollectBootstrapFailed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gather-bootstrap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	key := filepath.Join(dir, "id_ecdsa")
+	writeKey(t, key)
+
+	bootstrapPort := serveSSH(t, "127.0.0.1", defaultGatherScript)
+	masterPort := serveSSH(t, "127.0.0.2", defaultGatherScript)
+
+	opts := DefaultOptions()
+	opts.SSHKeys = []string{key}
+	opts.StepTimeout = 10 * time.Second
+	opts.Retries = 0
+	g := &bootstrapGather{
+		Options:     opts,
+		ctx:         ssh.WithShell(context.Background(), opts.RemoteShell),
+		start:       time.Now(),
+		passphrases: ssh.NewPassphrases(ssh.PromptPassphrase),
+		apiLimiter:  tfgather.NewAPILimiter(tfgather.DefaultAPIConcurrency),
+		masterPorts: map[string]int{"127.0.0.2": masterPort},
+	}
+
+	bundle, err := g.collect("127.0.0.1", "core", bootstrapPort, []string{"127.0.0.2"}, nil, dir, nil)
+	assert.True(t, errors.Is(err, gather.ErrPartial), "unexpected error: %v", err)
+	if !assert.NotEmpty(t, bundle) {
+		return
+	}
+	var masterFiles int
+	for _, name := range bundleFiles(t, bundle) {
+		if strings.Contains(name, "control-plane-live/127.0.0.2/") {
+			masterFiles++
+		}
+	}
+	assert.NotZero(t, masterFiles, "the control plane host is missing from the bundle")
+}
+
+func TestGatherInvalidSSHUser(t *testing.T) {
+	for _, user := range []string{" ", "core admin", "core\n"} {
+		t.Run(user, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.SSHUser = user
+			_, err := Gather(context.Background(), "", opts)
+			assert.True(t, errors.Is(err, gather.ErrUsage), "unexpected error: %v", err)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	configclient "github.com/openshift/client-go/config/clientset/versioned"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/openshift/installer/pkg/gather"
+)
+
+// ClusterOptions are the options of GatherCluster.
+type ClusterOptions struct {
+	// Kubeconfig is the path of the kubeconfig of the cluster, or else
+	// auth/kubeconfig in the assets directory.
+	Kubeconfig string
+
+	// SkipMustGather collects only the resources of
+	// gather.ClusterResourcesStep, without running must-gather.
+	SkipMustGather bool
+
+	// MustGatherTimeout is the time after which must-gather is stopped.
+	MustGatherTimeout time.Duration
+}
+
+// GatherCluster collects the debugging data of a cluster which bootstrapped
+// but did not finish installing, through its API, into a
+// cluster-bundle-<timestamp>.tar.gz in the assets directory dir, and
+// returns its path. must-gather is run with the oc binary in PATH, and is
+// skipped with a warning when there is none. The resources of
+// gather.ClusterResourcesStep are always collected.
+func GatherCluster(ctx context.Context, dir string, opts ClusterOptions) (string, error) {
+	kubeconfig := opts.Kubeconfig
+	if kubeconfig == "" {
+		kubeconfig = filepath.Join(dir, "auth", "kubeconfig")
+	}
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to load the kubeconfig: %w", err)
+	}
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to create the Kubernetes client: %w", err)
+	}
+	configClient, err := configclient.NewForConfig(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to create the config client: %w", err)
+	}
+
+	steps := []gather.Step{gather.ClusterResourcesStep(kubeClient, configClient)}
+	if !opts.SkipMustGather {
+		if oc, err := exec.LookPath("oc"); err != nil {
+			logrus.Warn("Skipping must-gather: no oc binary found in PATH, collecting only the installer resources")
+		} else {
+			steps = append(steps, gather.LocalMustGatherStep(oc, kubeconfig, opts.MustGatherTimeout))
+		}
+	}
+
+	staging, err := ioutil.TempDir("", "cluster-gather")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(staging)
+	summary := &gather.Summary{}
+	logrus.Infof("Gathering the cluster at %s", config.Host)
+	gather.RunStepsContext(ctx, &gather.Host{Role: "installer", Address: "localhost"}, staging, summary, steps, 0)
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("cluster gather interrupted: %w", err)
+	}
+	if err := summary.WriteFile(filepath.Join(staging, "summary.txt")); err != nil {
+		return "", err
+	}
+	for _, line := range summary.Lines() {
+		logrus.Info(line)
+	}
+
+	file := filepath.Join(dir, fmt.Sprintf("cluster-bundle-%s.tar.gz", time.Now().Format("20060102150405")))
+	if err := gather.Archive(file, "", staging); err != nil {
+		return "", fmt.Errorf("failed to create the cluster bundle: %w", err)
+	}
+	return file, nil
+}
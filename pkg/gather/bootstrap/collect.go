@@ -0,0 +1,511 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	gossh "golang.org/x/crypto/ssh"
+
+	ignitionbootstrap "github.com/openshift/installer/pkg/asset/ignition/bootstrap"
+	"github.com/openshift/installer/pkg/gather"
+	"github.com/openshift/installer/pkg/gather/ssh"
+	tfgather "github.com/openshift/installer/pkg/terraform/gather"
+	"github.com/openshift/installer/pkg/version"
+)
+
+// collect collects the bootstrap host, over SSH on port, with the extra
+// steps, and the masters and the targets, as user, into a log bundle in
+// directory, and returns its path.
+func (g *bootstrapGather) collect(bootstrap, user string, port int, masters []string, targets []gather.Target, directory string, steps []gather.Step) (string, error) {
+	g.directMasters = g.ParallelMasters || bootstrap == ""
+	if g.DryRun {
+		return "", g.printPlan(bootstrap, user, port, masters, targets)
+	}
+	hostKeyCallback, err := g.hostKeyCallback()
+	if err != nil {
+		return "", err
+	}
+	dial, closeDial, err := g.gatherDialer(hostKeyCallback)
+	if err != nil {
+		return "", err
+	}
+	defer closeDial()
+	g.dial = dial
+	if g.Estimate {
+		return g.estimateGatherBootstrap(bootstrap, user, port, dial, hostKeyCallback)
+	}
+	probed := len(masters)
+	pool := ssh.NewPoolWithHostKeyCallback(g.SSHKeys, g.passphrases, dial, hostKeyCallback)
+	defer pool.Close()
+	coverage := &gather.Coverage{}
+	coverage.NameHosts(g.hostNames)
+	defer g.logHosts(coverage)
+	if g.FirstReachableMaster {
+		masters = g.firstReachableMaster(masters, user, pool, coverage)
+	}
+	var encryptor *gather.Encryptor
+	if g.EncryptTo != "" {
+		if encryptor, err = gather.NewEncryptor(g.EncryptTo); err != nil {
+			return "", err
+		}
+	}
+
+	stage, err := g.gatherStage(directory)
+	if err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	if !g.Resume {
+		defer os.RemoveAll(stage)
+	}
+	addresses := append([]string{bootstrap}, masters...)
+	for _, target := range targets {
+		addresses = append(addresses, target.String())
+	}
+	state, err := gatherState(stage, addresses)
+	if err != nil {
+		return "", err
+	}
+	remoteDir := filepath.Join(stage, "remote")
+	collected := filepath.Join(stage, "collected")
+	for _, dir := range []string{remoteDir, collected} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create staging directory: %w", err)
+		}
+	}
+
+	summary := &gather.Summary{}
+	if g.FirstReachableMaster {
+		if len(masters) == 0 {
+			summary.Addf("control plane: none of the %d hosts accept SSH", probed)
+		} else {
+			summary.Addf("control plane: collected only %s, the first of %d hosts to accept SSH", masters[0], probed)
+		}
+	}
+	connect := func(target gather.Target) (*gossh.Client, error) {
+		if target.Role == "master" {
+			// The control plane hosts may have their own port, given
+			// with --master or --hosts-file.
+			return g.connect(pool, user, g.masterAddress(target.Address))
+		}
+		return g.connect(pool, user, net.JoinHostPort(target.Address, strconv.Itoa(tfgather.DefaultSSHPort)))
+	}
+	var live sync.WaitGroup
+	gatherMastersDirect := func() {
+		liveMasters := make([]gather.Target, 0, len(masters))
+		for _, master := range masters {
+			liveMasters = append(liveMasters, gather.Target{Role: "master", Address: master})
+		}
+		live.Add(1)
+		go func() {
+			defer live.Done()
+			if err := g.gatherDirect(liveMasters, func(target gather.Target) string { return "control-plane-live/" + target.Address }, connect, collected, summary, state, coverage); err != nil {
+				logrus.Warnf("Failed to connect to some control plane hosts, collected the others: %v", err)
+			}
+		}()
+	}
+	mastersDirect := g.IncludeMastersLive || g.ParallelMasters || g.FirstReachableMaster || bootstrap == ""
+	if mastersDirect {
+		gatherMastersDirect()
+	}
+	live.Add(1)
+	go func() {
+		defer live.Done()
+		if err := g.gatherDirect(targets, func(target gather.Target) string { return target.Role + "/" + target.Address }, connect, collected, summary, state, coverage); err != nil {
+			logrus.Warnf("Failed to connect to some hosts, collected the others: %v", err)
+		}
+	}()
+	bootstrapCollected, bootstrapGone, bootstrapUnreachable := false, false, false
+	if bootstrap == "" {
+		g.logger(phaseCollect, nil).Info("There is no bootstrap host, the control plane hosts are collected directly")
+		bootstrapGone = !g.AllowHealthy
+	} else if state.IsCollected(bootstrap) {
+		summary.Addf("bootstrap %s: resumed from a previous gather", bootstrap)
+		bootstrapCollected = true
+	} else if client, err := g.connect(pool, user, net.JoinHostPort(bootstrap, strconv.Itoa(port))); err != nil {
+		logrus.Warnf("Failed to connect to the bootstrap host %s, collecting from the reachable hosts: %v", bootstrap, err)
+		coverage.Unreachable("bootstrap", bootstrap, err)
+		bootstrapGone, bootstrapUnreachable = true, true
+		if !mastersDirect && len(masters) > 0 {
+			// installer-gather.sh would otherwise collect the control
+			// plane hosts one after the other from one of them.
+			g.logger(phaseCollect, nil).Infof("Collecting the %d control plane hosts directly and in parallel", len(masters))
+			summary.Addf("bootstrap: unreachable, the control plane hosts were collected directly and in parallel")
+			g.directMasters = true
+			gatherMastersDirect()
+		}
+	} else {
+		coverage.Reached("bootstrap", bootstrap)
+		reconnect := func() (*gossh.Client, error) {
+			return pool.Reconnect(user, net.JoinHostPort(bootstrap, strconv.Itoa(port)))
+		}
+		if err := g.gatherBootstrapHost(bootstrap, user, client, reconnect, masters, remoteDir, collected, steps, summary); err != nil {
+			if g.ctx.Err() != nil {
+				live.Wait()
+				return "", err
+			}
+			// The data of the other hosts is still written, and the
+			// failure reported as a partial gather.
+			logrus.Warnf("Failed to collect the bootstrap host %s, collecting from the other hosts: %v", bootstrap, err)
+			coverage.Failed("bootstrap", bootstrap, err)
+			summary.Addf("bootstrap %s: %v", bootstrap, err)
+			if err := resetDir(remoteDir); err != nil {
+				return "", fmt.Errorf("failed to discard the partial bundle of the bootstrap host: %w", err)
+			}
+			if !mastersDirect && len(masters) > 0 {
+				g.logger(phaseCollect, nil).Infof("Collecting the %d control plane hosts directly and in parallel", len(masters))
+				g.directMasters = true
+				gatherMastersDirect()
+			}
+		} else {
+			bootstrapCollected = true
+			if err := state.MarkCollected(bootstrap); err != nil {
+				return "", fmt.Errorf("failed to save the gather state: %w", err)
+			}
+			if g.Resume {
+				summary.Addf("bootstrap %s: collected", bootstrap)
+			}
+		}
+	}
+
+	scriptMaster := ""
+	if bootstrapGone && !g.NoScript && len(masters) > 0 {
+		if scriptMaster, err = g.gatherFromMaster(masters, user, pool, remoteDir); err != nil {
+			logrus.Warnf("Failed to run installer-gather.sh from a control plane host: %v", err)
+		} else if scriptMaster != "" {
+			summary.Addf("bootstrap: unreachable, installer-gather.sh was run from the control plane host %s", scriptMaster)
+		}
+	}
+
+	live.Wait()
+	if err := g.ctx.Err(); err != nil {
+		return "", err
+	}
+
+	pulled, err := gatherPulledBundle(remoteDir, (bootstrapCollected || scriptMaster != "") && !g.NoScript)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case scriptMaster != "":
+		recordScriptHosts(pulled, "master", scriptMaster, masters, coverage)
+	case bootstrapCollected && pulled != "":
+		recordScriptHosts(pulled, "bootstrap", bootstrap, masters, coverage)
+	case bootstrapCollected:
+		coverage.Gathered("bootstrap", bootstrap, 0)
+	}
+	ext := ".tar.gz"
+	if pulled != "" {
+		ext = gather.BundleExtension(pulled)
+	}
+	if ext == "" {
+		if ext, err = gather.DetectBundleExtension(pulled); err != nil {
+			return "", fmt.Errorf("failed to detect the log bundle compression: %w", err)
+		}
+	}
+
+	name := "log-bundle"
+	if scriptMaster != "" {
+		name = "log-bundle-master"
+	}
+	base := filepath.Join(directory, fmt.Sprintf("%s-%s", name, time.Now().Format("20060102150405")))
+	file := base + ext
+	if g.Output != "" {
+		base, file = strings.TrimSuffix(g.Output, gather.BundleExtension(g.Output)), g.Output
+	}
+	if g.Minimal && pulled != "" {
+		if file, err = moveBundle(encryptor, pulled, file); err != nil {
+			return "", fmt.Errorf("failed to move log bundle: %w", err)
+		}
+		g.logger(phaseArchive, logrus.Fields{"bundle": file}).Infof("Bootstrap gather logs captured here %q", file)
+		if err := g.gatherMetadata(bootstrap, user, masters, coverage).WriteFile(filepath.Join(filepath.Dir(file), gather.MetadataFileName)); err != nil {
+			logrus.Warnf("Failed to write the gather metadata: %v", err)
+		}
+		return file, os.RemoveAll(stage)
+	}
+
+	if g.IncludeWindowsNodes {
+		g.gatherWindowsNodes(directory, pool, collected, summary, state, coverage)
+	}
+	if err := coverage.Summarize(summary, filepath.Join(collected, "unreachable.txt")); err != nil {
+		return "", fmt.Errorf("failed to record the unreachable hosts: %w", err)
+	}
+	localSteps := []gather.Step{g.gatherTerraformStep(directory)}
+	if g.serialConsole != nil && (g.SerialLog || bootstrapUnreachable && !g.NoSerialFallback) {
+		if !g.SerialLog {
+			logrus.Infof("Fetching the serial console output of the hosts from the %s API, the bootstrap host is unreachable over SSH", g.platform)
+		}
+		localSteps = append(localSteps, gather.SerialConsoleStep(g.serialConsole))
+	}
+	gather.RunStepsContext(g.ctx, &gather.Host{Role: "installer", Address: "localhost"}, collected, summary, localSteps, 0)
+
+	analyzed := []string{collected}
+	if pulled != "" && (ext == ".tar.gz" || ext == ".tgz") {
+		analyzed = append(analyzed, pulled)
+	}
+	analyzers := gather.DefaultAnalyzers()
+	if files, err := gather.ReadBundle(analyzers, analyzed...); err != nil {
+		logrus.Warnf("Failed to analyze the log bundle: %v", err)
+	} else {
+		gather.Analyze(analyzers, files, summary)
+	}
+	for _, line := range summary.Lines() {
+		logrus.Info(line)
+	}
+	if err := summary.WriteFile(filepath.Join(collected, "summary.txt")); err != nil {
+		return "", fmt.Errorf("failed to write gather summary: %w", err)
+	}
+	if err := g.gatherVersions().WriteFile(filepath.Join(collected, gather.VersionsFileName)); err != nil {
+		logrus.Warnf("Failed to write the versions: %v", err)
+	}
+	metadata := g.gatherMetadata(bootstrap, user, masters, coverage)
+	if err := metadata.WriteFile(filepath.Join(collected, gather.MetadataFileName)); err != nil {
+		return "", fmt.Errorf("failed to write gather metadata: %w", err)
+	}
+
+	if ext == ".tar.gz" || ext == ".tgz" {
+		if file, err = archiveBundle(encryptor, file, pulled, collected); err != nil {
+			return "", fmt.Errorf("failed to create log bundle: %w", err)
+		}
+	} else {
+		// Only gzipped bundles can be extended, so the collected data is
+		// archived next to the remote bundle.
+		if file, err = moveBundle(encryptor, pulled, file); err != nil {
+			return "", fmt.Errorf("failed to move log bundle: %w", err)
+		}
+		diagnostics, err := archiveBundle(encryptor, base+"-diagnostics.tar.gz", "", collected)
+		if err != nil {
+			return "", fmt.Errorf("failed to create diagnostics bundle: %w", err)
+		}
+		g.logger(phaseArchive, logrus.Fields{"bundle": diagnostics}).Infof("Bootstrap gather diagnostics captured here %q", diagnostics)
+	}
+	g.logger(phaseArchive, logrus.Fields{"bundle": file}).Infof("Bootstrap gather logs captured here %q", file)
+	if err := metadata.WriteFile(filepath.Join(filepath.Dir(file), gather.MetadataFileName)); err != nil {
+		logrus.Warnf("Failed to write the gather metadata: %v", err)
+	}
+	if err := os.RemoveAll(stage); err != nil {
+		return file, err
+	}
+	return file, coverage.Err()
+}
+
+// gatherMetadata returns the metadata of the gather from bootstrap and
+// masters as user, with the hosts recorded in coverage so far.
+func (g *bootstrapGather) gatherMetadata(bootstrap, user string, masters []string, coverage *gather.Coverage) *gather.Metadata {
+	return &gather.Metadata{
+		Platform:         g.platform,
+		InstallerVersion: version.Raw,
+		SSHUser:          user,
+		Bootstrap:        bootstrap,
+		Masters:          append([]string{}, masters...),
+		Hosts:            append([]gather.HostResult{}, coverage.Hosts()...),
+		StartTime:        g.start,
+		EndTime:          time.Now(),
+	}
+}
+
+// gatherVersions returns the versions of the installer and the release the
+// cluster was installed with, and of the bootstrap host read when it was
+// collected.
+func (g *bootstrapGather) gatherVersions() *gather.Versions {
+	versions := g.bootstrapVersions
+	versions.Installer = version.Raw
+	if releaseImage, err := gatherReleaseImage(); err != nil {
+		logrus.Debugf("Failed to read the release image: %v", err)
+	} else {
+		versions.ReleaseImage = releaseImage
+	}
+	return &versions
+}
+
+// archiveBundle archives base and dir into file like gather.Archive, or
+// into its encryption when encryptor is not nil, and returns the path of
+// the written file.
+func archiveBundle(encryptor *gather.Encryptor, file, base, dir string) (string, error) {
+	if encryptor == nil {
+		return file, gather.Archive(file, base, dir)
+	}
+	return encryptor.Encrypt(file, func(w io.Writer) error {
+		return gather.WriteArchive(w, base, dir)
+	})
+}
+
+// moveBundle moves the bundle src to dst, or encrypts it to dst when
+// encryptor is not nil, and returns the path of the written file.
+func moveBundle(encryptor *gather.Encryptor, src, dst string) (string, error) {
+	if encryptor == nil {
+		if err := os.Rename(src, dst); err == nil {
+			return dst, nil
+		}
+		// dst may be on another file system, given by --output.
+		if err := copyFile(src, dst); err != nil {
+			return "", err
+		}
+		return dst, os.Remove(src)
+	}
+	dst, err := encryptor.Encrypt(dst, func(w io.Writer) error {
+		in, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		_, err = io.Copy(w, in)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return dst, os.Remove(src)
+}
+
+// copyFile copies the file src to dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+// gatherStage returns the directory the gathered data is staged in before
+// it is archived. With --resume, the directory is kept across runs until a
+// gather completes.
+func (g *bootstrapGather) gatherStage(directory string) (string, error) {
+	if g.Resume {
+		stage := filepath.Join(directory, ".gather")
+		return stage, os.MkdirAll(stage, 0755)
+	}
+	return ioutil.TempDir(directory, ".gather-")
+}
+
+// gatherState loads the state of a previous gather from stage, discarding
+// it and any data it collected if it was for different addresses.
+func gatherState(stage string, addresses []string) (*gather.State, error) {
+	state, err := gather.LoadState(filepath.Join(stage, "state.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the gather state: %w", err)
+	}
+	inputs := gather.StateInputs(addresses...)
+	if state.Inputs == inputs {
+		return state, nil
+	}
+	if len(state.Collected) > 0 {
+		logrus.Info("Discarding the previous gather, which was for different hosts")
+	}
+	for _, dir := range []string{"remote", "collected"} {
+		if err := os.RemoveAll(filepath.Join(stage, dir)); err != nil {
+			return nil, fmt.Errorf("failed to discard the previous gather: %w", err)
+		}
+	}
+	state.Inputs = inputs
+	state.Collected = nil
+	return state, state.Save()
+}
+
+// gatherBootstrapHost runs installer-gather.sh on the bootstrap host
+// connected to by client and pulls the bundle it creates into remoteDir,
+// using reconnect to replace client when the transfer fails, then runs the
+// steps on the host, writing into collected.
+func (g *bootstrapGather) gatherBootstrapHost(address, user string, client *gossh.Client, reconnect func() (*gossh.Client, error), masters []string, remoteDir, collected string, steps []gather.Step, summary *gather.Summary) error {
+	g.logger(phaseCollect, logrus.Fields{"host": address}).Info("Pulling debug logs from the bootstrap machine")
+	g.bootstrapVersions.ReadHost(g.ctx, &gather.Host{Role: "bootstrap", Address: address, Client: client})
+	if g.NoScript {
+		logrus.Info("Collecting without installer-gather.sh, the control plane hosts are not collected")
+		gather.RunStepsContext(g.ctx, &gather.Host{Role: "bootstrap", Address: address, Client: client}, collected, summary, gather.BootstrapScriptSteps(g.MaxUnitLogBytes), g.StepTimeout)
+	} else {
+		checkGatherVersionSkew(g.ctx, &gather.Host{Role: "bootstrap", Address: address, Client: client})
+		var err error
+		if client, err = g.pullBootstrapBundle(client, reconnect, user, masters, remoteDir); err != nil {
+			return err
+		}
+	}
+
+	if g.Minimal {
+		return nil
+	}
+	steps = append(append(gather.DefaultSteps(), gather.ClusterBootstrapStep(), gather.CSRStep(), gather.AuditLogStep(g.MaxUnitLogBytes)), steps...)
+	if g.IncludeClusterDNS {
+		steps = append(steps, gather.ClusterDNSStep(g.MaxUnitLogBytes))
+	}
+	if g.IncludeOptOpenShift {
+		steps = append(steps, gather.OptOpenShiftStep())
+	}
+	if g.IncludeStaticPods {
+		steps = append(steps, gather.StaticPodsStep())
+	}
+	if g.IncludeEtcdSnapshot {
+		steps = append(steps, gather.EtcdSnapshotStep(g.EtcdSnapshotMaxBytes))
+	}
+	host := &gather.Host{Role: "bootstrap", Address: address, Client: client}
+	gather.RunStepsContext(g.ctx, host, collected, summary, steps, g.StepTimeout)
+
+	if g.IncludeMustGather {
+		releaseImage, err := gatherReleaseImage()
+		if err != nil {
+			summary.Addf("must-gather: %v", err)
+			return nil
+		}
+		// must-gather gets a minute on top of its own timeout to
+		// archive and pull its output.
+		gather.RunStepsContext(g.ctx, host, collected, summary, []gather.Step{gather.MustGatherStep(releaseImage, g.MustGatherTimeout)}, g.MustGatherTimeout+time.Minute)
+	}
+	return nil
+}
+
+// gatherReleaseImage returns the release image the bootstrap host was
+// configured with.
+func gatherReleaseImage() (string, error) {
+	if ri, ok := os.LookupEnv("OPENSHIFT_INSTALL_RELEASE_IMAGE_OVERRIDE"); ok && ri != "" {
+		return ri, nil
+	}
+	return ignitionbootstrap.DefaultReleaseImage()
+}
+
+// checkGatherVersionSkew warns when the release of the cluster is far from
+// the version of the installer, because installer-gather.sh on the host
+// may not accept the arguments the installer passes to it.
+func checkGatherVersionSkew(ctx context.Context, host *gather.Host) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	release, err := gather.ReleaseVersion(ctx, host)
+	if err != nil {
+		logrus.Debugf("Skipping the version skew check: %v", err)
+		return
+	}
+	if gather.VersionSkew(version.Raw, release) {
+		logrus.Warnf("This installer (%s) is from a different release than the cluster (%s), and installer-gather.sh on the bootstrap host may not accept its arguments. If the gather fails, retry with the %s installer", version.Raw, release, release)
+	}
+}
+
+// gatherTerraformStep returns the step which records the terraform failure
+// logged in the assets directory and, with --include-terraform-log, the
+// terraform debug log.
+func (g *bootstrapGather) gatherTerraformStep(directory string) gather.Step {
+	var debugLog string
+	if g.IncludeTerraformLog {
+		debugLog = os.Getenv("TF_LOG_PATH")
+		if debugLog == "" {
+			debugLog = filepath.Join(directory, "terraform.log")
+		}
+	}
+	return gather.TerraformStep(filepath.Join(directory, ".openshift_install.log"), debugLog)
+}
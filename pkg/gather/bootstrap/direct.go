@@ -0,0 +1,160 @@
+package bootstrap
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	gossh "golang.org/x/crypto/ssh"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/openshift/installer/pkg/gather"
+	"github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// gatherDirect collects targets directly, rather than through the bootstrap
+// host, each into the directory of dir returned by subdir, which also keys
+// the target in state. Control plane hosts are collected into
+// control-plane-live, apart from the control-plane directory of the bundle,
+// which is collected through the bootstrap host. At most --max-parallel-hosts
+// targets are collected at a time. The targets which cannot be connected to
+// are recorded in coverage, and their errors returned combined.
+func (g *bootstrapGather) gatherDirect(targets []gather.Target, subdir func(gather.Target) string, connect func(gather.Target) (*gossh.Client, error), dir string, summary *gather.Summary, state *gather.State, coverage *gather.Coverage) error {
+	var pending []gather.Target
+	for _, target := range targets {
+		if state.IsCollected(subdir(target)) {
+			summary.Addf("%s %s: resumed from a previous gather", target.Role, target.Address)
+			continue
+		}
+		pending = append(pending, target)
+	}
+
+	return gather.CollectReachable(pending, g.MaxParallel, connect, func(target gather.Target, client *gossh.Client) {
+		g.logger(phaseCollect, logrus.Fields{"host": target.Address}).Infof("Pulling debug logs from the %s host %s", target.Role, target.Address)
+		steps := append(gather.ControlPlaneSteps(g.MaxUnitLogBytes), gather.DefaultSteps()...)
+		if g.IncludeStaticPods {
+			steps = append(steps, gather.StaticPodsStep())
+		}
+		if g.IncludeEtcdSnapshot {
+			steps = append(steps, gather.EtcdSnapshotStep(g.EtcdSnapshotMaxBytes))
+		}
+		host := &gather.Host{Role: target.Role, Address: target.Address, Client: client}
+		gather.RunStepsContext(g.ctx, host, filepath.Join(dir, subdir(target)), summary, steps, g.StepTimeout)
+		recordDirectHost(target.Role, target.Address, filepath.Join(dir, subdir(target)), coverage)
+		if err := state.MarkCollected(subdir(target)); err != nil {
+			logrus.Warnf("Failed to save the gather state: %v", err)
+		}
+	}, coverage)
+}
+
+// recordDirectHost records in coverage that the host with role at address
+// was collected into dir, with the size of dir.
+func recordDirectHost(role, address, dir string, coverage *gather.Coverage) {
+	bytes, err := gather.BundleBytes(dir)
+	if err != nil {
+		logrus.Debugf("Failed to measure the data of the %s host %s: %v", role, address, err)
+	}
+	coverage.Gathered(role, address, bytes)
+}
+
+// connect returns the client of pool connected to address as user. When the
+// host cannot be connected to, the error holds the diagnosis of whether it
+// resolves and accepts TCP connections on its SSH port, which is only run
+// then, to keep the hosts which can be connected to fast.
+func (g *bootstrapGather) connect(pool *ssh.Pool, user, address string) (*gossh.Client, error) {
+	client, err := pool.Client(user, address)
+	if err != nil && g.ctx.Err() == nil {
+		err = gather.Diagnose(address, g.dial, g.direct(), err)
+	}
+	return client, err
+}
+
+// firstReachableMaster probes masters in order and returns the first one
+// which accepts an SSH session, or none if no master does. The session is
+// kept in pool for collecting the master, and the masters probed before it
+// are recorded in coverage.
+func (g *bootstrapGather) firstReachableMaster(masters []string, user string, pool *ssh.Pool, coverage *gather.Coverage) []string {
+	for _, master := range masters {
+		if _, err := g.connect(pool, user, g.masterAddress(master)); err != nil {
+			logrus.Debugf("Control plane host %s is not reachable: %v", master, err)
+			coverage.Unreachable("master", master, err)
+			continue
+		}
+		logrus.Infof("Using the first reachable control plane host %s", master)
+		return []string{master}
+	}
+	return nil
+}
+
+// gatherWindowsNodes collects data from the Windows nodes listed by the
+// cluster into dir/windows/<node>. Failures are recorded in the summary, and
+// the nodes which cannot be connected to in coverage.
+func (g *bootstrapGather) gatherWindowsNodes(directory string, pool *ssh.Pool, dir string, summary *gather.Summary, state *gather.State, coverage *gather.Coverage) {
+	nodes, err := g.listGatherNodes(filepath.Join(directory, "auth", "kubeconfig"))
+	if err != nil {
+		summary.Addf("windows: %v", err)
+		return
+	}
+
+	for _, node := range nodes {
+		if node.OperatingSystem != "windows" {
+			continue
+		}
+		if node.Address == "" {
+			summary.Addf("windows: %s has no address", node.Name)
+			continue
+		}
+		key := "windows/" + node.Name
+		if state.IsCollected(key) {
+			summary.Addf("windows %s: resumed from a previous gather", node.Name)
+			continue
+		}
+		g.logger(phaseCollect, logrus.Fields{"host": node.Address}).Infof("Pulling debug logs from the Windows node %s", node.Name)
+		client, err := pool.Client(gather.WindowsUser, net.JoinHostPort(node.Address, "22"))
+		if err != nil {
+			coverage.Unreachable("windows", node.Address, err)
+			continue
+		}
+		coverage.Reached("windows", node.Address)
+		host := &gather.Host{Role: "windows", Address: node.Address, Client: client}
+		gather.RunStepsContext(g.ctx, host, filepath.Join(dir, "windows", node.Name), summary, gather.WindowsSteps(), g.StepTimeout)
+		recordDirectHost("windows", node.Address, filepath.Join(dir, "windows", node.Name), coverage)
+		if err := state.MarkCollected(key); err != nil {
+			logrus.Warnf("Failed to save the gather state: %v", err)
+		}
+		if g.Resume {
+			summary.Addf("windows %s: collected", node.Name)
+		}
+	}
+}
+
+// listGatherNodes lists the nodes of the cluster using kubeconfig, with the
+// port of its server replaced by --api-port.
+func (g *bootstrapGather) listGatherNodes(kubeconfig string) ([]gather.Node, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the kubeconfig: %w", err)
+	}
+	if config.Host, err = withPort(config.Host, g.APIPort); err != nil {
+		return nil, fmt.Errorf("invalid server in the kubeconfig: %w", err)
+	}
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the Kubernetes client: %w", err)
+	}
+	return gather.ListNodes(kubeClient)
+}
+
+// withPort returns server, a URL, with its port replaced by port.
+func withPort(server string, port int) (string, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return "", err
+	}
+	u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(port))
+	return u.String(), nil
+}
@@ -0,0 +1,487 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/installer/pkg/asset/cluster"
+	"github.com/openshift/installer/pkg/gather"
+	"github.com/openshift/installer/pkg/terraform"
+	tfgather "github.com/openshift/installer/pkg/terraform/gather"
+	gatheraws "github.com/openshift/installer/pkg/terraform/gather/aws"
+	gatherazure "github.com/openshift/installer/pkg/terraform/gather/azure"
+	gatherlibvirt "github.com/openshift/installer/pkg/terraform/gather/libvirt"
+	gathermetal "github.com/openshift/installer/pkg/terraform/gather/metal"
+	gatheropenstack "github.com/openshift/installer/pkg/terraform/gather/openstack"
+	gathervsphere "github.com/openshift/installer/pkg/terraform/gather/vsphere"
+	"github.com/openshift/installer/pkg/types"
+	awstypes "github.com/openshift/installer/pkg/types/aws"
+	azuretypes "github.com/openshift/installer/pkg/types/azure"
+	libvirttypes "github.com/openshift/installer/pkg/types/libvirt"
+	nonetypes "github.com/openshift/installer/pkg/types/none"
+	openstacktypes "github.com/openshift/installer/pkg/types/openstack"
+	vspheretypes "github.com/openshift/installer/pkg/types/vsphere"
+)
+
+// serialConsole returns the function fetching the serial console output of
+// the hosts in the terraform state of platform, and false when platform does
+// not support it. On OpenStack, the output is fetched with the credentials
+// of cloud in clouds.yaml. The API calls are made through limiter.
+func serialConsole(platform, cloud string, limiter *tfgather.APILimiter) (func(context.Context, *terraform.State) (map[string][]byte, error), bool) {
+	switch platform {
+	case awstypes.Name:
+		return func(ctx context.Context, tfs *terraform.State) (map[string][]byte, error) {
+			return gatheraws.SerialConsole(ctx, tfs, limiter)
+		}, true
+	case azuretypes.Name:
+		return func(ctx context.Context, tfs *terraform.State) (map[string][]byte, error) {
+			return gatherazure.SerialConsole(ctx, tfs, limiter)
+		}, true
+	case openstacktypes.Name:
+		return func(ctx context.Context, tfs *terraform.State) (map[string][]byte, error) {
+			return gatheropenstack.SerialConsole(ctx, tfs, cloud, limiter)
+		}, true
+	}
+	return nil, false
+}
+
+// platformGatherers holds the function returning the Gatherer of each
+// platform the host addresses can be extracted from terraform state for. The
+// addresses of hosts with several addresses, like the ones of dual-stack or
+// IPv6 clusters, are preferred from machineCIDR, which may be nil.
+var platformGatherers = map[string]func(machineCIDR *net.IPNet) tfgather.Gatherer{
+	awstypes.Name: func(machineCIDR *net.IPNet) tfgather.Gatherer {
+		return gatheraws.Gatherer{MachineCIDR: machineCIDR}
+	},
+	azuretypes.Name: func(machineCIDR *net.IPNet) tfgather.Gatherer {
+		return gatherazure.Gatherer{MachineCIDR: machineCIDR}
+	},
+	libvirttypes.Name: func(*net.IPNet) tfgather.Gatherer {
+		return gatherlibvirt.Gatherer{}
+	},
+	// The terraform state of the none platform is read when it is the one
+	// of the UPI terraform for bare metal, see extractHostAddresses.
+	nonetypes.Name: func(*net.IPNet) tfgather.Gatherer {
+		return gathermetal.Gatherer{}
+	},
+	openstacktypes.Name: func(machineCIDR *net.IPNet) tfgather.Gatherer {
+		return gatheropenstack.Gatherer{MachineCIDR: machineCIDR}
+	},
+	// The terraform state of vSphere is the one of the UPI terraform, whose
+	// hosts may have addresses on several networks.
+	vspheretypes.Name: func(machineCIDR *net.IPNet) tfgather.Gatherer {
+		return gathervsphere.Gatherer{MachineCIDR: machineCIDR}
+	},
+}
+
+func (g *bootstrapGather) extractHostAddresses(config *types.InstallConfig, tfstate *terraform.State) (bootstrap string, user string, port int, masters []string, err error) {
+	newGatherer, ok := platformGatherers[config.Platform.Name()]
+	if config.Platform.Name() == nonetypes.Name && !gathermetal.HasDevices(tfstate) {
+		// Other clusters on the none platform need --bootstrap and
+		// --master, as their hosts are not in a terraform state.
+		ok = false
+	}
+	if !ok {
+		return "", "", 0, nil, fmt.Errorf("%w %s", gather.ErrUnsupportedPlatform, config.Platform.Name())
+	}
+	var machineCIDR *net.IPNet
+	if config.Networking != nil && config.Networking.MachineCIDR != nil {
+		machineCIDR = &config.Networking.MachineCIDR.IPNet
+	}
+	gatherer := newGatherer(machineCIDR)
+	user, port = g.sshUser(gatherer.SSHUser()), gatherer.SSHPort()
+	masterHosts, mastersErr := gatherer.ControlPlaneHosts(tfstate)
+	if mastersErr != nil {
+		logrus.Errorf("Failed to get the control plane host addresses: %v", mastersErr)
+	}
+	masters = gather.NormalizeAddresses(tfgather.Addresses(masterHosts))
+	if len(masters) == 0 {
+		masterHosts = tfgather.MasterHosts(g.controlPlaneIPsFromAPI(config))
+		masters = tfgather.Addresses(masterHosts)
+	}
+	if len(masters) == 0 {
+		logrus.Warn("No control plane host addresses found in the state file, gathering only the bootstrap host")
+	} else {
+		g.logger(phaseDiscover, nil).Infof("Found %d control plane host addresses", len(masters))
+	}
+	bootstrapHost, err := gatherer.BootstrapHost(tfstate)
+	if err != nil || bootstrapHost.Address == "" {
+		if apiBootstrap := g.bootstrapIPFromAPI(config); apiBootstrap != "" {
+			bootstrap, masters, err = g.selectHosts(apiBootstrap, masterHosts)
+			return bootstrap, user, port, masters, err
+		}
+	}
+	bootstrap = bootstrapHost.Address
+	if err != nil {
+		// The bootstrap host may already be destroyed, in which case
+		// installer-gather.sh is run from a control plane host.
+		if !g.AllowHealthy && !g.IncludeTerraformLog && len(masters) == 0 {
+			return bootstrap, user, port, masters, fmt.Errorf("%w: %v", gather.ErrNoBootstrap, err)
+		}
+		logrus.Infof("Gathering without a bootstrap host: %v", err)
+		bootstrap = ""
+	}
+	bootstrap, masters, err = g.selectHosts(bootstrap, masterHosts)
+	return bootstrap, user, port, masters, err
+}
+
+// selectHosts returns the address of the bootstrap host, which is empty
+// when there is none, and the normalized addresses of the control plane
+// hosts, restricted to the hosts --only selects. The names of the hosts are
+// kept for the per-host results.
+func (g *bootstrapGather) selectHosts(bootstrap string, masters []tfgather.Host) (string, []string, error) {
+	hosts := masters
+	if bootstrap != "" {
+		hosts = append([]tfgather.Host{{Name: tfgather.BootstrapHostName, Address: bootstrap}}, masters...)
+	}
+	if len(g.Only) > 0 {
+		selected, err := tfgather.FilterHosts(hosts, g.Only)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: invalid --only: %v", gather.ErrUsage, err)
+		}
+		names := make([]string, 0, len(selected))
+		for _, host := range selected {
+			names = append(names, host.Name)
+		}
+		g.logger(phaseDiscover, nil).Infof("Gathering only %s", strings.Join(names, ", "))
+		hosts = selected
+	}
+
+	g.hostNames = map[string]string{}
+	bootstrap = ""
+	var addresses []string
+	for _, host := range hosts {
+		if host.Address == "" {
+			continue
+		}
+		g.hostNames[host.Address] = host.Name
+		if host.Name == tfgather.BootstrapHostName {
+			bootstrap = host.Address
+		} else {
+			addresses = append(addresses, host.Address)
+		}
+	}
+	return bootstrap, gather.NormalizeAddresses(addresses), nil
+}
+
+// checkStatePlatform fails when tfstate was created for another platform
+// than platform, the one of the install config, which would leave no host
+// addresses to read from it, unless --ignore-platform-mismatch is set.
+func (g *bootstrapGather) checkStatePlatform(platform string, tfstate *terraform.State) error {
+	resourceTypes := make([]string, 0, len(tfstate.Resources))
+	for _, resource := range tfstate.Resources {
+		resourceTypes = append(resourceTypes, resource.Type)
+	}
+	err := gather.CheckStatePlatform(platform, resourceTypes)
+	if err != nil && g.IgnorePlatformMismatch {
+		logrus.Warn(err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w (was the install config edited after the cluster was created?), use --ignore-platform-mismatch to read the state anyway, or --bootstrap and --master", err)
+	}
+	return nil
+}
+
+func (g *bootstrapGather) unSupportedPlatformGather(directory string) (string, error) {
+	if (g.Bootstrap == "" && !g.AllowHealthy) || len(g.Masters) == 0 {
+		return "", fmt.Errorf("%w: boostrap host address and at least one control plane host address must be provided", gather.ErrNoBootstrap)
+	}
+	bootstrap, masters, err := g.selectHosts(g.Bootstrap, tfgather.MasterHosts(g.Masters))
+	if err != nil {
+		return "", err
+	}
+
+	port := tfgather.DefaultSSHPort
+	if g.SSHPort != 0 {
+		port = g.SSHPort
+	}
+	if g.bootstrapPort != 0 {
+		port = g.bootstrapPort
+	}
+	targets, err := g.hostTargets()
+	if err != nil {
+		return "", err
+	}
+	return g.collect(bootstrap, g.sshUser(tfgather.DefaultSSHUser), port, masters, targets, directory, nil)
+}
+
+// apiDiscoverers holds the functions looking up the bootstrap and control
+// plane host addresses with the cloud API, by the infrastructure ID of the
+// cluster, of each platform which supports it. They are the fallback for
+// terraform states which hold no addresses, like the ones written by
+// provider versions whose schema differs.
+var apiDiscoverers = map[string]struct {
+	bootstrapIP     func(context.Context, *tfgather.APILimiter, *types.InstallConfig, string) (string, error)
+	controlPlaneIPs func(context.Context, *tfgather.APILimiter, *types.InstallConfig, string) ([]string, error)
+}{
+	awstypes.Name:   {bootstrapIP: gatheraws.BootstrapIPFromAPI, controlPlaneIPs: gatheraws.ControlPlaneIPsFromAPI},
+	azuretypes.Name: {bootstrapIP: gatherazure.BootstrapIPFromAPI, controlPlaneIPs: gatherazure.ControlPlaneIPsFromAPI},
+}
+
+// bootstrapIPFromAPI returns the bootstrap host address looked up with the
+// cloud API, or an empty string when the platform or the metadata do not
+// allow it, or it cannot be found.
+func (g *bootstrapGather) bootstrapIPFromAPI(config *types.InstallConfig) string {
+	discover, ok := apiDiscoverers[config.Platform.Name()]
+	if !ok || g.infraID == "" {
+		return ""
+	}
+	logrus.Infof("No bootstrap host address found in the state file, looking it up with the %s API", config.Platform.Name())
+	bootstrap, err := discover.bootstrapIP(g.ctx, g.apiLimiter, config, g.infraID)
+	if err != nil {
+		// The bootstrap host is usually missing because it was already
+		// destroyed.
+		logrus.Infof("No bootstrap host found with the %s API: %v", config.Platform.Name(), err)
+		return ""
+	}
+	return bootstrap
+}
+
+// controlPlaneIPsFromAPI returns the control plane host addresses looked up
+// with the cloud API, or none when the platform or the metadata do not
+// allow it, or they cannot be found.
+func (g *bootstrapGather) controlPlaneIPsFromAPI(config *types.InstallConfig) []string {
+	discover, ok := apiDiscoverers[config.Platform.Name()]
+	if !ok || g.infraID == "" {
+		return nil
+	}
+	logrus.Infof("No control plane host addresses found in the state file, looking them up with the %s API", config.Platform.Name())
+	masters, err := discover.controlPlaneIPs(g.ctx, g.apiLimiter, config, g.infraID)
+	if err != nil {
+		logrus.Warnf("Failed to look up the control plane host addresses with the %s API: %v", config.Platform.Name(), err)
+		return nil
+	}
+	return gather.NormalizeAddresses(masters)
+}
+
+// hostDiscoverers holds the function looking up the bootstrap and control
+// plane host addresses with the cloud API from the cluster metadata, for when
+// the terraform state is gone, of each platform which supports it.
+var hostDiscoverers = map[string]func(context.Context, *types.ClusterMetadata, *tfgather.APILimiter) (string, []string, error){
+	awstypes.Name: gatheraws.DiscoverHostsFromMetadata,
+}
+
+// gatherFromMetadata collects the hosts discovered from the metadata.json of
+// the assets directory when there is no terraform state. The addresses given
+// with --bootstrap and --master take precedence, and are required when there
+// is no metadata either.
+func (g *bootstrapGather) gatherFromMetadata(directory string) (string, error) {
+	if g.Bootstrap != "" || len(g.Masters) > 0 {
+		return g.unSupportedPlatformGather(directory)
+	}
+	metadata, err := cluster.LoadMetadata(directory)
+	if os.IsNotExist(err) {
+		return g.unSupportedPlatformGather(directory)
+	} else if err != nil {
+		return "", err
+	}
+	platform := metadata.Platform()
+	if platform == "" {
+		return g.unSupportedPlatformGather(directory)
+	}
+	discover, ok := hostDiscoverers[platform]
+	if !ok {
+		return "", fmt.Errorf("%w %s: there is no terraform state, and the host addresses can only be discovered from the cluster metadata on AWS, use --bootstrap and --master", gather.ErrUnsupportedPlatform, platform)
+	}
+	g.logger(phaseDiscover, nil).Infof("No terraform state found, discovering the hosts of %s with the %s API", metadata.InfraID, platform)
+	return g.gatherDiscovered(directory, metadata, discover)
+}
+
+// gatherFromMetadataFile collects the hosts discovered from the cluster
+// metadata at path, given with --metadata, without reading the asset store
+// or the terraform state of the assets directory. The addresses given with
+// --bootstrap and --master take precedence.
+func (g *bootstrapGather) gatherFromMetadataFile(directory, path string) (string, error) {
+	metadata, err := cluster.LoadMetadataFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid --metadata: %v", gather.ErrUsage, err)
+	}
+	platform := metadata.Platform()
+	if platform == "" {
+		return "", fmt.Errorf("%w: invalid --metadata: %s names no platform known to this installer", gather.ErrUsage, path)
+	}
+	if g.Bootstrap != "" || len(g.Masters) > 0 {
+		g.useMetadata(metadata)
+		return g.unSupportedPlatformGather(directory)
+	}
+	discover, ok := hostDiscoverers[platform]
+	if !ok {
+		return "", fmt.Errorf("%w %s: the hosts can only be discovered from the cluster metadata on AWS, use --bootstrap and --master", gather.ErrUnsupportedPlatform, platform)
+	}
+	g.logger(phaseDiscover, nil).Infof("Discovering the hosts of %s from %s with the %s API", metadata.InfraID, path, platform)
+	return g.gatherDiscovered(directory, metadata, discover)
+}
+
+// useMetadata records the platform and region of the cluster of metadata.
+func (g *bootstrapGather) useMetadata(metadata *types.ClusterMetadata) {
+	g.platform = metadata.Platform()
+	if metadata.AWS != nil {
+		g.awsRegion = metadata.AWS.Region
+	}
+	if metadata.OpenStack != nil {
+		g.openstackCloud = metadata.OpenStack.Cloud
+	}
+}
+
+// gatherDiscovered collects the hosts of the cluster of metadata found with
+// discover.
+func (g *bootstrapGather) gatherDiscovered(directory string, metadata *types.ClusterMetadata, discover func(context.Context, *types.ClusterMetadata, *tfgather.APILimiter) (string, []string, error)) (string, error) {
+	bootstrap, masters, err := discover(g.ctx, metadata, g.apiLimiter)
+	if err != nil {
+		return "", fmt.Errorf("failed to discover the hosts from the cluster metadata: %w", err)
+	}
+	g.useMetadata(metadata)
+	if bootstrap == "" {
+		logrus.Info("Gathering without a bootstrap host: no bootstrap instance found")
+	}
+	if bootstrap, masters, err = g.selectHosts(bootstrap, tfgather.MasterHosts(masters)); err != nil {
+		return "", err
+	}
+
+	port := tfgather.DefaultSSHPort
+	if g.SSHPort != 0 {
+		port = g.SSHPort
+	}
+	targets, err := g.hostTargets()
+	if err != nil {
+		return "", err
+	}
+	return g.collect(bootstrap, g.sshUser(tfgather.DefaultSSHUser), port, masters, targets, directory, nil)
+}
+
+// parseHostFlags validates the hosts given with --bootstrap and --master,
+// normalizing them to bare hostnames or IPs and recording their ports.
+func (g *bootstrapGather) parseHostFlags() error {
+	if err := g.loadHostsFile(); err != nil {
+		return err
+	}
+	if g.Bootstrap != "" {
+		bootstrap, port, err := gather.ParseHost(g.Bootstrap)
+		if err != nil {
+			return fmt.Errorf("%w: invalid --bootstrap %q: %v", gather.ErrUsage, g.Bootstrap, err)
+		}
+		g.Bootstrap, g.bootstrapPort = bootstrap, port
+	}
+	masters := make([]string, 0, len(g.Masters))
+	g.masterPorts = map[string]int{}
+	for _, value := range g.Masters {
+		master, port, err := gather.ParseHost(value)
+		if err != nil {
+			return fmt.Errorf("%w: invalid --master %q: %v", gather.ErrUsage, value, err)
+		}
+		if port != 0 {
+			g.masterPorts[master] = port
+		}
+		masters = append(masters, master)
+	}
+	g.Masters = gather.NormalizeAddresses(masters)
+	return nil
+}
+
+// loadHostsFile sets the bootstrap and control plane hosts, as if given
+// with --bootstrap and --master, to the ones of --hosts-file, and adds the
+// keys of its hosts to --key.
+func (g *bootstrapGather) loadHostsFile() error {
+	if g.HostsFile == "" {
+		return nil
+	}
+	if g.Bootstrap != "" || len(g.Masters) > 0 {
+		return fmt.Errorf("%w: --hosts-file cannot be used with --bootstrap or --master", gather.ErrUsage)
+	}
+	hosts, err := gather.LoadHostsFile(g.HostsFile)
+	if err != nil {
+		return fmt.Errorf("%w: %v", gather.ErrUsage, err)
+	}
+	entries := hosts.Masters
+	if hosts.Bootstrap != nil {
+		g.Bootstrap = hosts.Bootstrap.String()
+		entries = append([]gather.HostEntry{*hosts.Bootstrap}, entries...)
+	}
+	g.Masters = make([]string, 0, len(hosts.Masters))
+	for _, master := range hosts.Masters {
+		g.Masters = append(g.Masters, master.String())
+	}
+	keys := map[string]bool{}
+	for _, key := range g.SSHKeys {
+		keys[key] = true
+	}
+	for _, entry := range entries {
+		if entry.Key != "" && !keys[entry.Key] {
+			keys[entry.Key] = true
+			g.SSHKeys = append(g.SSHKeys, entry.Key)
+		}
+	}
+	return nil
+}
+
+// masterAddress returns the address to connect to master on directly, on
+// the port given with --master, or else the default SSH port.
+func (g *bootstrapGather) masterAddress(master string) string {
+	port, ok := g.masterPorts[master]
+	if !ok {
+		port = tfgather.DefaultSSHPort
+	}
+	return net.JoinHostPort(master, strconv.Itoa(port))
+}
+
+// hostTargets returns the targets given with --host.
+func (g *bootstrapGather) hostTargets() ([]gather.Target, error) {
+	var targets []gather.Target
+	for _, host := range g.Hosts {
+		target, err := gather.ParseTarget(host)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --host: %w", err)
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// gatherViaKubeconfig collects the nodes listed by the API using the
+// --via-kubeconfig kubeconfig directly, without terraform state. Each node
+// is collected into the directory of its role.
+func (g *bootstrapGather) gatherViaKubeconfig(directory string) (string, error) {
+	kubeconfig := g.ViaKubeconfig
+	if kubeconfig == ViaAssetsKubeconfig {
+		kubeconfig = filepath.Join(directory, "auth", "kubeconfig")
+	}
+	nodes, err := g.listGatherNodes(kubeconfig)
+	if err != nil {
+		return "", err
+	}
+	targets, err := g.hostTargets()
+	if err != nil {
+		return "", err
+	}
+	for _, node := range nodes {
+		switch {
+		case node.OperatingSystem == "windows":
+			logrus.Infof("Skipping the Windows node %s, use --include-windows-nodes to collect it", node.Name)
+		case node.Address == "":
+			logrus.Warnf("Skipping the node %s, which has no internal or external address", node.Name)
+		default:
+			targets = append(targets, gather.Target{Role: node.Role, Address: node.Address})
+		}
+	}
+	if len(targets) == 0 {
+		return "", errors.New("the API listed no nodes to collect")
+	}
+
+	port := tfgather.DefaultSSHPort
+	if g.SSHPort != 0 {
+		port = g.SSHPort
+	}
+	if g.bootstrapPort != 0 {
+		port = g.bootstrapPort
+	}
+	return g.collect(g.Bootstrap, g.sshUser(tfgather.DefaultSSHUser), port, nil, targets, directory, nil)
+}
@@ -0,0 +1,64 @@
+package bootstrap
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/installer/pkg/gather"
+)
+
+// The phases of the gather, which the structured log entries are tagged
+// with.
+const (
+	phaseDiscover = "discover"
+	phaseCollect  = "collect"
+	phaseArchive  = "archive"
+	phaseUpload   = "upload"
+	phaseSummary  = "summary"
+)
+
+// logger returns the logger of the entries of phase. With
+// Options.StructuredLogs, the entries have the platform, the phase and
+// fields, like the host they are about, as fields; otherwise they are
+// logged as they are.
+func (g *bootstrapGather) logger(phase string, fields logrus.Fields) logrus.FieldLogger {
+	if !g.StructuredLogs {
+		return logrus.StandardLogger()
+	}
+	entry := logrus.WithFields(logrus.Fields{"platform": g.platform, "phase": phase})
+	return entry.WithFields(fields)
+}
+
+// logHosts logs the result of each host recorded in coverage, as a table,
+// or with Options.StructuredLogs, as an entry per host with the role,
+// result and size of the host as fields.
+func (g *bootstrapGather) logHosts(coverage *gather.Coverage) {
+	hosts := coverage.Hosts()
+	if len(hosts) == 0 {
+		return
+	}
+	if g.StructuredLogs {
+		for _, host := range hosts {
+			fields := logrus.Fields{
+				"host":   host.Address,
+				"role":   host.Role,
+				"result": host.Result(),
+				"bytes":  host.Bytes,
+			}
+			if host.Name != "" {
+				fields["name"] = host.Name
+			}
+			g.logger(phaseSummary, fields).Infof("%s %s: %s", host.Role, host.Address, host.Result())
+		}
+		return
+	}
+	var table strings.Builder
+	if err := coverage.WriteTable(&table); err != nil {
+		return
+	}
+	logrus.Info("Gather results:")
+	for _, line := range strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n") {
+		logrus.Info(line)
+	}
+}
@@ -0,0 +1,70 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	gossh "golang.org/x/crypto/ssh"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/openshift/installer/pkg/gather"
+	"github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// printPlan prints the hosts the gather resolved and how it would collect
+// them to stdout.
+func (g *bootstrapGather) printPlan(bootstrap, user string, port int, masters []string, targets []gather.Target) error {
+	plan := &gather.Plan{
+		Platform: g.platform,
+		Masters:  masters,
+		Hosts:    targets,
+		SSHUser:  user,
+		SSHKeys:  g.SSHKeys,
+	}
+	if bootstrap != "" {
+		plan.Bootstrap = net.JoinHostPort(bootstrap, strconv.Itoa(port))
+	}
+	switch {
+	case g.NoScript:
+	case bootstrap != "":
+		plan.Command = g.gatherScriptCommand(g.remoteGatherScript(), masters)
+	case len(masters) > 0:
+		// installer-gather.sh is run from the first reachable control
+		// plane host against the others.
+		plan.Command = g.gatherScriptCommand(g.remoteGatherScript(), masters[1:])
+	}
+	return plan.Print(os.Stdout)
+}
+
+// estimateGatherBootstrap logs the estimated size and transfer time of the
+// bundle of the bootstrap host, and prints them as JSON.
+func (g *bootstrapGather) estimateGatherBootstrap(address, user string, port int, dial ssh.Dialer, hostKeyCallback gossh.HostKeyCallback) (string, error) {
+	if address == "" {
+		return "", fmt.Errorf("cannot estimate the bundle: %w", gather.ErrNoBootstrap)
+	}
+	client, err := ssh.NewClientWithHostKeyCallback(user, net.JoinHostPort(address, strconv.Itoa(port)), g.SSHKeys, g.passphrases, dial, hostKeyCallback)
+	if err != nil {
+		err = gather.Diagnose(net.JoinHostPort(address, strconv.Itoa(port)), dial, g.direct(), err)
+		return "", fmt.Errorf("failed to create SSH client: %w", err)
+	}
+	defer client.Close()
+
+	estimate, err := gather.EstimateBundle(g.ctx, &gather.Host{Role: "bootstrap", Address: address, Client: client}, g.MaxUnitLogBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to estimate the log bundle: %w", err)
+	}
+	logrus.Infof("The bootstrap host has %s of logs and assets to collect, estimated to compress to %s", resource.NewQuantity(estimate.RawBytes, resource.BinarySI), resource.NewQuantity(estimate.BundleBytes, resource.BinarySI))
+	logrus.Infof("At the measured %s/s, pulling the bundle is estimated to take %s", resource.NewQuantity(int64(estimate.BytesPerSecond), resource.BinarySI), time.Duration(estimate.TransferSeconds*float64(time.Second)).Round(time.Second))
+	logrus.Info("The control plane hosts, collected through the bootstrap host, are not included in the estimate")
+	data, err := json.Marshal(estimate)
+	if err != nil {
+		return "", err
+	}
+	fmt.Println(string(data))
+	return "", nil
+}
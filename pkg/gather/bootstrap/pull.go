@@ -0,0 +1,265 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/openshift/installer/pkg/gather"
+	"github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// pullBootstrapBundle runs installer-gather.sh on the bootstrap host and
+// pulls the bundle it creates into remoteDir, within --timeout. It returns
+// the client connected to the host, which is replaced using reconnect when
+// pulling the bundle is retried.
+func (g *bootstrapGather) pullBootstrapBundle(client *gossh.Client, reconnect func() (*gossh.Client, error), user string, masters []string, remoteDir string) (*gossh.Client, error) {
+	ctx := g.ctx
+	if g.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.Timeout)
+		defer cancel()
+	}
+	client, err := g.pullBootstrapBundleContext(ctx, client, reconnect, user, masters, remoteDir)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return client, fmt.Errorf("%w: gather timed out after %s", gather.ErrScriptFailed, g.Timeout)
+	}
+	return client, err
+}
+
+func (g *bootstrapGather) pullBootstrapBundleContext(ctx context.Context, client *gossh.Client, reconnect func() (*gossh.Client, error), user string, masters []string, remoteDir string) (*gossh.Client, error) {
+	// installer-gather.sh writes the bundle into the home directory of the
+	// user, which some images relocate.
+	home := path.Join("/home", user)
+	if dir, err := ssh.HomeDir(ctx, client, user); err != nil {
+		logrus.Debugf("Using default home directory %s: %v", home, err)
+	} else {
+		home = dir
+	}
+	script, cleanup, err := g.installGatherScript(ctx, client)
+	if err != nil {
+		return client, fmt.Errorf("%w: failed to upload the gather script %s: %v", gather.ErrScriptFailed, g.GatherScript, err)
+	}
+	defer cleanup()
+	if err := g.runGatherScript(ctx, client, g.gatherScriptCommand(script, masters)); err != nil {
+		if ctx.Err() != nil {
+			return client, fmt.Errorf("failed to run remote command: %w", err)
+		}
+		return client, fmt.Errorf("%w: failed to run remote command: %v", gather.ErrScriptFailed, err)
+	}
+
+	remoteBundle := gather.FindBundle(ctx, client, home)
+	var staging []string
+	if g.GatherScript == "" {
+		staging = append(staging, gather.GatherArtifactsDir)
+	}
+	err = gather.PullAndCleanUp(ctx, func() error {
+		var err error
+		client, err = g.pullWithRetries(ctx, client, reconnect, remoteBundle, filepath.Join(remoteDir, path.Base(remoteBundle)))
+		return err
+	}, func(ctx context.Context, command string) error {
+		return ssh.RunContext(ctx, client, command)
+	}, g.KeepRemote, remoteBundle, staging...)
+	if err != nil {
+		return client, fmt.Errorf("failed to pull log file from remote: %w", err)
+	}
+	return client, nil
+}
+
+// pullWithRetries pulls remote to local, retrying up to --retries times with
+// an exponential backoff over a new connection from reconnect. The partial
+// local file of a failed attempt is removed before the next one. It returns
+// the client last connected.
+func (g *bootstrapGather) pullWithRetries(ctx context.Context, client *gossh.Client, reconnect func() (*gossh.Client, error), remote, local string) (*gossh.Client, error) {
+	delay := 5 * time.Second
+	for attempt := 1; ; attempt++ {
+		if err := os.Remove(local); err != nil && !os.IsNotExist(err) {
+			return client, err
+		}
+		err := ssh.PullFileToContext(ctx, client, remote, local)
+		if err == nil || ctx.Err() != nil || attempt > g.Retries {
+			return client, err
+		}
+		logrus.Warnf("Failed to pull %s, retrying in %s (%d of %d): %v", remote, delay, attempt, g.Retries, err)
+		select {
+		case <-ctx.Done():
+			return client, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if c, err := reconnect(); err != nil {
+			logrus.Debugf("Failed to reconnect to pull %s: %v", remote, err)
+		} else {
+			client = c
+		}
+	}
+}
+
+// recordScriptHosts records in coverage the hosts installer-gather.sh
+// collected into the bundle pulled from the host with role at address:
+// that host, and each of masters, which is recorded as failed when the
+// bundle holds nothing from it. The control plane hosts also collected
+// directly are left as recorded then.
+func recordScriptHosts(pulled, role, address string, masters []string, coverage *gather.Coverage) {
+	bytes, masterBytes, err := gather.ScriptBundleBytes(pulled)
+	if err != nil {
+		logrus.Debugf("Failed to measure the data of the hosts in %s: %v", pulled, err)
+		coverage.Gathered(role, address, 0)
+		return
+	}
+	coverage.Gathered(role, address, bytes)
+	for _, master := range masters {
+		if master == address || coverage.Has("master", master) {
+			continue
+		}
+		if masterBytes[master] == 0 {
+			coverage.Failed("master", master, fmt.Errorf("not collected by installer-gather.sh on the %s host %s", role, address))
+			continue
+		}
+		coverage.Gathered("master", master, masterBytes[master])
+	}
+}
+
+// gatherFromMaster runs installer-gather.sh from the first of masters which
+// accepts SSH, against the others, for when the bootstrap host is gone, and
+// pulls the bundle it creates into remoteDir. It returns the address of the
+// host used, or an empty string if none of masters could be connected to.
+func (g *bootstrapGather) gatherFromMaster(masters []string, user string, pool *ssh.Pool, remoteDir string) (string, error) {
+	for i, master := range masters {
+		address := g.masterAddress(master)
+		client, err := pool.Client(user, address)
+		if err != nil {
+			logrus.Debugf("Control plane host %s is not reachable: %v", master, err)
+			continue
+		}
+		g.logger(phaseCollect, logrus.Fields{"host": master}).Infof("Running installer-gather.sh from the control plane host %s", master)
+		if err := gather.InstallGatherScripts(g.ctx, &gather.Host{Role: "master", Address: master, Client: client}); err != nil {
+			return "", err
+		}
+		others := append(append([]string{}, masters[:i]...), masters[i+1:]...)
+		reconnect := func() (*gossh.Client, error) { return pool.Reconnect(user, address) }
+		if _, err := g.pullBootstrapBundle(client, reconnect, user, others, remoteDir); err != nil {
+			// A partial bundle would be taken for the pulled one.
+			if err := resetDir(remoteDir); err != nil {
+				return "", err
+			}
+			return "", err
+		}
+		return master, nil
+	}
+	logrus.Warn("None of the control plane hosts could be reached to run installer-gather.sh")
+	return "", nil
+}
+
+// resetDir empties dir, so that the partial bundle of a failed pull is not
+// taken for the pulled one.
+func resetDir(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.MkdirAll(dir, 0755)
+}
+
+// gatherPulledBundle returns the path of the bundle pulled into remoteDir,
+// or an empty string if installer-gather.sh was not expected to have run.
+func gatherPulledBundle(remoteDir string, expected bool) (string, error) {
+	files, err := ioutil.ReadDir(remoteDir)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 && !expected {
+		return "", nil
+	}
+	if len(files) != 1 {
+		return "", fmt.Errorf("expected a single log bundle in %q, found %d files", remoteDir, len(files))
+	}
+	return filepath.Join(remoteDir, files[0].Name()), nil
+}
+
+// gatherScriptCommand returns the remote command running the gather script
+// at script against the control plane hosts.
+func (g *bootstrapGather) gatherScriptCommand(script string, masters []string) string {
+	command := fmt.Sprintf("%s %s", script, strings.Join(masters, " "))
+	if g.directMasters {
+		// The control plane hosts are collected directly.
+		command = "GATHER_MASTERS=0 " + script
+	}
+	if g.MaxUnitLogBytes > 0 {
+		command = fmt.Sprintf("MAX_UNIT_LOG_BYTES=%d %s", g.MaxUnitLogBytes, command)
+	}
+	return command
+}
+
+// runGatherScript runs the gather script command on the host connected to by
+// client, logging its output prefixed with the host as it runs, unless
+// --quiet is given.
+func (g *bootstrapGather) runGatherScript(ctx context.Context, client *gossh.Client, command string) error {
+	if g.Quiet {
+		return ssh.RunContext(ctx, client, command)
+	}
+	host, _, err := net.SplitHostPort(client.RemoteAddr().String())
+	if err != nil {
+		host = client.RemoteAddr().String()
+	}
+	return ssh.RunStreaming(ctx, client, command, host+": ")
+}
+
+// remoteGatherScript returns the path of the gather script run on the hosts,
+// before a local --gather-script is uploaded.
+func (g *bootstrapGather) remoteGatherScript() string {
+	switch {
+	case g.GatherScript == "":
+		return defaultGatherScript
+	case isLocalFile(g.GatherScript):
+		return path.Join("/tmp", uploadedGatherScript)
+	}
+	return g.GatherScript
+}
+
+// installGatherScript returns the path of the gather script to run on the
+// host connected to by client: the local --gather-script uploaded to a
+// temporary file, or else the --gather-script path on the host, or else
+// installer-gather.sh. cleanup removes the uploaded script.
+func (g *bootstrapGather) installGatherScript(ctx context.Context, client *gossh.Client) (script string, cleanup func(), err error) {
+	if !isLocalFile(g.GatherScript) {
+		return g.remoteGatherScript(), func() {}, nil
+	}
+	file, err := os.Open(g.GatherScript)
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+
+	out, err := ssh.Output(ctx, client, "mktemp "+path.Join("/tmp", uploadedGatherScript))
+	if err != nil {
+		return "", nil, err
+	}
+	script = strings.TrimSpace(string(out))
+	cleanup = func() {
+		if _, err := ssh.Output(ssh.CleanupContext(ctx), client, "rm -f "+script); err != nil {
+			logrus.Debugf("Failed to remove the uploaded gather script %s: %v", script, err)
+		}
+	}
+	if err := ssh.PushFile(client, file, script, 0755); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	logrus.Infof("Running the gather script %s uploaded to %s", g.GatherScript, script)
+	return script, cleanup, nil
+}
+
+// isLocalFile returns whether path is a regular file on this host.
+func isLocalFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
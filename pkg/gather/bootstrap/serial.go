@@ -0,0 +1,69 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/installer/pkg/asset/cluster"
+	"github.com/openshift/installer/pkg/gather"
+	"github.com/openshift/installer/pkg/terraform"
+	tfgather "github.com/openshift/installer/pkg/terraform/gather"
+)
+
+// GatherSerialConsole fetches the serial console output of the bootstrap and
+// control plane hosts in the terraform state of the assets directory dir
+// from the cloud API of the platform in its cluster metadata, without SSH,
+// for clusters whose hosts never accepted SSH. The output of each host is
+// written as <name>.log into a serial-console-<timestamp> directory in dir,
+// along with a summary.txt, and the path of the directory is returned.
+func GatherSerialConsole(ctx context.Context, dir string) (string, error) {
+	metadata, err := cluster.LoadMetadata(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to load the cluster metadata: %w", err)
+	}
+	platform, cloud := metadata.Platform(), ""
+	if metadata.OpenStack != nil {
+		cloud = metadata.OpenStack.Cloud
+	}
+	fetch, ok := serialConsole(platform, cloud, tfgather.NewAPILimiter(tfgather.DefaultAPIConcurrency))
+	if !ok {
+		return "", fmt.Errorf("%w %q: the serial console output can only be fetched on AWS, Azure and OpenStack, use gather bootstrap with --bootstrap and --master instead", gather.ErrUnsupportedPlatform, platform)
+	}
+
+	tfStateFilePath := filepath.Join(dir, terraform.StateFileName)
+	remoteState, err := (&bootstrapGather{}).gatherRemoteState(dir, tfStateFilePath)
+	if err != nil {
+		return "", err
+	}
+	var tfstate *terraform.State
+	if remoteState != nil {
+		tfstate, err = remoteState.Read()
+	} else {
+		tfstate, err = terraform.ReadState(tfStateFilePath)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the terraform state: %w", err)
+	}
+
+	out := filepath.Join(dir, "serial-console-"+time.Now().Format("20060102150405"))
+	summary := &gather.Summary{}
+	gather.RunStepsContext(ctx, &gather.Host{Role: "installer", Address: "localhost"}, out, summary, []gather.Step{
+		gather.SerialConsoleStep(func(ctx context.Context) (map[string][]byte, error) {
+			return fetch(ctx, tfstate)
+		}),
+	}, 0)
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("serial console gather interrupted: %w", err)
+	}
+	if err := summary.WriteFile(filepath.Join(out, "summary.txt")); err != nil {
+		return "", err
+	}
+	for _, line := range summary.Lines() {
+		logrus.Info(line)
+	}
+	return out, nil
+}
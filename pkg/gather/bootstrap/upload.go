@@ -0,0 +1,58 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/sirupsen/logrus"
+
+	awsconfig "github.com/openshift/installer/pkg/asset/installconfig/aws"
+	"github.com/openshift/installer/pkg/gather"
+	tfexec "github.com/openshift/installer/pkg/terraform/exec"
+)
+
+// uploader returns the uploader to the object store of u. S3 uses the AWS
+// credentials of the installer, in the region of the cluster on AWS, and
+// GCS the application default credentials.
+func (g *bootstrapGather) uploader(u *gather.UploadURL) (gather.Uploader, error) {
+	switch u.Scheme {
+	case "s3":
+		ssn, err := awsconfig.GetSession()
+		if err != nil {
+			return nil, err
+		}
+		config := aws.NewConfig()
+		if g.awsRegion != "" {
+			config = config.WithRegion(g.awsRegion)
+		}
+		uploader := s3manager.NewUploader(ssn.Copy(config))
+		return func(ctx context.Context, bucket, key string, r io.Reader) error {
+			_, err := uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+				Bucket: aws.String(bucket),
+				Key:    aws.String(key),
+				Body:   r,
+			})
+			return err
+		}, nil
+	case "gs":
+		return tfexec.UploadGCS, nil
+	}
+	return nil, fmt.Errorf("unsupported object store %q", u.Scheme)
+}
+
+// upload uploads the bundle at file to --upload-to, leaving it in place.
+func (g *bootstrapGather) upload(u *gather.UploadURL, file string) error {
+	upload, err := g.uploader(u)
+	if err != nil {
+		return err
+	}
+	object, err := gather.UploadBundle(g.ctx, upload, u, file)
+	if err != nil {
+		return err
+	}
+	g.logger(phaseUpload, logrus.Fields{"bundle": object}).Infof("Bootstrap gather logs uploaded to %s", object)
+	return nil
+}
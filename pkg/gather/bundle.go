@@ -0,0 +1,114 @@
+package gather
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Archive writes a gzipped tarball to path containing the members of the
+// gzipped tarball base, followed by the files under dir. base may be empty,
+// in which case only the files under dir are archived.
+func Archive(path, base, dir string) (err error) {
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer func() {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+	return WriteArchive(out, base, dir)
+}
+
+// WriteArchive is like Archive, but writes the gzipped tarball to w.
+func WriteArchive(w io.Writer, base, dir string) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	if base != "" {
+		if err := copyArchive(tw, base); err != nil {
+			return fmt.Errorf("failed to copy %q: %w", base, err)
+		}
+	}
+	if err := addDir(tw, dir); err != nil {
+		return fmt.Errorf("failed to add %q: %w", dir, err)
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func copyArchive(tw *tar.Writer, path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	gr, err := gzip.NewReader(in)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func addDir(tw *tar.Writer, dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = "./" + filepath.ToSlash(rel)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+}
@@ -0,0 +1,32 @@
+package gather
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// GatherArtifactsDir is the directory installer-gather.sh stages the data
+// of the bundle in on the host.
+const GatherArtifactsDir = "/tmp/artifacts"
+
+// PullAndCleanUp pulls the bundle at remote with pull and, once it was
+// pulled, removes it and the other paths, like the directory the bundle
+// was staged in, from the host with run, so that a later gather cannot
+// take a stale bundle for its own. Nothing is removed when the pull fails,
+// or with keep. Failing to remove the files is only logged.
+func PullAndCleanUp(ctx context.Context, pull func() error, run func(ctx context.Context, command string) error, keep bool, remote string, paths ...string) error {
+	if err := pull(); err != nil {
+		return err
+	}
+	if keep {
+		return nil
+	}
+	paths = append([]string{remote}, paths...)
+	// The staging directory of installer-gather.sh is owned by root.
+	if err := run(ctx, "sudo rm -rf -- "+strings.Join(paths, " ")); err != nil {
+		logrus.Warnf("Failed to remove %s from the host: %v", strings.Join(paths, " and "), err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package gather
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPullAndCleanUp(t *testing.T) {
+	cases := []struct {
+		name     string
+		pullErr  error
+		runErr   error
+		keep     bool
+		expected []string
+		err      string
+	}{{
+		name:     "pulled",
+		expected: []string{"sudo rm -rf -- /home/core/log-bundle.tar.gz /tmp/artifacts"},
+	}, {
+		name:    "pull failed",
+		pullErr: errors.New("connection reset"),
+		err:     "connection reset",
+	}, {
+		name: "kept",
+		keep: true,
+	}, {
+		name:     "removal failed",
+		runErr:   errors.New("sudo: a password is required"),
+		expected: []string{"sudo rm -rf -- /home/core/log-bundle.tar.gz /tmp/artifacts"},
+	}}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var commands []string
+			err := PullAndCleanUp(context.Background(), func() error {
+				return tc.pullErr
+			}, func(_ context.Context, command string) error {
+				commands = append(commands, command)
+				return tc.runErr
+			}, tc.keep, "/home/core/log-bundle.tar.gz", GatherArtifactsDir)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expected, commands)
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package gather
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configclient "github.com/openshift/client-go/config/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes"
+)
+
+// clusterPodLogLines is the number of lines of the log of each container
+// of the pods which are not running that ClusterResourcesStep stores.
+const clusterPodLogLines = 500
+
+// ClusterResourcesStep returns a step that stores, through the API, the
+// resources which tell why an installation that bootstrapped did not
+// complete: the cluster version, the cluster operators, the nodes, and the
+// pods which are not running, with the tail of their logs, and the warning
+// events of the openshift-* namespaces. The cluster operators which are
+// degraded or not available are recorded in the summary. The host is not
+// used.
+func ClusterResourcesStep(kube kubernetes.Interface, config configclient.Interface) Step {
+	return Step{
+		Name: "cluster",
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			var errs []error
+			if version, err := config.ConfigV1().ClusterVersions().Get("version", metav1.GetOptions{}); err != nil {
+				errs = append(errs, fmt.Errorf("failed to get the cluster version: %w", err))
+			} else if err := writeJSON(filepath.Join(dir, "clusterversion.json"), version); err != nil {
+				return err
+			}
+
+			if operators, err := config.ConfigV1().ClusterOperators().List(metav1.ListOptions{}); err != nil {
+				errs = append(errs, fmt.Errorf("failed to list the cluster operators: %w", err))
+			} else {
+				if err := writeJSON(filepath.Join(dir, "clusteroperators.json"), operators); err != nil {
+					return err
+				}
+				for _, problem := range ClusterOperatorProblems(operators.Items) {
+					summary.Addf("cluster: %s", problem)
+				}
+			}
+
+			if nodes, err := kube.CoreV1().Nodes().List(metav1.ListOptions{}); err != nil {
+				errs = append(errs, fmt.Errorf("failed to list the nodes: %w", err))
+			} else if err := writeJSON(filepath.Join(dir, "nodes.json"), nodes); err != nil {
+				return err
+			}
+
+			if events, err := kube.CoreV1().Events("").List(metav1.ListOptions{FieldSelector: "type=Warning"}); err != nil {
+				errs = append(errs, fmt.Errorf("failed to list the events: %w", err))
+			} else {
+				events.Items = filterEvents(events.Items)
+				if err := writeJSON(filepath.Join(dir, "events.json"), events); err != nil {
+					return err
+				}
+			}
+
+			if pods, err := kube.CoreV1().Pods("").List(metav1.ListOptions{}); err != nil {
+				errs = append(errs, fmt.Errorf("failed to list the pods: %w", err))
+			} else {
+				pods.Items = NotRunningPods(pods.Items)
+				if err := writeJSON(filepath.Join(dir, "pods-not-running.json"), pods); err != nil {
+					return err
+				}
+				if err := writePodLogs(ctx, kube, pods.Items, filepath.Join(dir, "pod-logs")); err != nil {
+					errs = append(errs, err)
+				}
+				if len(pods.Items) > 0 {
+					summary.Addf("cluster: %d pods in openshift-* namespaces are not running, see cluster/pods-not-running.json", len(pods.Items))
+				}
+			}
+			return utilerrors.NewAggregate(errs)
+		},
+	}
+}
+
+// ClusterOperatorProblems returns a line for each of operators which is
+// degraded or not available, with the reason and message of the
+// condition, sorted by operator.
+func ClusterOperatorProblems(operators []configv1.ClusterOperator) []string {
+	var problems []string
+	for _, operator := range operators {
+		available := false
+		for _, cond := range operator.Status.Conditions {
+			switch {
+			case cond.Type == configv1.OperatorDegraded && cond.Status == configv1.ConditionTrue:
+				problems = append(problems, fmt.Sprintf("the %s operator is degraded: %s: %s", operator.Name, cond.Reason, cond.Message))
+			case cond.Type == configv1.OperatorAvailable && cond.Status == configv1.ConditionTrue:
+				available = true
+			}
+		}
+		if !available {
+			problems = append(problems, fmt.Sprintf("the %s operator is not available", operator.Name))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// NotRunningPods returns the pods of the openshift-* namespaces which have
+// neither succeeded nor run with all of their containers ready.
+func NotRunningPods(pods []corev1.Pod) []corev1.Pod {
+	var notRunning []corev1.Pod
+	for _, pod := range pods {
+		if !strings.HasPrefix(pod.Namespace, "openshift-") || pod.Status.Phase == corev1.PodSucceeded {
+			continue
+		}
+		ready := pod.Status.Phase == corev1.PodRunning
+		for _, status := range pod.Status.ContainerStatuses {
+			ready = ready && status.Ready
+		}
+		if !ready {
+			notRunning = append(notRunning, pod)
+		}
+	}
+	return notRunning
+}
+
+func filterEvents(events []corev1.Event) []corev1.Event {
+	var filtered []corev1.Event
+	for _, event := range events {
+		if strings.HasPrefix(event.Namespace, "openshift-") {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered
+}
+
+// writePodLogs stores the last lines of the log of each container of pods
+// as <namespace>/<pod>/<container>.log under dir.
+func writePodLogs(ctx context.Context, kube kubernetes.Interface, pods []corev1.Pod, dir string) error {
+	lines := int64(clusterPodLogLines)
+	var errs []error
+	for _, pod := range pods {
+		podDir := filepath.Join(dir, pod.Namespace, pod.Name)
+		for _, container := range pod.Spec.Containers {
+			log, err := kube.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container.Name, TailLines: &lines}).Context(ctx).DoRaw()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to get the log of %s/%s/%s: %w", pod.Namespace, pod.Name, container.Name, err))
+				continue
+			}
+			if err := os.MkdirAll(podDir, 0755); err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(filepath.Join(podDir, container.Name+".log"), log, 0644); err != nil {
+				return err
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// LocalMustGatherStep returns a step that runs must-gather with oc, the
+// path of the oc binary of the installer host, against the API of
+// kubeconfig. Its output is stored under must-gather and its log as
+// must-gather.log. must-gather is stopped after timeout. The host is not
+// used.
+func LocalMustGatherStep(oc, kubeconfig string, timeout time.Duration) Step {
+	return Step{
+		Name: "must-gather",
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			var log bytes.Buffer
+			cmd := exec.CommandContext(ctx, oc, "--kubeconfig", kubeconfig, "adm", "must-gather", "--dest-dir", filepath.Join(dir, "must-gather"))
+			cmd.Stdout, cmd.Stderr = &log, &log
+			err := cmd.Run()
+			if writeErr := ioutil.WriteFile(filepath.Join(dir, "must-gather.log"), log.Bytes(), 0644); writeErr != nil {
+				return writeErr
+			}
+			if ctx.Err() == context.DeadlineExceeded {
+				summary.Addf("must-gather: stopped after %s, its output is partial", timeout)
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to run must-gather, see must-gather/must-gather.log: %w", err)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,59 @@
+package gather
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterOperatorProblems(t *testing.T) {
+	operator := func(name string, conditions ...configv1.ClusterOperatorStatusCondition) configv1.ClusterOperator {
+		return configv1.ClusterOperator{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     configv1.ClusterOperatorStatus{Conditions: conditions},
+		}
+	}
+	available := configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue}
+	problems := ClusterOperatorProblems([]configv1.ClusterOperator{
+		operator("kube-apiserver", available),
+		operator("ingress", available, configv1.ClusterOperatorStatusCondition{
+			Type: configv1.OperatorDegraded, Status: configv1.ConditionTrue, Reason: "IngressControllersDegraded", Message: "no router pods are ready",
+		}),
+		operator("authentication", configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorAvailable, Status: configv1.ConditionFalse}),
+		operator("console"),
+	})
+	assert.Equal(t, []string{
+		"the authentication operator is not available",
+		"the console operator is not available",
+		"the ingress operator is degraded: IngressControllersDegraded: no router pods are ready",
+	}, problems)
+}
+
+func TestNotRunningPods(t *testing.T) {
+	pod := func(namespace, name string, phase corev1.PodPhase, ready ...bool) corev1.Pod {
+		p := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+		for _, r := range ready {
+			p.Status.ContainerStatuses = append(p.Status.ContainerStatuses, corev1.ContainerStatus{Ready: r})
+		}
+		return p
+	}
+	pods := NotRunningPods([]corev1.Pod{
+		pod("openshift-ingress", "router-1", corev1.PodRunning, true),
+		pod("openshift-ingress", "router-2", corev1.PodRunning, true, false),
+		pod("openshift-console", "console-1", corev1.PodPending),
+		pod("openshift-kube-apiserver", "installer-3", corev1.PodSucceeded),
+		pod("openshift-kube-apiserver", "installer-4", corev1.PodFailed),
+		pod("default", "app", corev1.PodPending),
+	})
+	names := make([]string, 0, len(pods))
+	for _, p := range pods {
+		names = append(names, p.Namespace+"/"+p.Name)
+	}
+	assert.Equal(t, []string{"openshift-ingress/router-2", "openshift-console/console-1", "openshift-kube-apiserver/installer-4"}, names)
+}
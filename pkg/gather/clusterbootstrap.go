@@ -0,0 +1,75 @@
+package gather
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// clusterBootstrapLogCommand prints the log of the cluster-bootstrap
+// container started by bootkube.sh. The container is removed when it exits,
+// so its output in the bootkube journal is used when it no longer exists.
+const clusterBootstrapLogCommand = `id="$(sudo podman ps --all --no-trunc --format '{{.ID}} {{.Command}}' | awk '/start --asset-dir/ {print $1; exit}')"
+if test -n "${id}"; then
+  sudo podman logs "${id}" 2>&1
+else
+  sudo journalctl --unit=bootkube --no-pager --output=cat | sed -n '/^Starting cluster-bootstrap/,$p'
+fi`
+
+// ClusterBootstrapStep returns a step that collects the progress log of the
+// cluster-bootstrap container, which records the manifests and pods the
+// bootstrap host is waiting on.
+func ClusterBootstrapStep() Step {
+	return Step{
+		Name: "cluster-bootstrap",
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			out, err := gatherssh.Output(ctx, host.Client, clusterBootstrapLogCommand)
+			if err != nil {
+				return fmt.Errorf("failed to collect the cluster-bootstrap log: %w", err)
+			}
+			if len(bytes.TrimSpace(out)) == 0 {
+				return ioutil.WriteFile(filepath.Join(dir, "missing.txt"), []byte("cluster-bootstrap has not been started\n"), 0644)
+			}
+			return ioutil.WriteFile(filepath.Join(dir, "cluster-bootstrap.log"), out, 0644)
+		},
+	}
+}
+
+// ClusterBootstrapAnalyzer returns an analyzer that reports what
+// cluster-bootstrap was last waiting on, based on the log collected by
+// ClusterBootstrapStep.
+func ClusterBootstrapAnalyzer() Analyzer {
+	return Analyzer{
+		Name:  "cluster-bootstrap",
+		Files: []string{"cluster-bootstrap/cluster-bootstrap.log", "cluster-bootstrap/missing.txt"},
+		Analyze: func(files map[string][]byte, summary *Summary) {
+			if _, ok := files["cluster-bootstrap/missing.txt"]; ok {
+				summary.Addf("cluster-bootstrap: not started, bootkube.sh did not get past rendering and etcd")
+				return
+			}
+			if waiting := lastWaiting(files["cluster-bootstrap/cluster-bootstrap.log"]); waiting != "" {
+				summary.Addf("cluster-bootstrap: last waiting on: %s", waiting)
+			}
+		},
+	}
+}
+
+// lastWaiting returns the last line of a cluster-bootstrap log reporting
+// what it is waiting on, either the Kubernetes API or the required pods.
+func lastWaiting(log []byte) string {
+	var last string
+	scanner := bufio.NewScanner(bytes.NewReader(log))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.Contains(strings.ToLower(line), "waiting") || strings.Contains(line, "Pod Status:") {
+			last = line
+		}
+	}
+	return last
+}
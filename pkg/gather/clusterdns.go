@@ -0,0 +1,111 @@
+package gather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// clusterDNSNamespaces are the namespaces of the DNS operator and of the
+// CoreDNS daemon set it manages.
+var clusterDNSNamespaces = []string{"openshift-dns-operator", "openshift-dns"}
+
+// ClusterDNSStep returns a step that records the state of the DNS operator
+// and CoreDNS through the API on the bootstrap host: the dns cluster
+// operator and operator config, and the pods, config maps and pod logs of
+// their namespaces. When maxLogBytes is positive, only the most recent
+// maxLogBytes of each pod log are collected. It is skipped when the API is
+// not reachable.
+func ClusterDNSStep(maxLogBytes int64) Step {
+	return Step{
+		Name: "cluster-dns",
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			oc, reason, err := bootstrapOC(ctx, host)
+			if err != nil {
+				return err
+			}
+			if reason != "" {
+				return ioutil.WriteFile(filepath.Join(dir, "skipped.txt"), []byte(reason+"\n"), 0644)
+			}
+			oc += " --request-timeout=10s"
+
+			var errs []error
+			collect := func(file, command string) []byte {
+				out, err := gatherssh.Output(ctx, host.Client, command)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("failed to collect %s: %w", file, err))
+					return nil
+				}
+				if err := ioutil.WriteFile(filepath.Join(dir, file), out, 0644); err != nil {
+					errs = append(errs, err)
+				}
+				return out
+			}
+
+			collect("clusteroperator.json", oc+" get clusteroperator dns --ignore-not-found --output=json")
+			collect("dns.json", oc+" get dns.operator.openshift.io default --ignore-not-found --output=json")
+			for _, namespace := range clusterDNSNamespaces {
+				collect(namespace+"-configmaps.json", oc+" --namespace="+namespace+" get configmaps --output=json")
+				out := collect(namespace+"-pods.json", oc+" --namespace="+namespace+" get pods --output=json")
+				if out == nil {
+					continue
+				}
+				pods := &corev1.PodList{}
+				if err := json.Unmarshal(out, pods); err != nil {
+					errs = append(errs, fmt.Errorf("invalid pod list for %s: %w", namespace, err))
+					continue
+				}
+				for _, pod := range pods.Items {
+					command := fmt.Sprintf("%s --namespace=%s logs %s --all-containers", oc, namespace, pod.Name)
+					if maxLogBytes > 0 {
+						command += fmt.Sprintf(" --limit-bytes=%d", maxLogBytes)
+					}
+					collect(fmt.Sprintf("%s-%s.log", namespace, pod.Name), command)
+				}
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+			}
+			return utilerrors.NewAggregate(errs)
+		},
+	}
+}
+
+// ClusterDNSAnalyzer returns an analyzer that flags a degraded or
+// unavailable DNS operator, based on the cluster operator recorded by
+// ClusterDNSStep.
+func ClusterDNSAnalyzer() Analyzer {
+	return Analyzer{
+		Name:  "cluster-dns",
+		Files: []string{"cluster-dns/clusteroperator.json"},
+		Analyze: func(files map[string][]byte, summary *Summary) {
+			for _, data := range files {
+				if len(data) == 0 {
+					summary.Addf("cluster-dns: the dns cluster operator does not exist, the DNS operator was never deployed")
+					continue
+				}
+				operator := &configv1.ClusterOperator{}
+				if err := json.Unmarshal(data, operator); err != nil {
+					summary.Addf("cluster-dns: invalid cluster operator: %v", err)
+					continue
+				}
+				for _, cond := range operator.Status.Conditions {
+					switch {
+					case cond.Type == configv1.OperatorDegraded && cond.Status == configv1.ConditionTrue:
+						summary.Addf("cluster-dns: the DNS operator is degraded: %s: %s", cond.Reason, cond.Message)
+					case cond.Type == configv1.OperatorAvailable && cond.Status != configv1.ConditionTrue:
+						summary.Addf("cluster-dns: the DNS operator is not available: %s: %s", cond.Reason, cond.Message)
+					}
+				}
+			}
+		},
+	}
+}
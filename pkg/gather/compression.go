@@ -0,0 +1,82 @@
+package gather
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// bundleExtensions are the archive extensions remote gather scripts are
+// known to produce.
+var bundleExtensions = []string{".tar.gz", ".tgz", ".tar.xz", ".tar.zst", ".tar.bz2", ".tar"}
+
+// bundleMagic maps the leading bytes of compressed files to the extension
+// of a tarball compressed that way.
+var bundleMagic = []struct {
+	magic     []byte
+	extension string
+}{
+	{magic: []byte{0x1f, 0x8b}, extension: ".tar.gz"},
+	{magic: []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, extension: ".tar.xz"},
+	{magic: []byte{0x28, 0xb5, 0x2f, 0xfd}, extension: ".tar.zst"},
+	{magic: []byte{'B', 'Z', 'h'}, extension: ".tar.bz2"},
+}
+
+// FindBundle returns the path of the log bundle written by the remote
+// gather script into dir, whatever its compression. If no bundle is found,
+// the default dir/log-bundle.tar.gz is returned.
+func FindBundle(ctx context.Context, client *ssh.Client, dir string) string {
+	fallback := path.Join(dir, "log-bundle.tar.gz")
+	out, err := gatherssh.Output(ctx, client, "ls -1d "+path.Join(dir, "log-bundle.*"))
+	if err != nil {
+		return fallback
+	}
+	for _, candidate := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if BundleExtension(candidate) != "" {
+			return candidate
+		}
+	}
+	return fallback
+}
+
+// BundleExtension returns the archive extension of name, or an empty
+// string if the extension is not a known archive extension.
+func BundleExtension(name string) string {
+	for _, ext := range bundleExtensions {
+		if strings.HasSuffix(name, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// DetectBundleExtension returns the archive extension matching the
+// compression of the file at path, judged by its leading bytes.
+// Uncompressed files are assumed to be plain tarballs.
+func DetectBundleExtension(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	header := make([]byte, 6)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("failed to read %q: %w", path, err)
+	}
+	for _, m := range bundleMagic {
+		if bytes.HasPrefix(header[:n], m.magic) {
+			return m.extension, nil
+		}
+	}
+	return ".tar", nil
+}
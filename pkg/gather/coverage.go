@@ -0,0 +1,351 @@
+package gather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// Coverage records which hosts were reached and which could not be, so
+// that a bundle collected from only some of the hosts says so. It is safe
+// for concurrent use.
+type Coverage struct {
+	mu    sync.Mutex
+	hosts []HostResult
+	names map[string]string
+}
+
+// HostResult is whether a host could be connected to and collected.
+type HostResult struct {
+	Role    string `json:"role"`
+	Address string `json:"address"`
+	Reached bool   `json:"reached"`
+
+	// Name identifies the host in the cluster, like "master-1", when it
+	// is known.
+	Name string `json:"name,omitempty"`
+
+	// Gathered is whether the data of the host was collected, and Bytes
+	// the size of the data pulled from it.
+	Gathered bool  `json:"gathered,omitempty"`
+	Bytes    int64 `json:"bytes,omitempty"`
+
+	// Error is why the host could not be connected to or collected.
+	Error string `json:"error,omitempty"`
+
+	// Diagnosis is the result of resolving the host and connecting to its
+	// SSH port over TCP, after it could not be connected to.
+	Diagnosis string `json:"diagnosis,omitempty"`
+}
+
+// Result returns the result of the host for the summary: OK when it was
+// collected, UNREACHABLE when it could not be connected to and FAILED when
+// it could, but not be collected.
+func (r *HostResult) Result() string {
+	switch {
+	case !r.Reached:
+		return "UNREACHABLE"
+	case r.Error != "":
+		return "FAILED"
+	}
+	return "OK"
+}
+
+// NameHosts records the names of the hosts, by address, that the hosts
+// recorded afterwards are given.
+func (c *Coverage) NameHosts(names map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.names = names
+}
+
+// Reached records that the host with role at address was connected to.
+func (c *Coverage) Reached(role, address string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hosts = append(c.hosts, HostResult{Role: role, Address: address, Reached: true, Name: c.names[address]})
+}
+
+// Unreachable records that the host with role at address could not be
+// connected to because of err.
+func (c *Coverage) Unreachable(role, address string, err error) {
+	result := HostResult{Role: role, Address: address}
+	var diagnosed *DiagnosedError
+	if errors.As(err, &diagnosed) {
+		result.Diagnosis = diagnosed.Diagnosis
+		err = diagnosed.Err
+	}
+	result.Error = fmt.Sprintf("%s: %v", UnreachableReason(err), err)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result.Name = c.names[address]
+	c.hosts = append(c.hosts, result)
+}
+
+// Gathered records that the data of the host with role at address was
+// collected, with bytes pulled from it. The host is recorded as reached if
+// it was not recorded yet.
+func (c *Coverage) Gathered(role, address string, bytes int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	host := c.host(role, address)
+	host.Reached, host.Gathered, host.Bytes = true, true, bytes
+}
+
+// Failed records that the host with role at address was connected to, or
+// collected through another host, but its data could not be collected
+// because of err.
+func (c *Coverage) Failed(role, address string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	host := c.host(role, address)
+	host.Reached, host.Gathered, host.Error = true, false, err.Error()
+}
+
+// Has returns whether the host with role at address was recorded.
+func (c *Coverage) Has(role, address string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, host := range c.hosts {
+		if host.Role == role && host.Address == address {
+			return true
+		}
+	}
+	return false
+}
+
+// host returns the last result recorded for the host with role at address,
+// recording a new one if there is none. c.mu must be held.
+func (c *Coverage) host(role, address string) *HostResult {
+	for i := len(c.hosts) - 1; i >= 0; i-- {
+		if c.hosts[i].Role == role && c.hosts[i].Address == address {
+			return &c.hosts[i]
+		}
+	}
+	c.hosts = append(c.hosts, HostResult{Role: role, Address: address, Name: c.names[address]})
+	return &c.hosts[len(c.hosts)-1]
+}
+
+// Hosts returns the hosts recorded, in the order they were recorded.
+func (c *Coverage) Hosts() []HostResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]HostResult(nil), c.hosts...)
+}
+
+// Counts returns the number of hosts which were reached and which could
+// not be.
+func (c *Coverage) Counts() (reached, unreachable int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, host := range c.hosts {
+		if host.Reached {
+			reached++
+		} else {
+			unreachable++
+		}
+	}
+	return reached, unreachable
+}
+
+// failed returns the number of hosts which were reached but could not be
+// collected.
+func (c *Coverage) failed() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	failed := 0
+	for _, host := range c.hosts {
+		if host.Reached && host.Error != "" {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Err returns an error matching ErrUnreachable when none of the hosts were
+// reached, naming the diagnoses of the hosts, or ErrPartial when only some
+// were, or some could not be collected, and else nil.
+func (c *Coverage) Err() error {
+	reached, unreachable := c.Counts()
+	failed := c.failed()
+	switch {
+	case unreachable == 0 && failed == 0:
+		return nil
+	case unreachable == 0:
+		return fmt.Errorf("%w: %d of %d hosts could not be collected, see unreachable.txt in the bundle", ErrPartial, failed, reached)
+	case reached == 0:
+		var diagnoses []string
+		for _, host := range c.Hosts() {
+			if host.Diagnosis != "" {
+				diagnoses = append(diagnoses, fmt.Sprintf("%s %s", host.Role, host.Diagnosis))
+			}
+		}
+		if len(diagnoses) > 0 {
+			return fmt.Errorf("%w: none of the %d hosts could be reached (%s), the bundle only holds local data", ErrUnreachable, unreachable, strings.Join(diagnoses, "; "))
+		}
+		return fmt.Errorf("%w: none of the %d hosts could be reached, the bundle only holds local data", ErrUnreachable, unreachable)
+	}
+	return fmt.Errorf("%w: %d of %d hosts could not be reached, see unreachable.txt in the bundle", ErrPartial, unreachable, reached+unreachable)
+}
+
+// WriteTable writes the result of every host to w as a table, one host per
+// line, with the size of the data pulled from the hosts which were
+// collected. Hosts are shown by name when they have one, and else by role.
+func (c *Coverage) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tADDRESS\tRESULT\tSIZE")
+	for _, host := range c.Hosts() {
+		size := ""
+		if host.Bytes > 0 {
+			size = FormatBytes(host.Bytes)
+		}
+		name := host.Role
+		if host.Name != "" {
+			name = host.Name
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", name, host.Address, host.Result(), size)
+	}
+	return tw.Flush()
+}
+
+// FormatBytes returns n bytes in the largest binary unit it holds at least
+// one of, such as "4.2 MiB".
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// Summarize records the partial coverage in summary and writes the
+// unreachable hosts, and those which could not be collected, and the
+// reasons to path, one per line. Nothing is done when every host was
+// collected.
+func (c *Coverage) Summarize(summary *Summary, path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var unreachable []string
+	for _, host := range c.hosts {
+		if host.Error != "" {
+			line := fmt.Sprintf("%s %s: %s", host.Role, host.Address, host.Error)
+			if host.Diagnosis != "" {
+				line += " (" + host.Diagnosis + ")"
+			}
+			unreachable = append(unreachable, line)
+		}
+	}
+	if len(unreachable) == 0 {
+		return nil
+	}
+	summary.Addf("coverage: %d of %d hosts could not be reached, the bundle is partial (see unreachable.txt)", len(unreachable), len(c.hosts))
+	return ioutil.WriteFile(path, []byte(strings.Join(unreachable, "\n")+"\n"), 0644)
+}
+
+// BundleBytes returns the size of the members of the bundle at p, which is
+// either a gzipped tarball or a directory.
+func BundleBytes(p string) (int64, error) {
+	var total int64
+	err := walkBundle(p, func(_ string, size int64, _ func() (io.ReadCloser, error)) error {
+		total += size
+		return nil
+	})
+	return total, err
+}
+
+// ScriptBundleBytes returns the size of the members of the bundle of
+// installer-gather.sh at p collected from the host the script ran on, and
+// of those under control-plane/<address> by address, for the control plane
+// hosts the script collected.
+func ScriptBundleBytes(p string) (host int64, masters map[string]int64, err error) {
+	masters = map[string]int64{}
+	err = walkBundle(p, func(name string, size int64, _ func() (io.ReadCloser, error)) error {
+		parts := strings.SplitN(name, "/", 3)
+		if len(parts) == 3 && parts[0] == "control-plane" {
+			masters[parts[1]] += size
+		} else {
+			host += size
+		}
+		return nil
+	})
+	return host, masters, err
+}
+
+// UnreachableReason returns a short description of why connecting to a host
+// failed with err.
+func UnreachableReason(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, gatherssh.ErrAuthFailed):
+		return "authentication failed"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection refused"
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return "no route to host"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timed out"
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return "host not found"
+	}
+	return "connection failed"
+}
+
+// CollectReachable connects to each of targets concurrently, with at most
+// workers connections collected at a time, or without a limit for 0, and
+// runs collect for the ones connect succeeds for. The targets which cannot
+// be connected to are recorded in coverage and never stop the collection
+// from the others; their errors are returned combined.
+func CollectReachable(targets []Target, workers int, connect func(Target) (*ssh.Client, error), collect func(Target, *ssh.Client), coverage *Coverage) error {
+	if workers <= 0 || workers > len(targets) {
+		workers = len(targets)
+	}
+	queue := make(chan Target)
+	var mu sync.Mutex
+	var errs []error
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for target := range queue {
+				client, err := connect(target)
+				if err != nil {
+					logrus.Debugf("Failed to connect to the %s host %s: %v", target.Role, target.Address, err)
+					coverage.Unreachable(target.Role, target.Address, err)
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("%s host %s: %w", target.Role, target.Address, err))
+					mu.Unlock()
+					continue
+				}
+				coverage.Reached(target.Role, target.Address)
+				collect(target, client)
+			}
+		}()
+	}
+	for _, target := range targets {
+		queue <- target
+	}
+	close(queue)
+	wg.Wait()
+	return utilerrors.NewAggregate(errs)
+}
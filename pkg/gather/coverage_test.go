@@ -0,0 +1,178 @@
+package gather
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestCollectReachable(t *testing.T) {
+	targets := []Target{
+		{Role: "master", Address: "10.0.0.1"},
+		{Role: "master", Address: "10.0.0.2"},
+		{Role: "master", Address: "10.0.0.3"},
+		{Role: "worker", Address: "10.0.0.4"},
+		{Role: "worker", Address: "10.0.0.5"},
+	}
+	failures := map[string]error{
+		"10.0.0.2": &DiagnosedError{
+			Err:       &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+			Diagnosis: "10.0.0.2:22 TCP connect: connection refused",
+		},
+		"10.0.0.3": &net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}},
+		"10.0.0.5": fmt.Errorf("failed to authenticate on 10.0.0.5:22: %w", gatherssh.ErrAuthFailed),
+	}
+
+	var mu sync.Mutex
+	var collected []string
+	coverage := &Coverage{}
+	err := CollectReachable(targets, 2, func(target Target) (*ssh.Client, error) {
+		return nil, failures[target.Address]
+	}, func(target Target, client *ssh.Client) {
+		mu.Lock()
+		defer mu.Unlock()
+		collected = append(collected, target.Address)
+	}, coverage)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "master host 10.0.0.2: ")
+
+	sort.Strings(collected)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.4"}, collected)
+	reached, unreachable := coverage.Counts()
+	assert.Equal(t, 2, reached)
+	assert.Equal(t, 3, unreachable)
+
+	dir, err := ioutil.TempDir("", "coverage")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	summary := &Summary{}
+	path := filepath.Join(dir, "unreachable.txt")
+	if !assert.NoError(t, coverage.Summarize(summary, path)) {
+		return
+	}
+	assert.Equal(t, []string{"coverage: 3 of 5 hosts could not be reached, the bundle is partial (see unreachable.txt)"}, summary.Lines())
+	data, err := ioutil.ReadFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, string(data), "master 10.0.0.2: connection refused: ")
+	assert.Contains(t, string(data), " (10.0.0.2:22 TCP connect: connection refused)\n")
+	assert.Contains(t, string(data), "master 10.0.0.3: timed out: ")
+	assert.Contains(t, string(data), "worker 10.0.0.5: authentication failed: ")
+}
+
+func TestCoverageAllReached(t *testing.T) {
+	coverage := &Coverage{}
+	coverage.Reached("master", "10.0.0.1")
+	summary := &Summary{}
+	path := filepath.Join(os.TempDir(), "does-not-exist", "unreachable.txt")
+	assert.NoError(t, coverage.Summarize(summary, path))
+	assert.Empty(t, summary.Lines())
+}
+
+func TestCoverageTable(t *testing.T) {
+	coverage := &Coverage{}
+	coverage.Reached("bootstrap", "10.0.0.5")
+	coverage.Gathered("bootstrap", "10.0.0.5", 4404019)
+	coverage.Gathered("master", "10.0.1.7", 2048)
+	coverage.Unreachable("master", "10.0.1.8", &net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}})
+	coverage.Failed("master", "10.0.1.9", errors.New("not collected by installer-gather.sh"))
+	assert.True(t, coverage.Has("master", "10.0.1.9"))
+	assert.False(t, coverage.Has("bootstrap", "10.0.1.9"))
+
+	var table strings.Builder
+	assert.NoError(t, coverage.WriteTable(&table))
+	assert.Equal(t, `HOST       ADDRESS   RESULT       SIZE
+bootstrap  10.0.0.5  OK           4.2 MiB
+master     10.0.1.7  OK           2.0 KiB
+master     10.0.1.8  UNREACHABLE  
+master     10.0.1.9  FAILED       
+`, table.String())
+
+	err := coverage.Err()
+	assert.True(t, errors.Is(err, ErrPartial))
+	assert.EqualError(t, err, "partial gather: 1 of 4 hosts could not be reached, see unreachable.txt in the bundle")
+
+	coverage = &Coverage{}
+	coverage.Gathered("bootstrap", "10.0.0.5", 0)
+	coverage.Failed("master", "10.0.1.9", errors.New("not collected by installer-gather.sh"))
+	assert.EqualError(t, coverage.Err(), "partial gather: 1 of 2 hosts could not be collected, see unreachable.txt in the bundle")
+
+	coverage = &Coverage{}
+	coverage.NameHosts(map[string]string{"10.0.0.5": "bootstrap", "10.0.1.8": "master-1"})
+	coverage.Gathered("bootstrap", "10.0.0.5", 1024)
+	coverage.Unreachable("master", "10.0.1.8", &net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}})
+	coverage.Gathered("master", "10.0.1.9", 0)
+	table.Reset()
+	assert.NoError(t, coverage.WriteTable(&table))
+	assert.Equal(t, `HOST       ADDRESS   RESULT       SIZE
+bootstrap  10.0.0.5  OK           1.0 KiB
+master-1   10.0.1.8  UNREACHABLE  
+master     10.0.1.9  OK           
+`, table.String())
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, expected := range map[int64]string{
+		0:             "0 B",
+		1023:          "1023 B",
+		1024:          "1.0 KiB",
+		4404019:       "4.2 MiB",
+		3 * (1 << 30): "3.0 GiB",
+		5 * (1 << 40): "5.0 TiB",
+	} {
+		assert.Equal(t, expected, FormatBytes(n))
+	}
+}
+
+func TestScriptBundleBytes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, size := range map[string]int{
+		"bootstrap/journals/bootkube.log":         100,
+		"control-plane/10.0.1.7/journals/kubelet": 30,
+		"control-plane/10.0.1.7/containers/etcd":  20,
+		"control-plane/10.0.1.8/journals/kubelet": 10,
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	host, masters, err := ScriptBundleBytes(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), host)
+	assert.Equal(t, map[string]int64{"10.0.1.7": 50, "10.0.1.8": 10}, masters)
+
+	total, err := BundleBytes(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(160), total)
+}
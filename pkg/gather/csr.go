@@ -0,0 +1,137 @@
+package gather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// bootstrapKubeconfigPaths are the admin kubeconfigs on the bootstrap host,
+// in order of preference.
+var bootstrapKubeconfigPaths = []string{
+	"/opt/openshift/auth/kubeconfig",
+	"/etc/kubernetes/kubeconfig",
+}
+
+// bootstrapOC returns the oc command for the API using the admin
+// kubeconfig on the bootstrap host. If the API cannot be used, the reason is
+// returned instead.
+func bootstrapOC(ctx context.Context, host *Host) (oc string, reason string, err error) {
+	kubeconfig, err := firstExisting(ctx, host, bootstrapKubeconfigPaths...)
+	if err != nil {
+		return "", "", err
+	}
+	if kubeconfig == "" {
+		return "", "no kubeconfig on the bootstrap host", nil
+	}
+	oc = fmt.Sprintf("sudo oc --config=%s", kubeconfig)
+	if _, err := gatherssh.Output(ctx, host.Client, oc+" --request-timeout=10s get --raw /healthz"); err != nil {
+		if ctx.Err() != nil {
+			return "", "", ctx.Err()
+		}
+		return "", fmt.Sprintf("the API is not reachable: %v", err), nil
+	}
+	return oc, "", nil
+}
+
+// CSRStep returns a step that records the certificate signing requests and
+// nodes known to the API through the bootstrap host. It is skipped when the
+// API is not reachable.
+func CSRStep() Step {
+	return Step{
+		Name: "csr",
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			oc, reason, err := bootstrapOC(ctx, host)
+			if err != nil {
+				return err
+			}
+			if reason != "" {
+				return ioutil.WriteFile(filepath.Join(dir, "skipped.txt"), []byte(reason+"\n"), 0644)
+			}
+			oc += " --request-timeout=10s"
+
+			for file, command := range map[string]string{
+				"csrs.json":  oc + " get csr --output=json",
+				"nodes.json": oc + " get nodes --output=json",
+			} {
+				out, err := gatherssh.Output(ctx, host.Client, command)
+				if err != nil {
+					return fmt.Errorf("failed to collect %s: %w", file, err)
+				}
+				if err := ioutil.WriteFile(filepath.Join(dir, file), out, 0644); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// CSRAnalyzer returns an analyzer that flags pending certificate signing
+// requests and nodes which are not ready, based on the data recorded by
+// CSRStep.
+func CSRAnalyzer() Analyzer {
+	return Analyzer{
+		Name:  "csr",
+		Files: []string{"csr/csrs.json", "csr/nodes.json"},
+		Analyze: func(files map[string][]byte, summary *Summary) {
+			if data, ok := files["csr/csrs.json"]; ok {
+				csrs := &certificatesv1beta1.CertificateSigningRequestList{}
+				if err := json.Unmarshal(data, csrs); err != nil {
+					summary.Addf("csr: invalid CSR list: %v", err)
+				} else if pending := pendingCSRs(csrs.Items); len(pending) > 0 {
+					summary.Addf("csr: %d CSRs are pending approval: %s", len(pending), strings.Join(pending, ", "))
+				}
+			}
+			if data, ok := files["csr/nodes.json"]; ok {
+				nodes := &corev1.NodeList{}
+				if err := json.Unmarshal(data, nodes); err != nil {
+					summary.Addf("csr: invalid node list: %v", err)
+				} else if notReady := notReadyNodes(nodes.Items); len(notReady) > 0 {
+					summary.Addf("csr: %d of %d nodes are not ready: %s", len(notReady), len(nodes.Items), strings.Join(notReady, ", "))
+				}
+			}
+		},
+	}
+}
+
+// pendingCSRs returns the names and requesters of the CSRs which were
+// neither approved nor denied.
+func pendingCSRs(csrs []certificatesv1beta1.CertificateSigningRequest) []string {
+	var pending []string
+	for _, csr := range csrs {
+		if len(csr.Status.Conditions) == 0 {
+			pending = append(pending, fmt.Sprintf("%s (%s)", csr.Name, csr.Spec.Username))
+		}
+	}
+	sort.Strings(pending)
+	return pending
+}
+
+// notReadyNodes returns the names of the nodes whose Ready condition is not
+// true.
+func notReadyNodes(nodes []corev1.Node) []string {
+	var notReady []string
+	for _, node := range nodes {
+		ready := false
+		for _, cond := range node.Status.Conditions {
+			if cond.Type == corev1.NodeReady && cond.Status == corev1.ConditionTrue {
+				ready = true
+			}
+		}
+		if !ready {
+			notReady = append(notReady, node.Name)
+		}
+	}
+	sort.Strings(notReady)
+	return notReady
+}
@@ -0,0 +1,74 @@
+package gather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// diagnoseTimeout bounds the name resolution and the TCP connection made to
+// diagnose a host.
+const diagnoseTimeout = 5 * time.Second
+
+// DiagnosedError is an error connecting to a host over SSH, with the
+// diagnosis of the network path to the host.
+type DiagnosedError struct {
+	Err error
+
+	// Diagnosis is the result of resolving the host and connecting to its
+	// SSH port over TCP, like "10.0.0.5:22 TCP connect: connection refused".
+	Diagnosis string
+}
+
+func (e *DiagnosedError) Error() string {
+	return fmt.Sprintf("%v (%s)", e.Err, e.Diagnosis)
+}
+
+// Unwrap returns the error connecting to the host.
+func (e *DiagnosedError) Unwrap() error {
+	return e.Err
+}
+
+// Diagnose returns err, the error connecting to the SSH server at address,
+// with the diagnosis of whether the host resolves and its port accepts TCP
+// connections through dial. The host is only resolved locally with resolve,
+// when dial connects directly rather than through a proxy or bastion.
+// Authentication failures are returned as they are, since the host was
+// reached.
+func Diagnose(address string, dial gatherssh.Dialer, resolve bool, err error) error {
+	if errors.Is(err, gatherssh.ErrAuthFailed) {
+		return err
+	}
+	return &DiagnosedError{Err: err, Diagnosis: diagnose(address, dial, resolve)}
+}
+
+func diagnose(address string, dial gatherssh.Dialer, resolve bool) string {
+	var results []string
+	if host, _, err := net.SplitHostPort(address); err == nil && resolve && net.ParseIP(host) == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), diagnoseTimeout)
+		defer cancel()
+		addresses, err := net.DefaultResolver.LookupHost(ctx, host)
+		if err != nil {
+			var dnsErr *net.DNSError
+			if errors.As(err, &dnsErr) {
+				err = errors.New(dnsErr.Err)
+			}
+			return fmt.Sprintf("resolve %s failed: %v", host, err)
+		}
+		results = append(results, fmt.Sprintf("%s resolved to %s", host, strings.Join(addresses, ", ")))
+	}
+
+	conn, err := gatherssh.TimeoutDialer(dial, diagnoseTimeout)("tcp", address)
+	if err != nil {
+		results = append(results, fmt.Sprintf("%s TCP connect: %s", address, UnreachableReason(err)))
+	} else {
+		conn.Close()
+		results = append(results, fmt.Sprintf("%s TCP connect succeeded", address))
+	}
+	return strings.Join(results, ", ")
+}
@@ -0,0 +1,55 @@
+package gather
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+func TestDiagnose(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	open := listener.Addr().String()
+
+	closedListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := closedListener.Addr().String()
+	closedListener.Close()
+
+	sshErr := errors.New("ssh: handshake failed: EOF")
+	cases := []struct {
+		name      string
+		address   string
+		err       error
+		diagnosis string
+	}{
+		{name: "open", address: open, err: sshErr, diagnosis: open + " TCP connect succeeded"},
+		{name: "refused", address: closed, err: sshErr, diagnosis: closed + " TCP connect: connection refused"},
+		{name: "authentication", address: open, err: fmt.Errorf("%w: no supported methods remain", gatherssh.ErrAuthFailed)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Diagnose(tc.address, net.Dial, true, tc.err)
+			assert.True(t, errors.Is(err, tc.err))
+			var diagnosed *DiagnosedError
+			if tc.diagnosis == "" {
+				assert.False(t, errors.As(err, &diagnosed))
+				return
+			}
+			if assert.True(t, errors.As(err, &diagnosed)) {
+				assert.Equal(t, tc.diagnosis, diagnosed.Diagnosis)
+				assert.EqualError(t, err, fmt.Sprintf("%v (%s)", tc.err, tc.diagnosis))
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package gather
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+const (
+	// minSignificantSizeChange is the number of bytes below which a change
+	// in the size of a member is not reported.
+	minSignificantSizeChange = 4096
+
+	// significantSizeRatio is the ratio of the smaller to the larger size of
+	// a member below which the change in size is reported.
+	significantSizeRatio = 0.5
+)
+
+// bundleSummary is what DiffBundles compares between bundles.
+type bundleSummary struct {
+	sizes    map[string]int64
+	verdicts map[string]bool
+}
+
+// DiffBundles compares the bundles a and b, which are either gzipped
+// tarballs or directories, and returns a report with a line for each
+// difference. Lines starting with "-" describe a, lines starting with "+"
+// describe b, and lines starting with "~" describe both. The bundles are
+// compared by their members, the sizes of members which changed
+// significantly, the verdicts of analyzers and the steps which failed
+// while gathering them.
+func DiffBundles(a, b string, analyzers []Analyzer) ([]string, error) {
+	sa, err := summarizeBundle(a, analyzers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", a, err)
+	}
+	sb, err := summarizeBundle(b, analyzers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", b, err)
+	}
+
+	var report []string
+	for _, name := range sortedUnion(sa.sizes, sb.sizes) {
+		sizeA, inA := sa.sizes[name]
+		sizeB, inB := sb.sizes[name]
+		switch {
+		case !inB:
+			report = append(report, fmt.Sprintf("- member %s", name))
+		case !inA:
+			report = append(report, fmt.Sprintf("+ member %s", name))
+		case significantSizeChange(sizeA, sizeB):
+			report = append(report, fmt.Sprintf("~ member %s: %d -> %d bytes", name, sizeA, sizeB))
+		}
+	}
+	for _, verdict := range sortedKeys(sa.verdicts) {
+		if !sb.verdicts[verdict] {
+			report = append(report, fmt.Sprintf("- %s", verdict))
+		}
+	}
+	for _, verdict := range sortedKeys(sb.verdicts) {
+		if !sa.verdicts[verdict] {
+			report = append(report, fmt.Sprintf("+ %s", verdict))
+		}
+	}
+	return report, nil
+}
+
+// summarizeBundle returns the member sizes of the bundle at path, and the
+// verdicts of analyzers over it along with the steps recorded as failed
+// in its summary.txt.
+func summarizeBundle(path string, analyzers []Analyzer) (*bundleSummary, error) {
+	s := &bundleSummary{sizes: map[string]int64{}, verdicts: map[string]bool{}}
+	err := walkBundle(path, func(name string, size int64, open func() (io.ReadCloser, error)) error {
+		s.sizes[name] = size
+		if name != "summary.txt" {
+			return nil
+		}
+		r, err := open()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		for _, step := range failedSteps(data) {
+			s.verdicts[fmt.Sprintf("step %s failed", step)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := ReadBundle(analyzers, path)
+	if err != nil {
+		return nil, err
+	}
+	summary := &Summary{}
+	Analyze(analyzers, files, summary)
+	for _, line := range summary.Lines() {
+		s.verdicts["verdict "+line] = true
+	}
+	return s, nil
+}
+
+// failedSteps returns the names of the steps which failed or timed out
+// according to a summary written by Summary.WriteFile.
+func failedSteps(summary []byte) []string {
+	var steps []string
+	scanner := bufio.NewScanner(bytes.NewReader(summary))
+	for scanner.Scan() {
+		line := scanner.Text()
+		for _, sep := range []string{": failed: ", ": timed out after "} {
+			if i := strings.Index(line, sep); i > 0 {
+				steps = append(steps, line[:i])
+				break
+			}
+		}
+	}
+	return steps
+}
+
+// significantSizeChange returns true if a member changing size from a to
+// b bytes is worth reporting.
+func significantSizeChange(a, b int64) bool {
+	small, large := a, b
+	if small > large {
+		small, large = large, small
+	}
+	return large-small >= minSignificantSizeChange && float64(small) < significantSizeRatio*float64(large)
+}
+
+func sortedUnion(a, b map[string]int64) []string {
+	seen := map[string]bool{}
+	for name := range a {
+		seen[name] = true
+	}
+	for name := range b {
+		seen[name] = true
+	}
+	return sortedKeys(seen)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,60 @@
+package gather
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeBundle(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDiffBundles(t *testing.T) {
+	a := writeBundle(t, map[string]string{
+		"summary.txt":                        "# analyzer version 1\netcd-data: failed: connection refused\n",
+		"bootstrap/journals/kubelet.log":     strings.Repeat("a", 10000),
+		"bootstrap/journals/bootkube.log":    "same",
+		"audit/missing.txt":                  "no kube-apiserver audit log found\n",
+		"bootstrap/journals/approve-csr.log": "",
+	})
+	defer os.RemoveAll(a)
+	b := writeBundle(t, map[string]string{
+		"summary.txt":                     "# analyzer version 1\njournal: timed out after 1m0s\n",
+		"bootstrap/journals/kubelet.log":  strings.Repeat("b", 100),
+		"bootstrap/journals/bootkube.log": "same",
+		"audit/audit.log":                 "{}\n",
+	})
+	defer os.RemoveAll(b)
+
+	report, err := DiffBundles(a, b, []Analyzer{AuditLogAnalyzer()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{
+		"+ member audit/audit.log",
+		"- member audit/missing.txt",
+		"- member bootstrap/journals/approve-csr.log",
+		"~ member bootstrap/journals/kubelet.log: 10000 -> 100 bytes",
+		"- step etcd-data failed",
+		"- verdict audit: no kube-apiserver audit log, the bootstrap kube-apiserver may never have started",
+		"+ step journal failed",
+	}, report)
+}
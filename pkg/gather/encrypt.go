@@ -0,0 +1,80 @@
+package gather
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Encryptor encrypts bundles for a single recipient by streaming them
+// through the age or gpg command, so that the plaintext bundle is never
+// written to disk.
+type Encryptor struct {
+	command string
+	args    []string
+	ext     string
+}
+
+// NewEncryptor returns an Encryptor for recipient. An age public key
+// (age1...) or a file of age or SSH public keys is encrypted to with age.
+// Any other file is taken to hold an OpenPGP public key, and any other
+// string to be an OpenPGP user ID or fingerprint in the gpg keyring, both
+// of which are encrypted to with gpg.
+func NewEncryptor(recipient string) (*Encryptor, error) {
+	e := &Encryptor{}
+	switch data, err := ioutil.ReadFile(recipient); {
+	case strings.HasPrefix(recipient, "age1"):
+		e.command, e.args, e.ext = "age", []string{"--recipient", recipient}, ".age"
+	case err == nil && (bytes.HasPrefix(data, []byte("age1")) || bytes.HasPrefix(data, []byte("ssh-"))):
+		e.command, e.args, e.ext = "age", []string{"--recipients-file", recipient}, ".age"
+	case err == nil:
+		e.command, e.args, e.ext = "gpg", []string{"--batch", "--yes", "--trust-model", "always", "--encrypt", "--recipient-file", recipient}, ".gpg"
+	default:
+		e.command, e.args, e.ext = "gpg", []string{"--batch", "--yes", "--trust-model", "always", "--encrypt", "--recipient", recipient}, ".gpg"
+	}
+	if _, err := exec.LookPath(e.command); err != nil {
+		return nil, fmt.Errorf("%s is needed to encrypt to %q: %w", e.command, recipient, err)
+	}
+	return e, nil
+}
+
+// Encrypt writes the encryption of the data written by write to path with
+// the extension of the encryption format appended, and returns the path of
+// the encrypted file.
+func (e *Encryptor) Encrypt(path string, write func(io.Writer) error) (_ string, err error) {
+	path += e.ext
+	out, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create encrypted bundle: %w", err)
+	}
+	defer func() {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command(e.command, e.args...)
+	cmd.Stdout = out
+	cmd.Stderr = stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("failed to run %s: %w", e.command, err)
+	}
+	werr := write(in)
+	in.Close()
+	if err := cmd.Wait(); err != nil {
+		return "", fmt.Errorf("%s failed: %s: %w", e.command, strings.TrimSpace(stderr.String()), err)
+	}
+	return path, werr
+}
@@ -0,0 +1,77 @@
+package gather
+
+import (
+	"errors"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+var (
+	// ErrUnsupportedPlatform matches errors for platforms whose host
+	// addresses cannot be read from the terraform state.
+	ErrUnsupportedPlatform = errors.New("unsupported platform")
+
+	// ErrPlatformMismatch matches errors for terraform states created for
+	// another platform than the one of the install config.
+	ErrPlatformMismatch = errors.New("terraform state platform mismatch")
+
+	// ErrNoBootstrap matches errors for gathers which need a bootstrap host
+	// when there is none.
+	ErrNoBootstrap = errors.New("no bootstrap host")
+
+	// ErrAuthFailed matches errors from hosts which rejected every key.
+	ErrAuthFailed = gatherssh.ErrAuthFailed
+
+	// ErrPullFailed matches errors downloading a file from a host.
+	ErrPullFailed = gatherssh.ErrPullFailed
+
+	// ErrUsage matches errors for invalid options.
+	ErrUsage = errors.New("invalid options")
+
+	// ErrUnreachable matches errors for gathers which could not connect to
+	// any of the hosts.
+	ErrUnreachable = errors.New("no host reachable")
+
+	// ErrScriptFailed matches errors running installer-gather.sh on a
+	// host.
+	ErrScriptFailed = errors.New("installer-gather.sh failed")
+
+	// ErrPartial matches errors for gathers which could connect to only
+	// some of the hosts.
+	ErrPartial = errors.New("partial gather")
+)
+
+// Exit codes of a failed gather, telling callers whether retrying may help.
+const (
+	// ExitUsage is the exit code of invalid options, and of failures not
+	// covered by the other codes.
+	ExitUsage = 1
+
+	// ExitUnreachable is the exit code when none of the hosts could be
+	// connected to.
+	ExitUnreachable = 2
+
+	// ExitScriptFailed is the exit code when installer-gather.sh failed,
+	// or its bundle could not be pulled.
+	ExitScriptFailed = 3
+
+	// ExitPartial is the exit code when the bundle was written, but some
+	// of the hosts could not be connected to.
+	ExitPartial = 4
+)
+
+// ExitCode returns the exit code of a gather which failed with err, or 0
+// when err is nil.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, ErrUnreachable), errors.Is(err, ErrAuthFailed):
+		return ExitUnreachable
+	case errors.Is(err, ErrScriptFailed), errors.Is(err, ErrPullFailed):
+		return ExitScriptFailed
+	case errors.Is(err, ErrPartial):
+		return ExitPartial
+	}
+	return ExitUsage
+}
@@ -0,0 +1,124 @@
+package gather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	sentinels := []error{ErrUnsupportedPlatform, ErrNoBootstrap, ErrAuthFailed, ErrPullFailed}
+	cases := []struct {
+		name    string
+		err     error
+		matches error
+	}{
+		{
+			name:    "unsupported platform",
+			err:     fmt.Errorf("failed to get bootstrap and control plane host addresses: %w", fmt.Errorf("%w gcp", ErrUnsupportedPlatform)),
+			matches: ErrUnsupportedPlatform,
+		},
+		{
+			name:    "no bootstrap",
+			err:     fmt.Errorf("cannot estimate the bundle: %w", ErrNoBootstrap),
+			matches: ErrNoBootstrap,
+		},
+		{
+			name:    "auth failed",
+			err:     fmt.Errorf("failed to create SSH client: %w", fmt.Errorf("failed to authenticate on 10.0.0.1:22: %w", gatherssh.ErrAuthFailed)),
+			matches: ErrAuthFailed,
+		},
+		{
+			name:    "pull failed",
+			err:     fmt.Errorf("failed to pull log file: %w", gatherssh.ErrPullFailed),
+			matches: ErrPullFailed,
+		},
+		{
+			name: "other",
+			err:  errors.New("failed to read state"),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, sentinel := range sentinels {
+				assert.Equal(t, sentinel == tc.matches, errors.Is(tc.err, sentinel), "errors.Is(%v, %v)", tc.err, sentinel)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	unreachable := &Coverage{}
+	unreachable.Unreachable("bootstrap", "10.0.0.10", context.DeadlineExceeded)
+	partial := &Coverage{}
+	partial.Reached("bootstrap", "10.0.0.10")
+	partial.Unreachable("master", "10.0.0.1", context.DeadlineExceeded)
+	complete := &Coverage{}
+	complete.Reached("bootstrap", "10.0.0.10")
+
+	cases := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{name: "success", err: complete.Err(), code: 0},
+		{name: "usage", err: fmt.Errorf("%w: --ssh-user must not be empty", ErrUsage), code: ExitUsage},
+		{name: "no bootstrap", err: fmt.Errorf("%w: bootstrap host address must be provided", ErrNoBootstrap), code: ExitUsage},
+		{name: "other", err: errors.New("failed to read state"), code: ExitUsage},
+		{name: "unreachable", err: unreachable.Err(), code: ExitUnreachable},
+		{name: "auth failed", err: fmt.Errorf("failed to connect: %w", gatherssh.ErrAuthFailed), code: ExitUnreachable},
+		{name: "script failed", err: fmt.Errorf("%w: exit status 1", ErrScriptFailed), code: ExitScriptFailed},
+		{name: "pull failed", err: fmt.Errorf("failed to pull: %w", gatherssh.ErrPullFailed), code: ExitScriptFailed},
+		{name: "partial", err: partial.Err(), code: ExitPartial},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.code, ExitCode(tc.err))
+		})
+	}
+}
+
+func TestExitCodeWrapped(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exitcode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "log-bundle-20201015070000.tar.gz")
+	if err := ioutil.WriteFile(file, []byte("bundle"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{name: "unreachable", err: ErrUnreachable, code: ExitUnreachable},
+		{name: "auth failed", err: fmt.Errorf("failed to connect: %w", gatherssh.ErrAuthFailed), code: ExitUnreachable},
+		{name: "other", err: errors.New("access denied"), code: ExitUsage},
+		{name: "pull failed", err: fmt.Errorf("failed to pull: %w", gatherssh.ErrPullFailed), code: ExitScriptFailed},
+		{name: "partial", err: fmt.Errorf("%w: 1 of 4 hosts could not be collected", ErrPartial), code: ExitPartial},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// UploadBundle wraps the error of the uploader, which must keep
+			// its exit code.
+			_, err := UploadBundle(context.Background(), func(context.Context, string, string, io.Reader) error {
+				return tc.err
+			}, &UploadURL{Scheme: "s3", Bucket: "bundles"}, file)
+			if assert.Error(t, err) {
+				assert.Equal(t, tc.code, ExitCode(err))
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package gather
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+const (
+	// estimatedCompression is the ratio of the size of a gzipped bundle to
+	// the size of the logs and assets in it, which are mostly text.
+	estimatedCompression = 0.15
+
+	// throughputProbeBytes is the size of the random data transferred to
+	// measure the throughput of the connection.
+	throughputProbeBytes = 1 << 20
+)
+
+// Estimate is the estimated size and transfer time of the bundle of a host.
+type Estimate struct {
+	// RawBytes is the size of the logs and assets which would be collected.
+	RawBytes int64 `json:"rawBytes"`
+
+	// BundleBytes is the estimated size of the compressed bundle.
+	BundleBytes int64 `json:"bundleBytes"`
+
+	// BytesPerSecond is the measured throughput of the connection.
+	BytesPerSecond float64 `json:"bytesPerSecond"`
+
+	// TransferSeconds is the estimated time to pull the bundle.
+	TransferSeconds float64 `json:"transferSeconds"`
+}
+
+// estimateSizeCommand reports, as key=value lines, the bytes installer-gather.sh
+// collects from the bootstrap host.
+func estimateSizeCommand(maxUnitLogBytes int64) string {
+	return fmt.Sprintf(`j=0
+for u in %s; do
+  n=$(sudo journalctl --boot --no-pager --output=short --unit="${u}" | wc -c)
+  if [ %d -gt 0 ] && [ "${n}" -gt %d ]; then n=%d; fi
+  j=$((j + n))
+done
+echo journals=${j}
+echo pods=$(sudo du --summarize --bytes --total /var/log/pods /var/log/containers 2>/dev/null | tail -n 1 | cut -f1)
+echo assets=$(sudo du --summarize --bytes /opt/openshift 2>/dev/null | cut -f1)`, strings.Join(bootstrapUnits, " "), maxUnitLogBytes, maxUnitLogBytes, maxUnitLogBytes)
+}
+
+// EstimateBundle estimates the size of the bundle installer-gather.sh would
+// create on the bootstrap host, and the time to pull it based on the
+// throughput of a small probe transfer. When maxUnitLogBytes is positive,
+// journals are estimated as truncated to maxUnitLogBytes.
+func EstimateBundle(ctx context.Context, host *Host, maxUnitLogBytes int64) (*Estimate, error) {
+	out, err := gatherssh.Output(ctx, host.Client, estimateSizeCommand(maxUnitLogBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure the logs and assets: %w", err)
+	}
+	estimate := &Estimate{}
+	for _, value := range parseKeyValues(out) {
+		n, _ := strconv.ParseInt(value, 10, 64)
+		estimate.RawBytes += n
+	}
+	estimate.BundleBytes = int64(float64(estimate.RawBytes) * estimatedCompression)
+
+	start := time.Now()
+	probe, err := gatherssh.Output(ctx, host.Client, fmt.Sprintf("head --bytes=%d /dev/urandom", throughputProbeBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure the throughput: %w", err)
+	}
+	estimate.BytesPerSecond = float64(len(probe)) / time.Since(start).Seconds()
+	estimate.TransferSeconds = float64(estimate.BundleBytes) / estimate.BytesPerSecond
+	return estimate, nil
+}
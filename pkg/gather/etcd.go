@@ -0,0 +1,105 @@
+package gather
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// etcdDataDir is where etcd keeps its write-ahead log and snapshots.
+const etcdDataDir = "/var/lib/etcd"
+
+// etcdStatusCommand reports the state of the etcd data directory as
+// key=value lines.
+const etcdStatusCommand = `d=` + etcdDataDir + `/member
+if sudo test -d "${d}"; then echo present=true; else echo present=false; fi
+echo wal=$(sudo find "${d}/wal" -name '*.wal' 2>/dev/null | wc -l)
+echo snap=$(sudo find "${d}/snap" -name '*.snap' 2>/dev/null | wc -l)
+echo db=$(sudo stat --format=%s "${d}/snap/db" 2>/dev/null || echo 0)`
+
+// etcdSnapshotStatusCommand reports the member revision and hash from the
+// etcd backend database, when etcdctl is available on the host.
+const etcdSnapshotStatusCommand = `command -v etcdctl >/dev/null || { echo 'etcdctl is not available'; exit 0; }
+sudo test -f ` + etcdDataDir + `/member/snap/db || { echo 'no backend database'; exit 0; }
+sudo ETCDCTL_API=3 etcdctl snapshot status --write-out=table ` + etcdDataDir + `/member/snap/db`
+
+// EtcdDataStep returns a step that records metadata about the etcd data
+// directory, like the presence and sizes of the write-ahead log and
+// snapshots. The data itself is not collected, because it can be large
+// and contains secrets.
+func EtcdDataStep() Step {
+	return Step{
+		Name: "etcd-data",
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			status, err := gatherssh.Output(ctx, host.Client, etcdStatusCommand)
+			if err != nil {
+				return fmt.Errorf("failed to inspect the etcd data directory: %w", err)
+			}
+			if err := ioutil.WriteFile(filepath.Join(dir, "status.txt"), status, 0644); err != nil {
+				return err
+			}
+
+			for file, command := range map[string]string{
+				"listing.txt":         "sudo ls -lR --time-style=full-iso " + etcdDataDir,
+				"snapshot-status.txt": etcdSnapshotStatusCommand,
+			} {
+				out, err := gatherssh.Output(ctx, host.Client, command)
+				if err != nil {
+					return fmt.Errorf("failed to collect %s: %w", file, err)
+				}
+				if err := ioutil.WriteFile(filepath.Join(dir, file), out, 0644); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// EtcdDataAnalyzer returns an analyzer that reports whether etcd has ever
+// committed data, based on the status recorded by EtcdDataStep.
+func EtcdDataAnalyzer() Analyzer {
+	return Analyzer{
+		Name:  "etcd-data",
+		Files: []string{"etcd-data/status.txt"},
+		Analyze: func(files map[string][]byte, summary *Summary) {
+			for _, status := range files {
+				summary.Addf("etcd-data: %s", describeEtcdData(parseKeyValues(status)))
+			}
+		},
+	}
+}
+
+// describeEtcdData explains whether etcd has ever committed data given the
+// output of etcdStatusCommand.
+func describeEtcdData(status map[string]string) string {
+	if status["present"] != "true" {
+		return "no member data directory, etcd has never started on this host"
+	}
+	wal, _ := strconv.Atoi(status["wal"])
+	db, _ := strconv.ParseInt(status["db"], 10, 64)
+	if wal == 0 && db == 0 {
+		return "member data directory is empty, etcd has never committed data"
+	}
+	return "etcd has committed data (" + status["wal"] + " WAL files, " + status["snap"] + " snapshots, " + status["db"] + " byte backend database)"
+}
+
+// parseKeyValues parses key=value lines, ignoring any other lines.
+func parseKeyValues(data []byte) map[string]string {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "=", 2)
+		if len(parts) == 2 {
+			values[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	return values
+}
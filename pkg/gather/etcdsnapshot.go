@@ -0,0 +1,63 @@
+package gather
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// etcdSnapshotCommand prints the size and path of the newest etcd snapshot
+// on the host, preferring backups taken with etcdctl over the live backend
+// database of the member.
+const etcdSnapshotCommand = `for f in $(sudo sh -c 'ls -1t /var/lib/etcd-backup/*.db /home/*/assets/backup/*.db 2>/dev/null') ` + etcdDataDir + `/member/snap/db; do
+  if sudo test -f "${f}"; then sudo stat --format='%s %n' "${f}"; exit 0; fi
+done`
+
+// etcdSnapshotWarning is stored with every pulled snapshot.
+const etcdSnapshotWarning = `This etcd snapshot holds the complete cluster state, including every
+Secret, unencrypted. Share it only with the people debugging the cluster.
+`
+
+// EtcdSnapshotStep returns a step that pulls the newest etcd snapshot on the
+// host, or the backend database of the etcd member when there is no
+// snapshot, as snapshot.db. Snapshots larger than maxBytes are skipped. The
+// snapshot contains secrets, so it is stored next to a SENSITIVE.txt
+// warning and readable only by the user.
+func EtcdSnapshotStep(maxBytes int64) Step {
+	return Step{
+		Name: "etcd-snapshot",
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			out, err := gatherssh.Output(ctx, host.Client, etcdSnapshotCommand)
+			if err != nil {
+				return fmt.Errorf("failed to find an etcd snapshot: %w", err)
+			}
+			fields := strings.SplitN(strings.TrimSpace(string(out)), " ", 2)
+			if len(fields) != 2 {
+				return ioutil.WriteFile(filepath.Join(dir, "missing.txt"), []byte("no etcd snapshot found\n"), 0644)
+			}
+			size, err := strconv.ParseInt(fields[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid size of %s: %w", fields[1], err)
+			}
+			if maxBytes > 0 && size > maxBytes {
+				return ioutil.WriteFile(filepath.Join(dir, "skipped.txt"), []byte(fmt.Sprintf("%s is %d bytes, above the limit of %d bytes\n", fields[1], size, maxBytes)), 0644)
+			}
+
+			if err := ioutil.WriteFile(filepath.Join(dir, "SENSITIVE.txt"), []byte(etcdSnapshotWarning), 0644); err != nil {
+				return err
+			}
+			snapshot := filepath.Join(dir, "snapshot.db")
+			if err := pullPrivileged(ctx, host, fields[1], snapshot, 0); err != nil {
+				return err
+			}
+			summary.Addf("etcd-snapshot: pulled %s from %s, the bundle contains secrets", fields[1], host.Address)
+			return os.Chmod(snapshot, 0600)
+		},
+	}
+}
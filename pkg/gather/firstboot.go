@@ -0,0 +1,63 @@
+package gather
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// firstBootMarkersCommand lists the files Ignition and the first-boot units
+// leave behind, so a missing marker shows how far provisioning got.
+const firstBootMarkersCommand = `for f in /boot/ignition.firstboot /etc/.ignition-result.json /etc/machine-id /etc/hostname; do
+  if sudo test -e "${f}"; then echo "${f}=present"; else echo "${f}=missing"; fi
+done`
+
+// FirstBootStep returns a step that collects the journals of the afterburn
+// and coreos-* units which provision the hostname, network and SSH keys of
+// the host on first boot, along with the first-boot markers.
+func FirstBootStep() Step {
+	return Step{
+		Name: "firstboot",
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			for file, command := range map[string]string{
+				"afterburn.log": "sudo journalctl --no-pager --unit='afterburn*'",
+				"coreos.log":    "sudo journalctl --no-pager --unit='coreos-*'",
+				"errors.log":    "sudo journalctl --no-pager --quiet --priority=err --unit='afterburn*' --unit='coreos-*'",
+				"markers.txt":   firstBootMarkersCommand,
+			} {
+				out, err := gatherssh.Output(ctx, host.Client, command)
+				if err != nil {
+					return fmt.Errorf("failed to collect %s: %w", file, err)
+				}
+				if err := ioutil.WriteFile(filepath.Join(dir, file), out, 0644); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// FirstBootAnalyzer returns an analyzer that flags errors reported by the
+// first-boot provisioning units, based on the journals recorded by
+// FirstBootStep.
+func FirstBootAnalyzer() Analyzer {
+	return Analyzer{
+		Name:  "firstboot",
+		Files: []string{"firstboot/errors.log"},
+		Analyze: func(files map[string][]byte, summary *Summary) {
+			for _, errs := range files {
+				count := countLines(errs)
+				if count == 0 {
+					continue
+				}
+				lines := strings.Split(strings.TrimSpace(string(errs)), "\n")
+				summary.Addf("firstboot: first-boot provisioning logged %d errors, the last one: %s", count, strings.TrimSpace(lines[len(lines)-1]))
+			}
+		},
+	}
+}
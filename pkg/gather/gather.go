@@ -0,0 +1,91 @@
+// Package gather contains the diagnostic steps that collect debugging data
+// from cluster hosts in addition to the bundle created by installer-gather.sh.
+package gather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+// Host is a machine that debugging data is collected from.
+type Host struct {
+	// Role is the role of the host in the cluster (e.g. "bootstrap").
+	Role string
+
+	// Address is the hostname or IP used to connect to the host.
+	Address string
+
+	// Client is the SSH client connected to the host.
+	Client *ssh.Client
+}
+
+// Step is a best-effort diagnostic collected from a host.
+type Step struct {
+	// Name identifies the step. It is also the bundle directory the step
+	// writes its files to.
+	Name string
+
+	// Run collects the diagnostic from host into dir, recording notable
+	// findings in summary. Remote commands must be abandoned when ctx is
+	// done.
+	Run func(ctx context.Context, host *Host, dir string, summary *Summary) error
+}
+
+// DefaultSteps returns the steps that are run on every host and need no
+// information about the cluster.
+func DefaultSteps() []Step {
+	return []Step{
+		EtcdDataStep(),
+		JournalBootsStep(),
+		FirstBootStep(),
+		StorageStep(),
+	}
+}
+
+// RunSteps runs each of the steps against host, writing the data for each
+// step into its own subdirectory of dir. Failing steps are recorded in
+// the summary and do not stop the remaining steps. Each step is abandoned
+// after timeout, unless timeout is zero.
+func RunSteps(host *Host, dir string, summary *Summary, steps []Step, timeout time.Duration) {
+	RunStepsContext(context.Background(), host, dir, summary, steps, timeout)
+}
+
+// RunStepsContext is like RunSteps, but cancels the running step and skips
+// the remaining ones when ctx is done.
+func RunStepsContext(ctx context.Context, host *Host, dir string, summary *Summary, steps []Step, timeout time.Duration) {
+	for _, step := range steps {
+		if ctx.Err() != nil {
+			return
+		}
+		logrus.Debugf("Running gather step %s on %s", step.Name, host.Address)
+		stepDir := filepath.Join(dir, step.Name)
+		if err := os.MkdirAll(stepDir, 0755); err != nil {
+			summary.Addf("%s: %v", step.Name, fmt.Errorf("failed to create step directory: %w", err))
+			continue
+		}
+		if err := runStep(ctx, host, stepDir, summary, step, timeout); err != nil {
+			logrus.Debugf("Gather step %s failed: %v", step.Name, err)
+			if errors.Is(err, context.DeadlineExceeded) {
+				summary.Addf("%s: timed out after %s", step.Name, timeout)
+				continue
+			}
+			summary.Addf("%s: failed: %v", step.Name, err)
+		}
+	}
+}
+
+func runStep(ctx context.Context, host *Host, dir string, summary *Summary, step Step, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return step.Run(ctx, host, dir, summary)
+}
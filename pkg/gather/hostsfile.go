@@ -0,0 +1,122 @@
+package gather
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+
+	"github.com/ghodss/yaml"
+)
+
+// HostsFile is a saved set of hosts to gather from, for clusters whose
+// host addresses cannot be read from the terraform state, written in YAML
+// or JSON:
+//
+//	bootstrap:
+//	  address: 10.0.0.5
+//	masters:
+//	- address: 10.0.1.7
+//	  port: 2222
+//	  key: /home/user/.ssh/master
+type HostsFile struct {
+	Bootstrap *HostEntry  `json:"bootstrap,omitempty"`
+	Masters   []HostEntry `json:"masters"`
+}
+
+// HostEntry is a host of a HostsFile.
+type HostEntry struct {
+	// Address is the hostname or IP of the host, which may hold a port.
+	Address string `json:"address"`
+
+	// Port is the SSH port of the host, which defaults to 22.
+	Port int `json:"port,omitempty"`
+
+	// Key is the path of an SSH private key to log in to the host with.
+	Key string `json:"key,omitempty"`
+}
+
+// LoadHostsFile reads and validates the hosts file at path.
+func LoadHostsFile(path string) (*HostsFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := ParseHostsFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hosts file %s: %w", path, err)
+	}
+	return hosts, nil
+}
+
+// ParseHostsFile parses and validates a hosts file. Unknown fields are
+// rejected, and the addresses of the entries are normalized, with the port
+// of an address moved to the Port of its entry.
+func ParseHostsFile(data []byte) (*HostsFile, error) {
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	hosts := &HostsFile{}
+	if err := decoder.Decode(hosts); err != nil {
+		return nil, err
+	}
+	if hosts.Bootstrap != nil {
+		if err := hosts.Bootstrap.normalize(); err != nil {
+			return nil, fmt.Errorf("bootstrap: %w", err)
+		}
+	}
+	if len(hosts.Masters) == 0 {
+		return nil, errors.New("masters: at least one control plane host is required")
+	}
+	seen := map[string]int{}
+	for i := range hosts.Masters {
+		if err := hosts.Masters[i].normalize(); err != nil {
+			return nil, fmt.Errorf("masters[%d]: %w", i, err)
+		}
+		if j, ok := seen[hosts.Masters[i].Address]; ok {
+			return nil, fmt.Errorf("masters[%d]: %s is already masters[%d]", i, hosts.Masters[i].Address, j)
+		}
+		seen[hosts.Masters[i].Address] = i
+	}
+	return hosts, nil
+}
+
+// Marshal returns the hosts file as YAML.
+func (h *HostsFile) Marshal() ([]byte, error) {
+	return yaml.Marshal(h)
+}
+
+func (e *HostEntry) normalize() error {
+	if e.Address == "" {
+		return errors.New("address is required")
+	}
+	address, port, err := ParseHost(e.Address)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", e.Address, err)
+	}
+	if port != 0 && e.Port != 0 && port != e.Port {
+		return fmt.Errorf("the port of the address %q differs from port %d", e.Address, e.Port)
+	}
+	if e.Port < 0 || e.Port > 65535 {
+		return fmt.Errorf("invalid port %d", e.Port)
+	}
+	if port != 0 {
+		e.Port = port
+	}
+	e.Address = address
+	return nil
+}
+
+// String returns the address of the entry, with its port if it has one.
+func (e HostEntry) String() string {
+	if e.Port == 0 {
+		return e.Address
+	}
+	return net.JoinHostPort(e.Address, strconv.Itoa(e.Port))
+}
@@ -0,0 +1,92 @@
+package gather
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHostsFile(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     string
+		expected *HostsFile
+		err      string
+	}{
+		{
+			name: "yaml",
+			data: `
+bootstrap:
+  address: 10.0.0.5
+masters:
+- address: 10.0.1.7:2222
+  key: /home/user/.ssh/master
+- address: master-1.example.com
+  port: 2200
+`,
+			expected: &HostsFile{
+				Bootstrap: &HostEntry{Address: "10.0.0.5"},
+				Masters: []HostEntry{
+					{Address: "10.0.1.7", Port: 2222, Key: "/home/user/.ssh/master"},
+					{Address: "master-1.example.com", Port: 2200},
+				},
+			},
+		},
+		{
+			name:     "json",
+			data:     `{"masters": [{"address": "[fd00::7]:22"}]}`,
+			expected: &HostsFile{Masters: []HostEntry{{Address: "fd00::7", Port: 22}}},
+		},
+		{
+			name: "unknown field",
+			data: "masters:\n- address: 10.0.1.7\n  user: core\n",
+			err:  `json: unknown field "user"`,
+		},
+		{
+			name: "no masters",
+			data: "bootstrap:\n  address: 10.0.0.5\n",
+			err:  "masters: at least one control plane host is required",
+		},
+		{
+			name: "invalid master",
+			data: "masters:\n- address: 10.0.1.7\n- address: not_a_host\n",
+			err:  `masters[1]: invalid address "not_a_host": `,
+		},
+		{
+			name: "missing address",
+			data: "bootstrap:\n  port: 22\nmasters:\n- address: 10.0.1.7\n",
+			err:  "bootstrap: address is required",
+		},
+		{
+			name: "conflicting port",
+			data: "masters:\n- address: 10.0.1.7:22\n  port: 2222\n",
+			err:  `masters[0]: the port of the address "10.0.1.7:22" differs from port 2222`,
+		},
+		{
+			name: "duplicate",
+			data: "masters:\n- address: 10.0.1.7\n- address: 10.0.1.7:22\n",
+			err:  "masters[1]: 10.0.1.7 is already masters[0]",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			hosts, err := ParseHostsFile([]byte(tc.data))
+			if tc.err != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, hosts)
+
+			data, err := hosts.Marshal()
+			if !assert.NoError(t, err) {
+				return
+			}
+			roundTripped, err := ParseHostsFile(data)
+			assert.NoError(t, err)
+			assert.Equal(t, hosts, roundTripped)
+		})
+	}
+}
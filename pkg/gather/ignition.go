@@ -0,0 +1,228 @@
+package gather
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	ignition "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/vincent-petithory/dataurl"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// redactedSource replaces the contents of files which may hold secrets.
+const redactedSource = "data:,REDACTED"
+
+// maxDecodedFileBytes is the size above which the contents of a file are
+// left out of the decoded Ignition config.
+const maxDecodedFileBytes = 16 * 1024
+
+// WorkerIgnitionStep returns a step that requests the worker Ignition config
+// from the machine config server at endpoint the same way a joining worker
+// does, verifying the server certificate against rootCA. The served config
+// is stored with the contents of secret files redacted. When decode is
+// set, a readable listing of the files, units and users of the config is
+// stored alongside it as ignition-decoded.txt.
+func WorkerIgnitionStep(endpoint string, rootCA []byte, decode bool) Step {
+	return Step{
+		Name: "worker-ignition",
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			raw, err := gatherssh.Output(ctx, host.Client, fetchIgnitionCommand(endpoint, rootCA))
+			if err != nil {
+				if ctx.Err() != nil {
+					return err
+				}
+				err = fmt.Errorf("failed to fetch %s from %s: %w", endpoint, host.Address, err)
+				if werr := ioutil.WriteFile(filepath.Join(dir, "error.txt"), []byte(err.Error()+"\n"), 0644); werr != nil {
+					return werr
+				}
+				return err
+			}
+
+			config, err := RedactIgnition(raw)
+			if err != nil {
+				return fmt.Errorf("invalid config served by %s: %w", endpoint, err)
+			}
+			data, err := json.MarshalIndent(config, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(filepath.Join(dir, "worker.ign"), data, 0600); err != nil {
+				return err
+			}
+			if !decode {
+				return nil
+			}
+			return ioutil.WriteFile(filepath.Join(dir, "ignition-decoded.txt"), DecodeIgnition(config), 0600)
+		},
+	}
+}
+
+// WorkerIgnitionAnalyzer returns an analyzer that reports whether the
+// machine config server served the worker Ignition config to
+// WorkerIgnitionStep.
+func WorkerIgnitionAnalyzer() Analyzer {
+	return Analyzer{
+		Name:  "worker-ignition",
+		Files: []string{"worker-ignition/worker.ign", "worker-ignition/error.txt"},
+		Analyze: func(files map[string][]byte, summary *Summary) {
+			if msg, ok := files["worker-ignition/error.txt"]; ok {
+				summary.Addf("worker-ignition: workers cannot fetch their config: %s", strings.TrimSpace(string(msg)))
+				return
+			}
+			config := &ignition.Config{}
+			if err := json.Unmarshal(files["worker-ignition/worker.ign"], config); err != nil {
+				summary.Addf("worker-ignition: the served config is invalid: %v", err)
+				return
+			}
+			summary.Addf("worker-ignition: the served config has %d files and %d units", len(config.Storage.Files), len(config.Systemd.Units))
+		},
+	}
+}
+
+// fetchIgnitionCommand returns a remote command which fetches the Ignition
+// config at endpoint, trusting only rootCA.
+func fetchIgnitionCommand(endpoint string, rootCA []byte) string {
+	return fmt.Sprintf(`ca="$(mktemp)" && cat > "${ca}" <<'EOF'
+%s
+EOF
+curl --silent --show-error --fail --max-time 30 --cacert "${ca}" --header 'Accept: application/vnd.coreos.ignition+json; version=2.2.0' '%s'
+rc=$?
+rm -f "${ca}"
+exit ${rc}`, strings.TrimSpace(string(rootCA)), endpoint)
+}
+
+// RedactIgnition parses an Ignition config and replaces the contents of
+// files that may contain secrets, as well as any user password hashes.
+func RedactIgnition(raw []byte) (*ignition.Config, error) {
+	config := &ignition.Config{}
+	if err := json.Unmarshal(raw, config); err != nil {
+		return nil, err
+	}
+	for i, file := range config.Storage.Files {
+		if isSecretPath(file.Path) {
+			config.Storage.Files[i].Contents.Source = redactedSource
+			config.Storage.Files[i].Contents.Verification = ignition.Verification{}
+		}
+	}
+	for i := range config.Passwd.Users {
+		if config.Passwd.Users[i].PasswordHash != nil {
+			config.Passwd.Users[i].PasswordHash = nil
+		}
+	}
+	return config, nil
+}
+
+// DecodeIgnition returns a readable listing of the files written, the units
+// configured and the users created by config, with the contents of inline
+// text files decoded. Pass a config returned by RedactIgnition so that
+// secrets are not decoded.
+func DecodeIgnition(config *ignition.Config) []byte {
+	var buf bytes.Buffer
+
+	files := append([]ignition.File(nil), config.Storage.Files...)
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	fmt.Fprintf(&buf, "files (%d):\n", len(files))
+	for _, file := range files {
+		mode := "default mode"
+		if file.Mode != nil {
+			mode = fmt.Sprintf("mode %04o", *file.Mode)
+		}
+		data, err := decodeFileContents(file.Contents)
+		switch {
+		case err != nil:
+			fmt.Fprintf(&buf, "  %s (%s): %v\n", file.Path, mode, err)
+		case file.Contents.Source == redactedSource:
+			fmt.Fprintf(&buf, "  %s (%s): redacted\n", file.Path, mode)
+		case len(data) > maxDecodedFileBytes || !utf8.Valid(data):
+			fmt.Fprintf(&buf, "  %s (%s, %d bytes)\n", file.Path, mode, len(data))
+		default:
+			fmt.Fprintf(&buf, "  %s (%s, %d bytes):\n", file.Path, mode, len(data))
+			writeIndented(&buf, data)
+		}
+	}
+
+	units := append([]ignition.Unit(nil), config.Systemd.Units...)
+	sort.Slice(units, func(i, j int) bool { return units[i].Name < units[j].Name })
+	fmt.Fprintf(&buf, "units (%d):\n", len(units))
+	for _, unit := range units {
+		state := "not enabled"
+		switch {
+		case unit.Mask:
+			state = "masked"
+		case unit.Enable || (unit.Enabled != nil && *unit.Enabled):
+			state = "enabled"
+		case unit.Enabled != nil:
+			state = "disabled"
+		}
+		fmt.Fprintf(&buf, "  %s (%s", unit.Name, state)
+		for _, dropin := range unit.Dropins {
+			fmt.Fprintf(&buf, ", drop-in %s", dropin.Name)
+		}
+		fmt.Fprintln(&buf, ")")
+		if unit.Contents != "" {
+			writeIndented(&buf, []byte(unit.Contents))
+		}
+	}
+
+	fmt.Fprintf(&buf, "users (%d):\n", len(config.Passwd.Users))
+	for _, user := range config.Passwd.Users {
+		fmt.Fprintf(&buf, "  %s (%d SSH keys)\n", user.Name, len(user.SSHAuthorizedKeys))
+	}
+	return buf.Bytes()
+}
+
+// decodeFileContents returns the contents of an inline data URL file,
+// decompressing them when needed. Remote sources are not fetched and
+// decode to an error naming the source.
+func decodeFileContents(contents ignition.FileContents) ([]byte, error) {
+	if contents.Source == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(contents.Source, "data:") {
+		return nil, fmt.Errorf("fetched from %s", contents.Source)
+	}
+	url, err := dataurl.DecodeString(contents.Source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data URL: %w", err)
+	}
+	if contents.Compression != "gzip" {
+		return url.Data, nil
+	}
+	gr, err := gzip.NewReader(bytes.NewReader(url.Data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip data: %w", err)
+	}
+	defer gr.Close()
+	return ioutil.ReadAll(gr)
+}
+
+// writeIndented writes data to buf with every line indented.
+func writeIndented(buf *bytes.Buffer, data []byte) {
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		fmt.Fprintf(buf, "      %s\n", line)
+	}
+}
+
+// isSecretPath returns true for file paths that usually hold credentials,
+// like pull secrets, kubeconfigs and private keys.
+func isSecretPath(path string) bool {
+	base := filepath.Base(path)
+	switch {
+	case strings.Contains(path, "secret"),
+		strings.Contains(path, "kubeconfig"),
+		strings.Contains(path, "token"),
+		strings.HasSuffix(base, ".key"),
+		base == "config.json":
+		return true
+	}
+	return false
+}
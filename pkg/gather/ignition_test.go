@@ -0,0 +1,41 @@
+package gather
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeIgnition(t *testing.T) {
+	raw := []byte(`{
+  "ignition": {"version": "2.2.0"},
+  "storage": {"files": [
+    {"path": "/etc/motd", "mode": 420, "contents": {"source": "data:;base64,aGVsbG8K"}},
+    {"path": "/var/lib/kubelet/config.json", "mode": 384, "contents": {"source": "data:,%7B%22auths%22%3A%7B%7D%7D"}},
+    {"path": "/etc/compressed", "contents": {"compression": "gzip", "source": "data:;base64,H4sIAAAAAAAA/0vLz+cCAKhlMn4EAAAA"}},
+    {"path": "/etc/remote", "contents": {"source": "https://example.com/remote"}}
+  ]},
+  "systemd": {"units": [
+    {"name": "kubelet.service", "enabled": true, "dropins": [{"name": "10-mco-default-env.conf"}]},
+    {"name": "zincati.service", "mask": true}
+  ]},
+  "passwd": {"users": [{"name": "core", "passwordHash": "secret", "sshAuthorizedKeys": ["ssh-rsa AAAA"]}]}
+}`)
+	config, err := RedactIgnition(raw)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `files (4):
+  /etc/compressed (default mode, 4 bytes):
+      foo
+  /etc/motd (mode 0644, 6 bytes):
+      hello
+  /etc/remote (default mode): fetched from https://example.com/remote
+  /var/lib/kubelet/config.json (mode 0600): redacted
+units (2):
+  kubelet.service (enabled, drop-in 10-mco-default-env.conf)
+  zincati.service (masked)
+users (1):
+  core (1 SSH keys)
+`, string(DecodeIgnition(config)))
+}
@@ -0,0 +1,62 @@
+package gather
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// maxExpectedBoots is the number of boots above which a host is assumed to
+// be stuck in a reboot loop. Hosts normally reboot at most a couple of
+// times while pivoting to the machine-os-content.
+const maxExpectedBoots = 5
+
+// JournalBootsStep returns a step that records the boots known to the
+// journal.
+func JournalBootsStep() Step {
+	return Step{
+		Name: "journal",
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			out, err := gatherssh.Output(ctx, host.Client, "sudo journalctl --list-boots --no-pager")
+			if err != nil {
+				return fmt.Errorf("failed to list boots: %w", err)
+			}
+			return ioutil.WriteFile(filepath.Join(dir, "boots.txt"), out, 0644)
+		},
+	}
+}
+
+// JournalBootsAnalyzer returns an analyzer that flags hosts which booted
+// more often than expected, based on the boot list recorded by
+// JournalBootsStep.
+func JournalBootsAnalyzer() Analyzer {
+	return Analyzer{
+		Name:  "journal",
+		Files: []string{"journal/boots.txt"},
+		Analyze: func(files map[string][]byte, summary *Summary) {
+			for _, boots := range files {
+				if count := countLines(boots); count > maxExpectedBoots {
+					summary.Addf("journal: the host booted %d times, it may be in a reboot loop", count)
+				}
+			}
+		},
+	}
+}
+
+// countLines returns the number of non-empty lines in data.
+func countLines(data []byte) int {
+	count := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) != "" {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,76 @@
+package gather
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/openshift/installer/pkg/asset"
+)
+
+// ManifestsStep returns a step that writes the locally-rendered manifest
+// files into the bundle. The data of Secret manifests is redacted and
+// other files that hold credentials are skipped. The host is not used.
+func ManifestsStep(files []*asset.File) Step {
+	return Step{
+		Name: "manifests",
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			skipped := 0
+			for _, file := range files {
+				data, err := redactManifest(file)
+				if err != nil {
+					return fmt.Errorf("failed to redact %s: %w", file.Filename, err)
+				}
+				if data == nil {
+					skipped++
+					continue
+				}
+				path := filepath.Join(dir, file.Filename)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					return err
+				}
+				if err := ioutil.WriteFile(path, data, 0600); err != nil {
+					return err
+				}
+			}
+			if skipped > 0 {
+				summary.Addf("manifests: skipped %d files that may hold credentials", skipped)
+			}
+			return nil
+		},
+	}
+}
+
+// redactManifest returns the contents of file with the values of Secret
+// data replaced. It returns nil for files which may hold credentials
+// but are not Secrets.
+func redactManifest(file *asset.File) ([]byte, error) {
+	var manifest map[string]interface{}
+	if err := yaml.Unmarshal(file.Data, &manifest); err != nil || manifest == nil {
+		if isSecretPath(file.Filename) {
+			return nil, nil
+		}
+		return file.Data, nil
+	}
+
+	if manifest["kind"] != "Secret" {
+		if isSecretPath(file.Filename) {
+			return nil, nil
+		}
+		return file.Data, nil
+	}
+	for _, field := range []string{"data", "stringData"} {
+		values, ok := manifest[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key := range values {
+			values[key] = "REDACTED"
+		}
+	}
+	return yaml.Marshal(manifest)
+}
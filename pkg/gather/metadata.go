@@ -0,0 +1,40 @@
+package gather
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"time"
+)
+
+// MetadataFileName is the name of the file describing a gather, which is
+// written into the bundle and next to it.
+const MetadataFileName = "gather-metadata.json"
+
+// Metadata describes a gather, so that tooling can route and triage a
+// bundle without unpacking it.
+type Metadata struct {
+	// Platform is the name of the platform of the cluster, when the install
+	// config could be read.
+	Platform string `json:"platform,omitempty"`
+
+	InstallerVersion string `json:"installerVersion"`
+	SSHUser          string `json:"sshUser"`
+
+	Bootstrap string   `json:"bootstrap,omitempty"`
+	Masters   []string `json:"masters"`
+
+	// Hosts are the hosts that were connected to, or failed to be.
+	Hosts []HostResult `json:"hosts"`
+
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+}
+
+// WriteFile writes the metadata to path as JSON.
+func (m *Metadata) WriteFile(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,59 @@
+package gather
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataWriteFile(t *testing.T) {
+	coverage := &Coverage{}
+	coverage.Reached("bootstrap", "10.0.0.10")
+	coverage.Reached("master", "10.0.0.1")
+	coverage.Unreachable("master", "10.0.0.2", os.NewSyscallError("connect", syscall.ECONNREFUSED))
+
+	start := time.Date(2020, 10, 15, 7, 0, 0, 0, time.UTC)
+	metadata := &Metadata{
+		Platform:         "aws",
+		InstallerVersion: "v4.6.0",
+		SSHUser:          "core",
+		Bootstrap:        "10.0.0.10",
+		Masters:          []string{"10.0.0.1", "10.0.0.2"},
+		Hosts:            coverage.Hosts(),
+		StartTime:        start,
+		EndTime:          start.Add(3 * time.Minute),
+	}
+
+	dir, err := ioutil.TempDir("", "metadata")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, MetadataFileName)
+	if !assert.NoError(t, metadata.WriteFile(path)) {
+		return
+	}
+	data, err := ioutil.ReadFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.JSONEq(t, `{
+  "platform": "aws",
+  "installerVersion": "v4.6.0",
+  "sshUser": "core",
+  "bootstrap": "10.0.0.10",
+  "masters": ["10.0.0.1", "10.0.0.2"],
+  "hosts": [
+    {"role": "bootstrap", "address": "10.0.0.10", "reached": true},
+    {"role": "master", "address": "10.0.0.1", "reached": true},
+    {"role": "master", "address": "10.0.0.2", "reached": false, "error": "connection refused: connect: connection refused"}
+  ],
+  "startTime": "2020-10-15T07:00:00Z",
+  "endTime": "2020-10-15T07:03:00Z"
+}`, string(data))
+}
@@ -0,0 +1,51 @@
+package gather
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// mustGatherCommand runs must-gather from the bootstrap host and prints the
+// path of a tarball holding its output and log. The must-gather image is
+// looked up in the release image with podman, so that the mirrors
+// configured on the bootstrap host are used in disconnected installs.
+const mustGatherCommand = `dest="$(mktemp -d)"
+image="$(sudo podman run --quiet --rm '%s' image must-gather)" || exit 1
+sudo timeout %d %s adm must-gather --image="${image}" --dest-dir="${dest}/must-gather" > "${dest}/must-gather.log" 2>&1
+sudo tar -C "${dest}" -czf "${dest}.tar.gz" .
+sudo chown "$(id -u)" "${dest}.tar.gz"
+sudo rm -rf "${dest}"
+echo "${dest}.tar.gz"`
+
+// MustGatherStep returns a step that runs must-gather with the must-gather
+// image of releaseImage against the API through the bootstrap host, and
+// stores its output as must-gather.tar.gz. must-gather is stopped after
+// timeout. The step is skipped when the API is not reachable.
+func MustGatherStep(releaseImage string, timeout time.Duration) Step {
+	return Step{
+		Name: "must-gather",
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			oc, reason, err := bootstrapOC(ctx, host)
+			if err != nil {
+				return err
+			}
+			if reason != "" {
+				return ioutil.WriteFile(filepath.Join(dir, "skipped.txt"), []byte(reason+"\n"), 0644)
+			}
+
+			out, err := gatherssh.Output(ctx, host.Client, fmt.Sprintf(mustGatherCommand, releaseImage, int(timeout.Seconds()), oc))
+			if err != nil {
+				return fmt.Errorf("failed to run must-gather: %w", err)
+			}
+			tarball := strings.TrimSpace(string(out))
+			defer gatherssh.Output(gatherssh.CleanupContext(ctx), host.Client, fmt.Sprintf("rm -f '%s'", tarball))
+			return gatherssh.PullFileToContext(ctx, host.Client, tarball, filepath.Join(dir, "must-gather.tar.gz"))
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package gather
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// nodeRoleLabelPrefix is the prefix of the labels naming the roles of a
+// node.
+const nodeRoleLabelPrefix = "node-role.kubernetes.io/"
+
+// Node is a cluster node as reported by the Kubernetes API.
+type Node struct {
+	// Name is the name of the node object.
+	Name string
+
+	// Address is the internal address of the node, or its external address
+	// when it has no internal one. It is empty if the node reports neither.
+	Address string
+
+	// OperatingSystem is the operating system reported by the kubelet
+	// (e.g. "linux" or "windows").
+	OperatingSystem string
+
+	// Role is the role of the node from its node-role.kubernetes.io label,
+	// preferring "master" when it has several, or "node" when it has none.
+	Role string
+}
+
+// ListNodes returns the nodes of the cluster.
+func ListNodes(client kubernetes.Interface) ([]Node, error) {
+	list, err := client.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	nodes := make([]Node, 0, len(list.Items))
+	for _, node := range list.Items {
+		nodes = append(nodes, Node{
+			Name:            node.Name,
+			Address:         nodeAddress(node.Status.Addresses),
+			OperatingSystem: node.Status.NodeInfo.OperatingSystem,
+			Role:            nodeRole(node.Labels),
+		})
+	}
+	return nodes, nil
+}
+
+func nodeRole(labels map[string]string) string {
+	var roles []string
+	for label := range labels {
+		if strings.HasPrefix(label, nodeRoleLabelPrefix) {
+			roles = append(roles, strings.TrimPrefix(label, nodeRoleLabelPrefix))
+		}
+	}
+	if len(roles) == 0 {
+		return "node"
+	}
+	sort.Strings(roles)
+	for _, role := range roles {
+		if role == "master" {
+			return role
+		}
+	}
+	return roles[0]
+}
+
+func nodeAddress(addresses []corev1.NodeAddress) string {
+	for _, addressType := range []corev1.NodeAddressType{corev1.NodeInternalIP, corev1.NodeExternalIP} {
+		for _, address := range addresses {
+			if address.Type == addressType {
+				return address.Address
+			}
+		}
+	}
+	return ""
+}
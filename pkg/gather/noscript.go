@@ -0,0 +1,124 @@
+package gather
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// bootstrapUnits are the units whose journals installer-gather.sh
+// collects from the bootstrap host.
+var bootstrapUnits = []string{"bootkube", "openshift", "kubelet", "crio", "approve-csr"}
+
+// controlPlaneUnits are the units whose journals installer-masters-gather.sh
+// collects from the control plane hosts.
+var controlPlaneUnits = []string{"kubelet", "crio"}
+
+var crictlNameRE = regexp.MustCompile(`(?m)^Name: (.*)$`)
+
+// BootstrapScriptSteps returns steps that collect the journals and
+// container logs installer-gather.sh collects from the bootstrap host,
+// using the same bundle layout, for images where the script is missing or
+// broken. When maxUnitLogBytes is positive, journals are truncated to their
+// most recent maxUnitLogBytes.
+func BootstrapScriptSteps(maxUnitLogBytes int64) []Step {
+	return []Step{
+		unitJournalsStep("bootstrap/journals", bootstrapUnits, maxUnitLogBytes),
+		criContainersStep("bootstrap/containers"),
+		{
+			Name: "bootstrap/pods",
+			Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+				ids, err := gatherssh.Output(ctx, host.Client, "sudo podman ps --all --quiet")
+				if err != nil {
+					return fmt.Errorf("failed to list podman containers: %w", err)
+				}
+				for _, id := range strings.Fields(string(ids)) {
+					if err := writeOutput(ctx, host, fmt.Sprintf("sudo podman logs %s 2>&1", id), filepath.Join(dir, id+".log")); err != nil {
+						return err
+					}
+					if err := writeOutput(ctx, host, fmt.Sprintf("sudo podman inspect %s 2>&1", id), filepath.Join(dir, id+".inspect")); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// ControlPlaneSteps returns steps that collect the journals and container
+// logs installer-masters-gather.sh collects from a control plane host,
+// for collecting the host directly instead of through the bootstrap host.
+// When maxUnitLogBytes is positive, journals are truncated to their most
+// recent maxUnitLogBytes.
+func ControlPlaneSteps(maxUnitLogBytes int64) []Step {
+	return []Step{
+		unitJournalsStep("journals", controlPlaneUnits, maxUnitLogBytes),
+		criContainersStep("containers"),
+	}
+}
+
+// unitJournalsStep returns a step named name that writes the journal of
+// each of units to <unit>.log.
+func unitJournalsStep(name string, units []string, maxUnitLogBytes int64) Step {
+	return Step{
+		Name: name,
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			for _, unit := range units {
+				command := fmt.Sprintf("sudo journalctl --boot --no-pager --output=short --unit=%s", unit)
+				if maxUnitLogBytes > 0 {
+					command = fmt.Sprintf("%s | tail --bytes=%d", command, maxUnitLogBytes)
+				}
+				if err := writeOutput(ctx, host, command, filepath.Join(dir, unit+".log")); err != nil {
+					return fmt.Errorf("failed to collect the %s journal: %w", unit, err)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// criContainersStep returns a step named name that writes the logs and
+// status of every CRI-O container to <container>-<id>.log and .inspect.
+func criContainersStep(name string) Step {
+	return Step{
+		Name: name,
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			ids, err := gatherssh.Output(ctx, host.Client, "sudo crictl ps --all --quiet")
+			if err != nil {
+				return fmt.Errorf("failed to list containers: %w", err)
+			}
+			for _, id := range strings.Fields(string(ids)) {
+				status, err := gatherssh.Output(ctx, host.Client, fmt.Sprintf("sudo crictl ps --all --id %s --verbose", id))
+				if err != nil {
+					return fmt.Errorf("failed to look up container %s: %w", id, err)
+				}
+				name := id
+				if match := crictlNameRE.FindSubmatch(status); match != nil {
+					name = fmt.Sprintf("%s-%s", strings.TrimSpace(string(match[1])), id)
+				}
+				if err := writeOutput(ctx, host, fmt.Sprintf("sudo crictl logs %s 2>&1", id), filepath.Join(dir, name+".log")); err != nil {
+					return err
+				}
+				if err := writeOutput(ctx, host, fmt.Sprintf("sudo crictl inspect %s 2>&1", id), filepath.Join(dir, name+".inspect")); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// writeOutput runs command on host and writes its output to file.
+func writeOutput(ctx context.Context, host *Host, command, file string) error {
+	out, err := gatherssh.Output(ctx, host.Client, command)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, out, 0644)
+}
@@ -0,0 +1,103 @@
+package gather
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/openshift/installer/pkg/asset"
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// optOpenShiftDir is where the bootstrap host stages the assets it renders
+// and applies to the cluster.
+const optOpenShiftDir = "/opt/openshift"
+
+// optOpenShiftCommand archives optOpenShiftDir to standard output, leaving
+// out private keys and kubeconfigs.
+const optOpenShiftCommand = `sudo tar --create --gzip --directory=` + optOpenShiftDir + ` --exclude='*.key' --exclude='*kubeconfig*' .`
+
+// OptOpenShiftStep returns a step that copies the assets staged under
+// /opt/openshift on the bootstrap host into the bundle. Private keys and
+// kubeconfigs are left out, the data of Secret manifests is redacted and
+// other files that hold credentials are skipped.
+func OptOpenShiftStep() Step {
+	return Step{
+		Name: "opt-openshift",
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			pr, pw := io.Pipe()
+			streamErr := make(chan error, 1)
+			go func() {
+				err := gatherssh.Stream(ctx, host.Client, optOpenShiftCommand, pw)
+				pw.CloseWithError(err)
+				streamErr <- err
+			}()
+
+			skipped, err := extractRedacted(pr, dir)
+			pr.CloseWithError(errors.New("extraction stopped"))
+			if serr := <-streamErr; serr != nil && err == nil {
+				return fmt.Errorf("failed to archive %s: %w", optOpenShiftDir, serr)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to extract %s: %w", optOpenShiftDir, err)
+			}
+			if skipped > 0 {
+				summary.Addf("opt-openshift: skipped %d files that may hold credentials", skipped)
+			}
+			return nil
+		},
+	}
+}
+
+// extractRedacted extracts the regular files of the gzipped tarball read
+// from r into dir, redacting them like the rendered manifests. It returns
+// the number of files skipped because they may hold credentials.
+func extractRedacted(r io.Reader, dir string) (int, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, err
+	}
+	defer gr.Close()
+
+	skipped := 0
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return skipped, nil
+		}
+		if err != nil {
+			return skipped, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		// Keep every member under dir, whatever its name.
+		name := filepath.Clean(string(filepath.Separator) + hdr.Name)
+		raw, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return skipped, err
+		}
+		data, err := redactManifest(&asset.File{Filename: name, Data: raw})
+		if err != nil {
+			return skipped, fmt.Errorf("failed to redact %s: %w", hdr.Name, err)
+		}
+		if data == nil {
+			skipped++
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return skipped, err
+		}
+		if err := ioutil.WriteFile(path, data, 0600); err != nil {
+			return skipped, err
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package gather
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Plan is what a gather resolved to collect, printed by --dry-run instead
+// of connecting to the hosts.
+type Plan struct {
+	// Platform is the name of the platform of the cluster, or empty when
+	// the install config was not read.
+	Platform string
+
+	// Bootstrap is the address of the bootstrap host, with its SSH port. It
+	// is empty when the bootstrap host is gone.
+	Bootstrap string
+	Masters   []string
+	Hosts     []Target
+
+	SSHUser string
+
+	// SSHKeys are the paths of the keys to authenticate with. When empty,
+	// the SSH agent and the default keys are used.
+	SSHKeys []string
+
+	// Command is the remote command run to collect the control plane, or
+	// empty when none is run.
+	Command string
+}
+
+// Print writes the plan to w as one "key: value" line per setting, with a
+// line for each of the hosts and keys, so it can be grepped.
+func (p *Plan) Print(w io.Writer) error {
+	lines := []string{
+		"platform: " + valueOrNone(p.Platform),
+		"bootstrap: " + valueOrNone(p.Bootstrap),
+	}
+	for _, master := range p.Masters {
+		lines = append(lines, "master: "+master)
+	}
+	for _, host := range p.Hosts {
+		lines = append(lines, "host: "+host.String())
+	}
+	lines = append(lines, "ssh-user: "+p.SSHUser)
+	if len(p.SSHKeys) == 0 {
+		lines = append(lines, "ssh-key: agent and default keys")
+	}
+	for _, key := range p.SSHKeys {
+		lines = append(lines, "ssh-key: "+key)
+	}
+	lines = append(lines, "command: "+valueOrNone(p.Command))
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
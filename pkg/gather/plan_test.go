@@ -0,0 +1,53 @@
+package gather
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanPrint(t *testing.T) {
+	cases := []struct {
+		name     string
+		plan     Plan
+		expected string
+	}{{
+		name: "bootstrap",
+		plan: Plan{
+			Platform:  "aws",
+			Bootstrap: "3.80.1.20:22",
+			Masters:   []string{"10.0.1.10", "10.0.2.10"},
+			Hosts:     []Target{{Role: "etcd", Address: "10.0.3.10"}},
+			SSHUser:   "core",
+			SSHKeys:   []string{"/home/user/.ssh/cluster"},
+			Command:   "/usr/local/bin/installer-gather.sh 10.0.1.10 10.0.2.10",
+		},
+		expected: `platform: aws
+bootstrap: 3.80.1.20:22
+master: 10.0.1.10
+master: 10.0.2.10
+host: etcd=10.0.3.10
+ssh-user: core
+ssh-key: /home/user/.ssh/cluster
+command: /usr/local/bin/installer-gather.sh 10.0.1.10 10.0.2.10
+`,
+	}, {
+		name: "no bootstrap or script",
+		plan: Plan{Masters: []string{"10.0.1.10"}, SSHUser: "core"},
+		expected: `platform: none
+bootstrap: none
+master: 10.0.1.10
+ssh-user: core
+ssh-key: agent and default keys
+command: none
+`,
+	}}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.NoError(t, tc.plan.Print(&buf))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
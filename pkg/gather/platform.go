@@ -0,0 +1,60 @@
+package gather
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// providerPlatforms maps the prefix of the types of the resources of each
+// terraform provider which creates the hosts of a platform to the platform.
+// The hosts of the UPI terraform for bare metal are Packet devices, on the
+// none platform.
+var providerPlatforms = map[string]string{
+	"aws_":       "aws",
+	"azurerm_":   "azure",
+	"google_":    "gcp",
+	"libvirt_":   "libvirt",
+	"openstack_": "openstack",
+	"packet_":    "none",
+	"vsphere_":   "vsphere",
+}
+
+// StatePlatforms returns the platforms whose terraform providers created the
+// resources of resourceTypes, sorted. Resources of the providers which are
+// used on every platform, like ignition or local, are ignored.
+func StatePlatforms(resourceTypes []string) []string {
+	found := map[string]bool{}
+	for _, resourceType := range resourceTypes {
+		for prefix, platform := range providerPlatforms {
+			if strings.HasPrefix(resourceType, prefix) {
+				found[platform] = true
+			}
+		}
+	}
+	platforms := make([]string, 0, len(found))
+	for platform := range found {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
+// CheckStatePlatform returns an error matching ErrPlatformMismatch when the
+// terraform state with resources of resourceTypes was created for another
+// platform than platform, the one of the install config. A state may hold
+// the resources of several platforms, like the AWS Route 53 records of
+// vSphere clusters, and one without the resources of any platform is not
+// checked.
+func CheckStatePlatform(platform string, resourceTypes []string) error {
+	platforms := StatePlatforms(resourceTypes)
+	if len(platforms) == 0 {
+		return nil
+	}
+	for _, p := range platforms {
+		if p == platform {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: the install config is for %s, but the terraform state holds resources of %s, so the host addresses cannot be read from it", ErrPlatformMismatch, platform, strings.Join(platforms, " and "))
+}
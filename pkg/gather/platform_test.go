@@ -0,0 +1,65 @@
+package gather
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckStatePlatform(t *testing.T) {
+	cases := []struct {
+		name          string
+		platform      string
+		resourceTypes []string
+		err           string
+	}{
+		{
+			name:          "match",
+			platform:      "aws",
+			resourceTypes: []string{"ignition_config", "aws_instance", "aws_lb"},
+		},
+		{
+			name:          "mismatch",
+			platform:      "azure",
+			resourceTypes: []string{"ignition_config", "aws_instance", "aws_lb"},
+			err:           "terraform state platform mismatch: the install config is for azure, but the terraform state holds resources of aws, so the host addresses cannot be read from it",
+		},
+		{
+			name:          "several platforms",
+			platform:      "vsphere",
+			resourceTypes: []string{"vsphere_virtual_machine", "aws_route53_record"},
+		},
+		{
+			name:          "several platforms mismatch",
+			platform:      "openstack",
+			resourceTypes: []string{"vsphere_virtual_machine", "aws_route53_record"},
+			err:           "terraform state platform mismatch: the install config is for openstack, but the terraform state holds resources of aws and vsphere, so the host addresses cannot be read from it",
+		},
+		{
+			name:          "bare metal",
+			platform:      "none",
+			resourceTypes: []string{"matchbox_profile", "packet_device", "aws_route53_record"},
+		},
+		{
+			name:          "no platform resources",
+			platform:      "libvirt",
+			resourceTypes: []string{"ignition_config", "local_file"},
+		},
+		{
+			name:     "empty state",
+			platform: "aws",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckStatePlatform(tc.platform, tc.resourceTypes)
+			if tc.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.err)
+			assert.True(t, errors.Is(err, ErrPlatformMismatch))
+		})
+	}
+}
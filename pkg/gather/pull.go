@@ -0,0 +1,40 @@
+package gather
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// pullPrivileged downloads remotePath, which may only be readable by root,
+// from host to localPath. When maxBytes is positive, only the last maxBytes
+// of the file are downloaded.
+func pullPrivileged(ctx context.Context, host *Host, remotePath, localPath string, maxBytes int64) error {
+	read := fmt.Sprintf("sudo cat '%s'", remotePath)
+	if maxBytes > 0 {
+		read = fmt.Sprintf("sudo tail --bytes=%d '%s'", maxBytes, remotePath)
+	}
+	out, err := gatherssh.Output(ctx, host.Client, fmt.Sprintf(`tmp="$(mktemp)" && %s > "${tmp}" && echo "${tmp}"`, read))
+	if err != nil {
+		return fmt.Errorf("failed to copy %s: %w", remotePath, err)
+	}
+	tmp := strings.TrimSpace(string(out))
+	defer gatherssh.Output(gatherssh.CleanupContext(ctx), host.Client, fmt.Sprintf("rm -f '%s'", tmp))
+
+	return gatherssh.PullFileToContext(ctx, host.Client, tmp, localPath)
+}
+
+// firstExisting returns the first of paths which exists on host, or an
+// empty string if none do.
+func firstExisting(ctx context.Context, host *Host, paths ...string) (string, error) {
+	for _, path := range paths {
+		if _, err := gatherssh.Output(ctx, host.Client, fmt.Sprintf("sudo test -e '%s'", path)); err == nil {
+			return path, nil
+		} else if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+	}
+	return "", nil
+}
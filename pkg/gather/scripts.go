@@ -0,0 +1,46 @@
+package gather
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/openshift/installer/data"
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// gatherScriptsDir is where the bootstrap host has the gather scripts, both
+// in the installer data and on the host.
+const gatherScriptsDir = "/usr/local/bin"
+
+// gatherScripts are installer-gather.sh and the script it runs on each
+// control plane host, which only the bootstrap host is created with.
+var gatherScripts = []string{"installer-gather.sh", "installer-masters-gather.sh"}
+
+// InstallGatherScripts copies the gather scripts of the installer to host,
+// so that installer-gather.sh can be run from a control plane host once the
+// bootstrap host is gone. The scripts collect what they can, as the
+// bootstrap assets and kubeconfig they read are missing there.
+func InstallGatherScripts(ctx context.Context, host *Host) error {
+	for _, name := range gatherScripts {
+		if err := installGatherScript(ctx, host, name); err != nil {
+			return fmt.Errorf("failed to install %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func installGatherScript(ctx context.Context, host *Host, name string) error {
+	file, err := data.Assets.Open(path.Join("bootstrap/files", gatherScriptsDir, name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	tmp := path.Join("/tmp", name)
+	if err := gatherssh.PushFile(host.Client, file, tmp, 0755); err != nil {
+		return err
+	}
+	_, err = gatherssh.Output(ctx, host.Client, fmt.Sprintf("sudo install -m 0755 %s %s && rm -f %s", tmp, path.Join(gatherScriptsDir, name), tmp))
+	return err
+}
@@ -0,0 +1,35 @@
+package gather
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SerialConsoleStep returns a step that stores the serial console output of
+// each host returned by fetch as <name>.log, for hosts which never booted
+// far enough to accept SSH. fetch is called with the context of the step.
+// The host is not used. The output of the hosts fetch returns is stored
+// even when it also returns an error.
+func SerialConsoleStep(fetch func(context.Context) (map[string][]byte, error)) Step {
+	return Step{
+		Name: "serial-console",
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			outputs, fetchErr := fetch(ctx)
+			names := make([]string, 0, len(outputs))
+			for name, output := range outputs {
+				if err := ioutil.WriteFile(filepath.Join(dir, name+".log"), output, 0644); err != nil {
+					return err
+				}
+				names = append(names, name)
+			}
+			if len(names) > 0 {
+				sort.Strings(names)
+				summary.Addf("serial-console: collected %d hosts (%s)", len(names), strings.Join(names, ", "))
+			}
+			return fetchErr
+		},
+	}
+}
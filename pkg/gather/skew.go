@@ -0,0 +1,54 @@
+package gather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// releaseVersionCommand prints the version of the release image bootkube.sh
+// was rendered with on the bootstrap host.
+const releaseVersionCommand = `release="$(sed -n 's/^[[:space:]]*release="\(.*\)"$/\1/p' /usr/local/bin/bootkube.sh | head -n 1)"
+test -n "${release}" || { echo 'no release image in bootkube.sh' >&2; exit 1; }
+sudo podman inspect --type=image --format '{{index .Labels "io.openshift.release"}}' "${release}"`
+
+var minorVersionRE = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+// ReleaseVersion returns the version of the release the bootstrap host was
+// configured with.
+func ReleaseVersion(ctx context.Context, host *Host) (string, error) {
+	out, err := gatherssh.Output(ctx, host.Client, releaseVersionCommand)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the release version: %w", err)
+	}
+	version := strings.TrimSpace(string(out))
+	if version == "" || version == "<no value>" {
+		return "", errors.New("the release image has no version label")
+	}
+	return version, nil
+}
+
+// VersionSkew returns true if the installer and cluster versions have a
+// different major or minor version, in which case installer-gather.sh on
+// the cluster hosts may not accept the arguments the installer passes. It
+// returns false if either version cannot be parsed.
+func VersionSkew(installer, cluster string) bool {
+	i := minorVersionRE.FindStringSubmatch(installer)
+	c := minorVersionRE.FindStringSubmatch(cluster)
+	if i == nil || c == nil {
+		return false
+	}
+	for n := 1; n <= 2; n++ {
+		iv, _ := strconv.Atoi(i[n])
+		cv, _ := strconv.Atoi(c[n])
+		if iv != cv {
+			return true
+		}
+	}
+	return false
+}
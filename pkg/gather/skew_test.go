@@ -0,0 +1,27 @@
+package gather
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionSkew(t *testing.T) {
+	cases := []struct {
+		installer string
+		cluster   string
+		skew      bool
+	}{
+		{installer: "v4.2.0", cluster: "4.2.0-0.nightly-2019-08-15-073735", skew: false},
+		{installer: "v4.2.3", cluster: "4.2.0", skew: false},
+		{installer: "v4.3.0", cluster: "4.2.0", skew: true},
+		{installer: "v5.2.0", cluster: "4.2.0", skew: true},
+		{installer: "unreleased-master-1514-gd2d7fd5", cluster: "4.2.0", skew: false},
+		{installer: "was not built correctly", cluster: "4.2.0", skew: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.installer+"/"+tc.cluster, func(t *testing.T) {
+			assert.Equal(t, tc.skew, VersionSkew(tc.installer, tc.cluster))
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package gather
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SplitFile splits the file at path into parts of at most size bytes, in
+// the same directory, named path.partNN with the parts numbered from 00,
+// and removes the file. It returns the paths of the parts in order. A file
+// no larger than size is left in place, and returned alone.
+func SplitFile(path string, size int64) ([]string, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid part size %d", size)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() <= size {
+		return []string{path}, nil
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	count := (info.Size() + size - 1) / size
+	width := len(strconv.FormatInt(count-1, 10))
+	if width < 2 {
+		width = 2
+	}
+	parts := make([]string, 0, count)
+	for i := int64(0); i < count; i++ {
+		part := fmt.Sprintf("%s.part%0*d", path, width, i)
+		parts = append(parts, part)
+		if err := writePart(part, in, size); err != nil {
+			for _, p := range parts {
+				os.Remove(p)
+			}
+			return nil, fmt.Errorf("failed to write %s: %w", part, err)
+		}
+	}
+	in.Close()
+	return parts, os.Remove(path)
+}
+
+func writePart(path string, r io.Reader, size int64) (err error) {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	_, err = io.CopyN(out, r, size)
+	if err == io.EOF {
+		err = nil
+	}
+	return err
+}
+
+// ReassembleNote returns the instructions for reassembling the file at path
+// from its parts.
+func ReassembleNote(path string, parts []string) string {
+	names := make([]string, 0, len(parts))
+	for _, part := range parts {
+		names = append(names, filepath.Base(part))
+	}
+	return fmt.Sprintf(`%s was split into %d parts. To reassemble it, concatenate the parts in order:
+
+    cat %s > %s
+`, filepath.Base(path), len(parts), strings.Join(names, " "), filepath.Base(path))
+}
@@ -0,0 +1,66 @@
+package gather
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitFile(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     string
+		size     int64
+		expected []string
+	}{
+		{name: "smaller", data: "bundle", size: 10, expected: []string{"bundle"}},
+		{name: "equal", data: "bundle", size: 6, expected: []string{"bundle"}},
+		{name: "exact", data: "bundle", size: 2, expected: []string{"bu", "nd", "le"}},
+		{name: "remainder", data: "bundle", size: 4, expected: []string{"bund", "le"}},
+		{name: "many", data: "abcdefghijkl", size: 1, expected: []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "split")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "log-bundle-20201015070000.tar.gz")
+			if err := ioutil.WriteFile(path, []byte(tc.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			parts, err := SplitFile(path, tc.size)
+			if !assert.NoError(t, err) {
+				return
+			}
+			var contents []string
+			for _, part := range parts {
+				data, err := ioutil.ReadFile(part)
+				if !assert.NoError(t, err) {
+					return
+				}
+				contents = append(contents, string(data))
+			}
+			assert.Equal(t, tc.expected, contents)
+			if len(parts) == 1 {
+				assert.Equal(t, []string{path}, parts)
+				return
+			}
+			assert.Equal(t, path+".part00", parts[0])
+			assert.Equal(t, path+".part01", parts[1])
+			_, err = os.Stat(path)
+			assert.True(t, os.IsNotExist(err))
+		})
+	}
+}
+
+func TestReassembleNote(t *testing.T) {
+	note := ReassembleNote("/tmp/log-bundle.tar.gz", []string{"/tmp/log-bundle.tar.gz.part00", "/tmp/log-bundle.tar.gz.part01"})
+	assert.Contains(t, note, "log-bundle.tar.gz was split into 2 parts.")
+	assert.Contains(t, note, "cat log-bundle.tar.gz.part00 log-bundle.tar.gz.part01 > log-bundle.tar.gz\n")
+}
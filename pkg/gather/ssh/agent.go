@@ -1,39 +1,115 @@
 package ssh
 
 import (
-	"github.com/pkg/errors"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/ssh/agent"
 
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 )
 
-// newAgent initializes an SSH Agent with the keys.
-// If no keys are provided, it loads all the keys from the user's environment.
-func newAgent(keyPaths []string) (agent.Agent, error) {
-	keys, err := loadKeys(keyPaths)
-	if err != nil {
-		return nil, err
+// defaultKeyFiles are the private keys in ~/.ssh which are used when no
+// keys are given and no SSH agent holds any.
+var defaultKeyFiles = []string{"id_rsa", "id_ecdsa", "id_ed25519"}
+
+// newAgent initializes an SSH Agent with the keys, and returns it with the
+// sources of its keys, for reporting authentication failures.
+// If no keys are provided, it uses the agent at SSH_AUTH_SOCK when it holds
+// any keys, and else loads the default keys from ~/.ssh. Encrypted keys are
+// decrypted with passphrases.
+func newAgent(keyPaths []string, passphrases *Passphrases) (agent.Agent, []string, error) {
+	if len(keyPaths) > 0 {
+		keys, err := LoadPrivateSSHKeys(keyPaths, passphrases)
+		if err != nil {
+			return nil, keyPaths, err
+		}
+		ag, err := keyringAgent(keys, keyPaths)
+		return ag, keyPaths, err
+	}
+
+	var tried []string
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		source := fmt.Sprintf("the SSH agent at %s", sock)
+		tried = append(tried, source)
+		ag, err := socketAgent(sock)
+		if err == nil {
+			logrus.Debugf("Authenticating with the keys of %s", source)
+			return ag, []string{source}, nil
+		}
+		logrus.Debugf("Not using %s: %v", source, err)
+	}
+
+	dir := filepath.Join(os.Getenv("HOME"), ".ssh")
+	var paths []string
+	for _, name := range defaultKeyFiles {
+		path := filepath.Join(dir, name)
+		tried = append(tried, path)
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
 	}
+	// Like ssh, keys which cannot be loaded are skipped as long as one
+	// can be.
+	keys, err := LoadPrivateSSHKeys(paths, passphrases)
 	if len(keys) == 0 {
-		return nil, errors.New("no keys found for SSH agent")
+		if err != nil {
+			return nil, tried, fmt.Errorf("no usable SSH keys found, tried %s: %w", strings.Join(tried, ", "), err)
+		}
+		return nil, tried, fmt.Errorf("no SSH keys found, tried %s", strings.Join(tried, ", "))
 	}
+	if err != nil {
+		logrus.Debugf("Skipping some SSH keys: %v", err)
+	}
+	ag, err := keyringAgent(keys, paths)
+	return ag, paths, err
+}
 
+// keyringAgent returns an agent holding keys, loaded from paths.
+func keyringAgent(keys []interface{}, paths []string) (agent.Agent, error) {
 	ag := agent.NewKeyring()
 	var errs []error
 	for idx := range keys {
 		if err := ag.Add(agent.AddedKey{PrivateKey: keys[idx]}); err != nil {
-			errs = append(errs, errors.Wrap(err, "failed to add key to agent"))
+			errs = append(errs, fmt.Errorf("failed to add key to agent: %w", err))
 		}
 	}
 	if agg := utilerrors.NewAggregate(errs); agg != nil {
 		return nil, agg
 	}
+	logrus.Debugf("Authenticating with the keys in %s", strings.Join(paths, ", "))
 	return ag, nil
 }
 
-func loadKeys(paths []string) ([]interface{}, error) {
-	if len(paths) > 0 {
-		return LoadPrivateSSHKeys(paths)
+// closingAgent is an agent whose connection must be closed once it is no
+// longer used.
+type closingAgent struct {
+	agent.Agent
+	io.Closer
+}
+
+// socketAgent connects to the SSH agent listening on sock, and fails if it
+// holds no keys.
+func socketAgent(sock string) (agent.Agent, error) {
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, err
+	}
+	ag := agent.NewClient(conn)
+	keys, err := ag.List()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if len(keys) == 0 {
+		conn.Close()
+		return nil, errors.New("the agent holds no keys")
 	}
-	return defaultPrivateSSHKeys()
+	return &closingAgent{Agent: ag, Closer: conn}, nil
 }
@@ -0,0 +1,128 @@
+package ssh
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func writeKey(t *testing.T, path string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func setenv(t *testing.T, key, value string) func() {
+	old, ok := os.LookupEnv(key)
+	os.Setenv(key, value)
+	return func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func TestNewAgent(t *testing.T) {
+	home, err := ioutil.TempDir("", "agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	sshDir := filepath.Join(home, ".ssh")
+	if err := os.Mkdir(sshDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	defer setenv(t, "HOME", home)()
+	defer setenv(t, "SSH_AUTH_SOCK", "")()
+
+	t.Run("no keys", func(t *testing.T) {
+		_, _, err := newAgent(nil, nil)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), filepath.Join(sshDir, "id_rsa"))
+			assert.Contains(t, err.Error(), filepath.Join(sshDir, "id_ed25519"))
+		}
+	})
+
+	writeKey(t, filepath.Join(sshDir, "id_ecdsa"))
+	if err := ioutil.WriteFile(filepath.Join(sshDir, "id_ed25519"), []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	writeKey(t, filepath.Join(sshDir, "other"))
+
+	t.Run("default keys", func(t *testing.T) {
+		ag, sources, err := newAgent(nil, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []string{filepath.Join(sshDir, "id_ecdsa"), filepath.Join(sshDir, "id_ed25519")}, sources)
+		keys, err := ag.List()
+		assert.NoError(t, err)
+		assert.Len(t, keys, 1)
+	})
+
+	t.Run("explicit keys", func(t *testing.T) {
+		_, sources, err := newAgent([]string{filepath.Join(sshDir, "other")}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(sshDir, "other")}, sources)
+
+		_, _, err = newAgent([]string{filepath.Join(sshDir, "id_ed25519")}, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("running agent", func(t *testing.T) {
+		sock := filepath.Join(home, "agent.sock")
+		listener, err := net.Listen("unix", sock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer listener.Close()
+		keyring := agent.NewKeyring()
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := keyring.Add(agent.AddedKey{PrivateKey: key}); err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				go agent.ServeAgent(keyring, conn)
+			}
+		}()
+		defer setenv(t, "SSH_AUTH_SOCK", sock)()
+
+		ag, sources, err := newAgent(nil, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer ag.(*closingAgent).Close()
+		assert.Equal(t, []string{"the SSH agent at " + sock}, sources)
+		keys, err := ag.List()
+		assert.NoError(t, err)
+		assert.Len(t, keys, 1)
+	})
+}
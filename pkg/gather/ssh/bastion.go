@@ -0,0 +1,66 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Bastion is a jump host that SSH connections are tunneled through, for
+// hosts which are not reachable directly, like those of private clusters.
+type Bastion struct {
+	// User is the user to log in to the bastion as.
+	User string
+
+	// Address is the host:port of the bastion.
+	Address string
+}
+
+// ParseBastion parses a bastion given as [user@]host[:port]. The user
+// defaults to defaultUser and the port to 22.
+func ParseBastion(value, defaultUser string) (*Bastion, error) {
+	user, hostPort := defaultUser, value
+	if i := strings.LastIndex(value, "@"); i >= 0 {
+		user, hostPort = value[:i], value[i+1:]
+	}
+	if user == "" {
+		return nil, errors.New("the bastion user is empty")
+	}
+	host, port, err := net.SplitHostPort(hostPort)
+	if addrErr, ok := err.(*net.AddrError); ok && addrErr.Err == "missing port in address" {
+		host, port, err = strings.TrimSuffix(strings.TrimPrefix(hostPort, "["), "]"), "22", nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid bastion address: %w", err)
+	}
+	if host == "" {
+		return nil, errors.New("the bastion host is empty")
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return nil, fmt.Errorf("invalid bastion port %q", port)
+	}
+	return &Bastion{User: user, Address: net.JoinHostPort(host, port)}, nil
+}
+
+// Dialer connects to the bastion through dial with the keys, decrypted with
+// passphrases, verifying its host key with hostKeyCallback, and returns a Dialer that opens every
+// connection from the bastion over that single SSH connection, along with
+// the client connected to the bastion, which must be closed when the
+// Dialer is no longer used.
+func (b *Bastion) Dialer(keys []string, passphrases *Passphrases, hostKeyCallback ssh.HostKeyCallback, dial Dialer) (Dialer, *ssh.Client, error) {
+	client, err := newClient(b.User, b.Address, keys, passphrases, dial, hostKeyCallback)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to bastion %s: %w", b.Address, err)
+	}
+	return func(network, address string) (net.Conn, error) {
+		conn, err := client.Dial(network, address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to tunnel to %s through bastion %s: %w", address, b.Address, err)
+		}
+		return conn, nil
+	}, client, nil
+}
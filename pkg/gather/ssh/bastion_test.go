@@ -0,0 +1,35 @@
+package ssh
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBastion(t *testing.T) {
+	cases := []struct {
+		value   string
+		bastion *Bastion
+		err     bool
+	}{
+		{value: "bastion.example.com", bastion: &Bastion{User: "core", Address: "bastion.example.com:22"}},
+		{value: "ec2-user@bastion.example.com:2222", bastion: &Bastion{User: "ec2-user", Address: "bastion.example.com:2222"}},
+		{value: "admin@10.0.0.1", bastion: &Bastion{User: "admin", Address: "10.0.0.1:22"}},
+		{value: "admin@[fd00::1]:2222", bastion: &Bastion{User: "admin", Address: "[fd00::1]:2222"}},
+		{value: "[fd00::1]", bastion: &Bastion{User: "core", Address: "[fd00::1]:22"}},
+		{value: "@bastion.example.com", err: true},
+		{value: "admin@", err: true},
+		{value: "admin@bastion.example.com:ssh", err: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			bastion, err := ParseBastion(tc.value, "core")
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.bastion, bastion)
+		})
+	}
+}
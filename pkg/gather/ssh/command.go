@@ -0,0 +1,138 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// proxyCommandOutputLines is the number of the last lines of the standard
+// error of a proxy command included in the errors of its connection.
+const proxyCommandOutputLines = 5
+
+// CommandDialer returns a Dialer that, like the ProxyCommand of OpenSSH,
+// runs command with /bin/sh and uses its standard input and output as the
+// connection. %h and %p in command are replaced with the host and port of
+// the address dialed, and %% with %. The command is killed when the
+// connection is closed, and the end of its standard error is included in
+// the errors reading from the connection once it exits.
+func CommandDialer(command string) Dialer {
+	return func(network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		expanded := expandProxyCommand(command, host, port)
+		cmd := exec.Command("/bin/sh", "-c", expanded)
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+		// The standard output is a pipe of its own rather than the one of
+		// cmd, which Wait closes, so that the command is waited for
+		// while the connection is read from.
+		stdout, stdoutWriter, err := os.Pipe()
+		if err != nil {
+			stdin.Close()
+			return nil, err
+		}
+		cmd.Stdout = stdoutWriter
+		stderr := &tailBuffer{max: runOutputBytes}
+		cmd.Stderr = stderr
+		err = cmd.Start()
+		stdoutWriter.Close()
+		if err != nil {
+			stdin.Close()
+			stdout.Close()
+			return nil, fmt.Errorf("failed to start the proxy command %q: %w", expanded, err)
+		}
+		logrus.Debugf("Connecting to %s through the proxy command %q", address, expanded)
+		conn := &commandConn{cmd: cmd, command: expanded, address: address, stdin: stdin, stdout: stdout, stderr: stderr, exited: make(chan struct{})}
+		go func() {
+			cmd.Wait()
+			close(conn.exited)
+		}()
+		return conn, nil
+	}
+}
+
+// expandProxyCommand replaces the tokens of command with host and port.
+func expandProxyCommand(command, host, port string) string {
+	return strings.NewReplacer("%%", "%", "%h", host, "%p", port).Replace(command)
+}
+
+// commandConn is a connection over the standard input and output of a
+// proxy command.
+type commandConn struct {
+	cmd     *exec.Cmd
+	command string
+	address string
+	stdin   io.WriteCloser
+	stdout  io.ReadCloser
+	stderr  *tailBuffer
+
+	// exited is closed once the command exited and its standard error
+	// was read.
+	exited chan struct{}
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func (c *commandConn) Read(p []byte) (int, error) {
+	n, err := c.stdout.Read(p)
+	if err != nil {
+		// The standard output is closed as the command exits, shortly
+		// before its standard error is read.
+		select {
+		case <-c.exited:
+		case <-time.After(time.Second):
+		}
+		if output := c.stderr.lines(proxyCommandOutputLines); output != "" {
+			err = fmt.Errorf("the proxy command %q ended with %q: %w", c.command, output, err)
+		}
+	}
+	return n, err
+}
+
+func (c *commandConn) Write(p []byte) (int, error) {
+	return c.stdin.Write(p)
+}
+
+// Close closes the standard input of the command and kills it.
+func (c *commandConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.closeErr = c.stdin.Close()
+		c.cmd.Process.Kill()
+		<-c.exited
+		c.stdout.Close()
+	})
+	return c.closeErr
+}
+
+func (c *commandConn) LocalAddr() net.Addr {
+	return commandAddr("")
+}
+
+// RemoteAddr returns the address dialed, so that the client reports it.
+func (c *commandConn) RemoteAddr() net.Addr {
+	return commandAddr(c.address)
+}
+
+// Deadlines are not supported by the pipes of the command.
+func (c *commandConn) SetDeadline(time.Time) error      { return nil }
+func (c *commandConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *commandConn) SetWriteDeadline(time.Time) error { return nil }
+
+// commandAddr is the address of a connection over a proxy command.
+type commandAddr string
+
+func (a commandAddr) Network() string { return "proxy-command" }
+func (a commandAddr) String() string  { return string(a) }
@@ -0,0 +1,40 @@
+package ssh
+
+import (
+	"bufio"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandProxyCommand(t *testing.T) {
+	assert.Equal(t, "cloudflared access ssh --hostname 10.0.0.5:22 --format 100%h",
+		expandProxyCommand("cloudflared access ssh --hostname %h:%p --format 100%%h", "10.0.0.5", "22"))
+}
+
+func TestCommandDialer(t *testing.T) {
+	conn, err := CommandDialer("echo %h %p; cat")("tcp", "10.0.0.5:22")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "10.0.0.5:22", conn.RemoteAddr().String())
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.5 22\n", line)
+	_, err = conn.Write([]byte("SSH-2.0-OpenSSH_7.8\n"))
+	assert.NoError(t, err)
+	line, err = r.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "SSH-2.0-OpenSSH_7.8\n", line)
+	assert.NoError(t, conn.Close())
+
+	conn, err = CommandDialer("echo 'connection refused by %h' >&2; exit 1")("tcp", "10.0.0.5:22")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	_, err = ioutil.ReadAll(conn)
+	assert.EqualError(t, err, `the proxy command "echo 'connection refused by 10.0.0.5' >&2; exit 1" ended with "connection refused by 10.0.0.5": EOF`)
+}
@@ -0,0 +1,52 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrAuthFailed matches errors from hosts which rejected every key.
+	ErrAuthFailed = errors.New("SSH authentication failed")
+
+	// ErrPullFailed matches errors downloading a file from a host.
+	ErrPullFailed = errors.New("failed to pull file")
+)
+
+// Error is an error from an SSH operation against a host. It matches
+// ErrAuthFailed or ErrPullFailed with errors.Is, depending on the failed
+// operation, as well as the errors Err matches.
+type Error struct {
+	// Op describes the failed operation, like "authenticate".
+	Op string
+
+	// Address is the address of the host.
+	Address string
+
+	// Err is the underlying error.
+	Err error
+
+	kind error
+}
+
+func authError(address string, err error) *Error {
+	return &Error{Op: "authenticate", Address: address, Err: err, kind: ErrAuthFailed}
+}
+
+func pullError(address, path string, err error) *Error {
+	return &Error{Op: fmt.Sprintf("pull %s", path), Address: address, Err: err, kind: ErrPullFailed}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("failed to %s on %s: %v", e.Op, e.Address, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is returns true if target is the sentinel error of the failed operation.
+func (e *Error) Is(target error) bool {
+	return e.kind != nil && target == e.kind
+}
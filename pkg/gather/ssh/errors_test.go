@@ -0,0 +1,47 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorIs(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		matches  []error
+		excludes []error
+	}{
+		{
+			name:     "auth",
+			err:      authError("10.0.0.1:22", errors.New("ssh: unable to authenticate")),
+			matches:  []error{ErrAuthFailed},
+			excludes: []error{ErrPullFailed},
+		},
+		{
+			name:     "pull",
+			err:      pullError("10.0.0.1:22", "/home/core/log-bundle.tar.gz", os.ErrNotExist),
+			matches:  []error{ErrPullFailed, os.ErrNotExist},
+			excludes: []error{ErrAuthFailed},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			wrapped := fmt.Errorf("failed to gather: %w", tc.err)
+			for _, target := range tc.matches {
+				assert.True(t, errors.Is(wrapped, target), "expected a match for %v", target)
+			}
+			for _, target := range tc.excludes {
+				assert.False(t, errors.Is(wrapped, target), "expected no match for %v", target)
+			}
+			var sshErr *Error
+			if assert.True(t, errors.As(wrapped, &sshErr)) {
+				assert.Equal(t, "10.0.0.1:22", sshErr.Address)
+			}
+		})
+	}
+}
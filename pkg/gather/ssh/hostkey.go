@@ -0,0 +1,90 @@
+package ssh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// HostCertificateCallback returns a host key callback that only accepts
+// host certificates signed by the SSH certificate authority whose public
+// key, in authorized_keys format, is stored in caPath. Plain host keys and
+// certificates signed by other authorities are rejected.
+func HostCertificateCallback(caPath string) (ssh.HostKeyCallback, error) {
+	data, err := ioutil.ReadFile(caPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", caPath, err)
+	}
+	ca, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH certificate authority from %q: %w", caPath, err)
+	}
+
+	checker := &ssh.CertChecker{
+		IsHostAuthority: func(auth ssh.PublicKey, _ string) bool {
+			return bytes.Equal(auth.Marshal(), ca.Marshal())
+		},
+	}
+	return checker.CheckHostKey, nil
+}
+
+// KnownHostsCallback returns a host key callback that verifies the host
+// keys against the known_hosts file at path, which is created if it does
+// not exist. Hosts which are not in the file yet are added to it, like
+// ssh does with StrictHostKeyChecking=accept-new, and hosts whose key
+// differs from the one in the file are rejected.
+func KnownHostsCallback(path string) (ssh.HostKeyCallback, error) {
+	file, err := os.OpenFile(path, os.O_RDONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %q: %w", path, err)
+	}
+	file.Close()
+	known, err := knownhosts.New(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", path, err)
+	}
+
+	var mu sync.Mutex
+	added := map[string]ssh.PublicKey{}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := known(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if err == nil || !errors.As(err, &keyErr) {
+			return err
+		}
+		if len(keyErr.Want) > 0 {
+			return fmt.Errorf("the host key of %s does not match the one in %s, the host was replaced or the connection is intercepted: %w", hostname, path, err)
+		}
+
+		// The known hosts are only read once, so hosts added since are
+		// checked against the keys they were added with.
+		mu.Lock()
+		defer mu.Unlock()
+		address := knownhosts.Normalize(hostname)
+		if want, ok := added[address]; ok {
+			if !bytes.Equal(want.Marshal(), key.Marshal()) {
+				return fmt.Errorf("the host key of %s changed since it was added to %s", hostname, path)
+			}
+			return nil
+		}
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to add the host key of %s to %s: %w", hostname, path, err)
+		}
+		defer file.Close()
+		if _, err := fmt.Fprintln(file, knownhosts.Line([]string{address}, key)); err != nil {
+			return fmt.Errorf("failed to add the host key of %s to %s: %w", hostname, path, err)
+		}
+		added[address] = key
+		logrus.Infof("Added the %s host key of %s to %s", key.Type(), hostname, path)
+		return nil
+	}, nil
+}
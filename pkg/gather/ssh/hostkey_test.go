@@ -0,0 +1,130 @@
+package ssh
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func newSigner(t *testing.T) ssh.Signer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func newHostCertificate(t *testing.T, ca ssh.Signer, host ssh.Signer) *ssh.Certificate {
+	cert := &ssh.Certificate{
+		Key:             host.PublicKey(),
+		CertType:        ssh.HostCert,
+		ValidPrincipals: []string{"bastion.example.com"},
+		ValidBefore:     ssh.CertTimeInfinity,
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestHostCertificateCallback(t *testing.T) {
+	ca := newSigner(t)
+	otherCA := newSigner(t)
+	host := newSigner(t)
+
+	dir, err := ioutil.TempDir("", "hostkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	caPath := filepath.Join(dir, "ca.pub")
+	if err := ioutil.WriteFile(caPath, ssh.MarshalAuthorizedKey(ca.PublicKey()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	callback, err := HostCertificateCallback(caPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := &net.TCPAddr{IP: net.ParseIP("192.0.2.10"), Port: 22}
+	cases := []struct {
+		name string
+		key  ssh.PublicKey
+		err  bool
+	}{
+		{
+			name: "signed by ca",
+			key:  newHostCertificate(t, ca, host),
+		},
+		{
+			name: "signed by other ca",
+			key:  newHostCertificate(t, otherCA, host),
+			err:  true,
+		},
+		{
+			name: "plain host key",
+			key:  host.PublicKey(),
+			err:  true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := callback("bastion.example.com:22", addr, tc.key)
+			if tc.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestKnownHostsCallback(t *testing.T) {
+	host := newSigner(t)
+	other := newSigner(t)
+
+	dir, err := ioutil.TempDir("", "knownhosts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "known_hosts")
+	addr := &net.TCPAddr{IP: net.ParseIP("192.0.2.10"), Port: 22}
+
+	callback, err := KnownHostsCallback(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, callback("192.0.2.10:22", addr, host.PublicKey()), "unknown hosts are added")
+	assert.NoError(t, callback("192.0.2.10:22", addr, host.PublicKey()))
+	assert.Error(t, callback("192.0.2.10:22", addr, other.PublicKey()))
+
+	data, err := ioutil.ReadFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "192.0.2.10 "+string(ssh.MarshalAuthorizedKey(host.PublicKey())), string(data))
+
+	callback, err = KnownHostsCallback(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, callback("192.0.2.10:22", addr, host.PublicKey()))
+	err = callback("192.0.2.10:22", addr, other.PublicKey())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "does not match")
+	}
+}
@@ -0,0 +1,131 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// passphraseAttempts is the number of times the passphrase of a key is asked
+// for before giving up, like ssh does.
+const passphraseAttempts = 3
+
+// ErrIncorrectPassphrase matches errors decrypting a private key with a
+// wrong passphrase.
+var ErrIncorrectPassphrase = errors.New("incorrect passphrase")
+
+// Passphrases decrypts the encrypted private keys of the connections opened
+// with it, asking for the passphrase of each key only once. It is safe for
+// concurrent use.
+type Passphrases struct {
+	// ask returns the passphrase of the encrypted private key at path.
+	ask func(path string) ([]byte, error)
+
+	// mu serializes the prompts of the connections opened concurrently,
+	// which all load the keys, and guards cache.
+	mu sync.Mutex
+
+	// cache holds the passphrases which decrypted the keys at their paths.
+	cache map[string][]byte
+}
+
+// NewPassphrases returns the Passphrases asking for the passphrase of the
+// encrypted private key at path with ask, like PromptPassphrase or the
+// function returned by FilePassphrases.
+func NewPassphrases(ask func(path string) ([]byte, error)) *Passphrases {
+	return &Passphrases{ask: ask, cache: map[string][]byte{}}
+}
+
+// PromptPassphrase asks for the passphrase of the key at path on the
+// terminal, or else with the program in SSH_ASKPASS, like ssh does.
+func PromptPassphrase(path string) ([]byte, error) {
+	prompt := fmt.Sprintf("Enter passphrase for key %q: ", path)
+	if fd := int(os.Stdin.Fd()); terminal.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, prompt)
+		defer fmt.Fprintln(os.Stderr)
+		return terminal.ReadPassword(fd)
+	}
+	if askpass := os.Getenv("SSH_ASKPASS"); askpass != "" {
+		out, err := exec.Command(askpass, prompt).Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to ask for the passphrase of %q with %s: %w", path, askpass, err)
+		}
+		return bytes.TrimRight(out, "\r\n"), nil
+	}
+	return nil, fmt.Errorf("%q is encrypted, and there is no terminal to ask for its passphrase on, use --key-passphrase-file or SSH_ASKPASS", path)
+}
+
+// FilePassphrases returns a function for NewPassphrases reading the passphrase of the i-th of
+// keys from the i-th of files, or from the only one of files for all keys.
+// Trailing newlines are ignored.
+func FilePassphrases(keys, files []string) (func(path string) ([]byte, error), error) {
+	if len(files) != 1 && len(files) != len(keys) {
+		return nil, fmt.Errorf("%d passphrase files were given for %d keys, give one for all keys or one per key", len(files), len(keys))
+	}
+	return func(path string) ([]byte, error) {
+		file := files[0]
+		for i, key := range keys {
+			if key == path && len(files) > 1 {
+				file = files[i]
+			}
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the passphrase of %q: %w", path, err)
+		}
+		return bytes.TrimRight(data, "\r\n"), nil
+	}, nil
+}
+
+// parsePrivateKey parses the private key in data, read from path,
+// decrypting it with its passphrase from passphrases when it is encrypted.
+// Encrypted keys cannot be parsed without passphrases.
+func parsePrivateKey(path string, data []byte, passphrases *Passphrases) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || !strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED") {
+		key, err := ssh.ParseRawPrivateKey(data)
+		if err != nil && block != nil && block.Type == "OPENSSH PRIVATE KEY" && strings.Contains(err.Error(), "encrypted") {
+			return nil, fmt.Errorf("%q is encrypted in the OpenSSH format, which is not supported, add it to the SSH agent instead, or convert it to PEM with ssh-keygen -p -m PEM -f %s", path, path)
+		}
+		return key, err
+	}
+
+	if passphrases == nil || passphrases.ask == nil {
+		return nil, fmt.Errorf("%q is encrypted with a passphrase", path)
+	}
+	passphrases.mu.Lock()
+	defer passphrases.mu.Unlock()
+	if passphrase, ok := passphrases.cache[path]; ok {
+		return ssh.ParseRawPrivateKeyWithPassphrase(data, passphrase)
+	}
+	for attempt := 1; ; attempt++ {
+		passphrase, err := passphrases.ask(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := ssh.ParseRawPrivateKeyWithPassphrase(data, passphrase)
+		if err == nil {
+			passphrases.cache[path] = passphrase
+			return key, nil
+		}
+		for i := range passphrase {
+			passphrase[i] = 0
+		}
+		if err != x509.IncorrectPasswordError {
+			return nil, err
+		}
+		if attempt == passphraseAttempts {
+			return nil, fmt.Errorf("%w for %q, retry with the right one", ErrIncorrectPassphrase, path)
+		}
+	}
+}
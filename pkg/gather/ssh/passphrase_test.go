@@ -0,0 +1,93 @@
+package ssh
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeEncryptedKey(t *testing.T, path, passphrase string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := x509.EncryptPEMBlock(rand.Reader, "EC PRIVATE KEY", der, []byte(passphrase), x509.PEMCipherAES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadEncryptedKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	first, second := filepath.Join(dir, "first"), filepath.Join(dir, "second")
+	writeEncryptedKey(t, first, "first secret")
+	writeEncryptedKey(t, second, "second secret")
+	firstFile, secondFile := filepath.Join(dir, "first.pass"), filepath.Join(dir, "second.pass")
+	if err := ioutil.WriteFile(firstFile, []byte("first secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(secondFile, []byte("second secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("no passphrase", func(t *testing.T) {
+		_, err := LoadPrivateSSHKeys([]string{first}, nil)
+		assert.Contains(t, err.Error(), "is encrypted with a passphrase")
+	})
+
+	t.Run("passphrase per key", func(t *testing.T) {
+		passphrase, err := FilePassphrases([]string{first, second}, []string{firstFile, secondFile})
+		if !assert.NoError(t, err) {
+			return
+		}
+		keys, err := LoadPrivateSSHKeys([]string{first, second}, NewPassphrases(passphrase))
+		assert.NoError(t, err)
+		assert.Len(t, keys, 2)
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		attempts := 0
+		passphrases := NewPassphrases(func(string) ([]byte, error) {
+			attempts++
+			return []byte("wrong"), nil
+		})
+		_, err := LoadPrivateSSHKeys([]string{first}, passphrases)
+		assert.Contains(t, err.Error(), "incorrect passphrase for")
+		assert.Equal(t, passphraseAttempts, attempts)
+	})
+
+	t.Run("asked once", func(t *testing.T) {
+		attempts := 0
+		passphrases := NewPassphrases(func(string) ([]byte, error) {
+			attempts++
+			return []byte("first secret"), nil
+		})
+		for i := 0; i < 2; i++ {
+			_, err := LoadPrivateSSHKeys([]string{first}, passphrases)
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, 1, attempts)
+	})
+
+	_, err = FilePassphrases([]string{first, second, "third"}, []string{firstFile, secondFile})
+	assert.EqualError(t, err, "2 passphrase files were given for 3 keys, give one for all keys or one per key")
+}
@@ -0,0 +1,108 @@
+package ssh
+
+import (
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// Pool keeps a single SSH client for each user and address, so that every
+// step collecting from a host shares one connection instead of dialing the
+// host again. It is safe for concurrent use.
+type Pool struct {
+	keys            []string
+	passphrases     *Passphrases
+	dial            Dialer
+	hostKeyCallback ssh.HostKeyCallback
+
+	mu      sync.Mutex
+	clients map[string]*pooledClient
+}
+
+type pooledClient struct {
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+// NewPool returns a pool which connects with the keys, like
+// NewClientWithDialer, using dial to open the connections.
+func NewPool(keys []string, dial Dialer) *Pool {
+	return NewPoolWithHostKeyCallback(keys, nil, dial, ssh.InsecureIgnoreHostKey())
+}
+
+// NewPoolWithHostKeyCallback is like NewPool, but verifies the host keys
+// with hostKeyCallback, and decrypts the encrypted keys with passphrases.
+func NewPoolWithHostKeyCallback(keys []string, passphrases *Passphrases, dial Dialer, hostKeyCallback ssh.HostKeyCallback) *Pool {
+	return &Pool{keys: keys, passphrases: passphrases, dial: dial, hostKeyCallback: hostKeyCallback, clients: map[string]*pooledClient{}}
+}
+
+// Client returns the client connected to address as user, connecting it on
+// first use. Failed connections are not cached, so a later call dials the
+// host again. The client must not be closed by the caller; use Close.
+func (p *Pool) Client(user, address string) (*ssh.Client, error) {
+	pc := p.pooled(user, address)
+
+	// Callers for the same host wait for a single dial.
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.client == nil {
+		client, err := newClient(user, address, p.keys, p.passphrases, p.dial, p.hostKeyCallback)
+		if err != nil {
+			return nil, err
+		}
+		pc.client = client
+	}
+	return pc.client, nil
+}
+
+// Reconnect closes the client connected to address as user, if any, and
+// connects a new one, for connections which dropped. Later calls to Client
+// return the new client.
+func (p *Pool) Reconnect(user, address string) (*ssh.Client, error) {
+	pc := p.pooled(user, address)
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.client != nil {
+		pc.client.Close()
+		pc.client = nil
+	}
+	client, err := newClient(user, address, p.keys, p.passphrases, p.dial, p.hostKeyCallback)
+	if err != nil {
+		return nil, err
+	}
+	pc.client = client
+	return client, nil
+}
+
+func (p *Pool) pooled(user, address string) *pooledClient {
+	key := user + "@" + address
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pc, ok := p.clients[key]
+	if !ok {
+		pc = &pooledClient{}
+		p.clients[key] = pc
+	}
+	return pc
+}
+
+// Close closes every client of the pool.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	clients := p.clients
+	p.clients = map[string]*pooledClient{}
+	p.mu.Unlock()
+
+	var errs []error
+	for _, pc := range clients {
+		pc.mu.Lock()
+		if pc.client != nil {
+			if err := pc.client.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		pc.mu.Unlock()
+	}
+	return utilerrors.NewAggregate(errs)
+}
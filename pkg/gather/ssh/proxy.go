@@ -0,0 +1,139 @@
+package ssh
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Dialer opens the connection used as the transport for an SSH client.
+type Dialer func(network, address string) (net.Conn, error)
+
+// TimeoutDialer returns a Dialer that gives up on connections which dial
+// does not open within timeout, so that unreachable hosts fail fast. The
+// returned error is a net.Error whose Timeout method returns true.
+func TimeoutDialer(dial Dialer, timeout time.Duration) Dialer {
+	return func(network, address string) (net.Conn, error) {
+		type result struct {
+			conn net.Conn
+			err  error
+		}
+		results := make(chan result, 1)
+		go func() {
+			conn, err := dial(network, address)
+			results <- result{conn: conn, err: err}
+		}()
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case r := <-results:
+			return r.conn, r.err
+		case <-timer.C:
+			go func() {
+				if r := <-results; r.conn != nil {
+					r.conn.Close()
+				}
+			}()
+			return nil, &net.OpError{Op: "dial", Net: network, Err: dialTimeoutError(timeout)}
+		}
+	}
+}
+
+// dialTimeoutError is returned by TimeoutDialer.
+type dialTimeoutError time.Duration
+
+func (e dialTimeoutError) Error() string {
+	return fmt.Sprintf("no connection after %s", time.Duration(e))
+}
+
+func (e dialTimeoutError) Timeout() bool   { return true }
+func (e dialTimeoutError) Temporary() bool { return true }
+
+// ParseProxyURL parses the address of an HTTP proxy, which may omit the
+// scheme (e.g. "user:password@proxy.example.com:3128").
+func ParseProxyURL(proxy string) (*url.URL, error) {
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+	}
+	if u.Port() == "" {
+		return nil, errors.New("the proxy port is required")
+	}
+	return u, nil
+}
+
+// HTTPConnectDialer returns a Dialer that tunnels connections through the
+// HTTP proxy at proxyURL using the CONNECT method. Basic authentication is
+// used when proxyURL has user information.
+func HTTPConnectDialer(proxyURL *url.URL, dial Dialer) Dialer {
+	return func(network, address string) (net.Conn, error) {
+		conn, err := dial(network, proxyURL.Host)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to proxy %s: %w", proxyURL.Host, err)
+		}
+		if proxyURL.Scheme == "https" {
+			conn = tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname()})
+		}
+		if err := connect(conn, proxyURL, address); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to tunnel to %s through proxy %s: %w", address, proxyURL.Host, err)
+		}
+		return conn, nil
+	}
+}
+
+// connect asks the proxy on conn to tunnel the connection to address.
+func connect(conn net.Conn, proxyURL *url.URL, address string) error {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: http.Header{},
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		return err
+	}
+
+	// Read byte-by-byte up to the end of the response headers, so that no
+	// part of the tunneled stream is buffered away from the SSH client.
+	resp, err := http.ReadResponse(bufio.NewReaderSize(&byteReader{conn: conn}, 1), req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return fmt.Errorf("proxy responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// byteReader reads a single byte at a time from conn.
+type byteReader struct {
+	conn net.Conn
+}
+
+func (r *byteReader) Read(p []byte) (int, error) {
+	if len(p) > 1 {
+		p = p[:1]
+	}
+	return r.conn.Read(p)
+}
@@ -2,12 +2,17 @@
 package ssh
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"os"
-	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/openshift/installer/pkg/lineprinter"
-	"github.com/pkg/errors"
 	"github.com/pkg/sftp"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/ssh"
@@ -16,16 +21,73 @@ import (
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 )
 
+// DefaultShell is the remote command that Run and Output pass their
+// commands to as a single quoted argument, so that commands are interpreted
+// by a POSIX shell whatever the login shell of the remote user is, unless
+// their context holds another one from WithShell.
+const DefaultShell = "/bin/sh -c"
+
+type shellKey struct{}
+
+// WithShell returns a copy of ctx in which the commands are passed to shell
+// instead of DefaultShell. When shell is empty, commands are passed to the
+// login shell as they are.
+func WithShell(ctx context.Context, shell string) context.Context {
+	return context.WithValue(ctx, shellKey{}, shell)
+}
+
+// shell returns the shell of ctx.
+func shell(ctx context.Context) string {
+	if shell, ok := ctx.Value(shellKey{}).(string); ok {
+		return shell
+	}
+	return DefaultShell
+}
+
+// CleanupContext returns a context which is never done, with the shell of
+// ctx, for the commands cleaning up after commands run with ctx, which must
+// run even when ctx is done.
+func CleanupContext(ctx context.Context) context.Context {
+	return WithShell(context.Background(), shell(ctx))
+}
+
 // NewClient creates a new SSH client which can be used to SSH to address using user and the keys.
 //
-// if keys list is empty, it tries to load the keys from the user's environment.
+// if keys list is empty, it uses the keys of the SSH agent at SSH_AUTH_SOCK,
+// or else ~/.ssh/id_rsa, ~/.ssh/id_ecdsa and ~/.ssh/id_ed25519.
 func NewClient(user, address string, keys []string) (*ssh.Client, error) {
-	ag, err := newAgent(keys)
+	return NewClientWithDialer(user, address, keys, net.Dial)
+}
+
+// NewClientWithDialer is like NewClient, but uses dial to open the
+// connection to address, which allows tunneling the SSH connection.
+func NewClientWithDialer(user, address string, keys []string, dial Dialer) (*ssh.Client, error) {
+	return newClient(user, address, keys, nil, dial, ssh.InsecureIgnoreHostKey())
+}
+
+// NewClientWithHostKeyCallback is like NewClientWithDialer, but verifies
+// the host key of address with hostKeyCallback, and decrypts the encrypted
+// keys with passphrases.
+func NewClientWithHostKeyCallback(user, address string, keys []string, passphrases *Passphrases, dial Dialer, hostKeyCallback ssh.HostKeyCallback) (*ssh.Client, error) {
+	return newClient(user, address, keys, passphrases, dial, hostKeyCallback)
+}
+
+func newClient(user, address string, keys []string, passphrases *Passphrases, dial Dialer, hostKeyCallback ssh.HostKeyCallback) (*ssh.Client, error) {
+	ag, sources, err := newAgent(keys, passphrases)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to initialize the SSH agent")
+		return nil, fmt.Errorf("failed to initialize the SSH agent: %w", err)
+	}
+	closeAgent := func() {}
+	if closer, ok := ag.(io.Closer); ok {
+		closeAgent = func() { closer.Close() }
 	}
 
-	client, err := ssh.Dial("tcp", address, &ssh.ClientConfig{
+	conn, err := dial("tcp", address)
+	if err != nil {
+		closeAgent()
+		return nil, err
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, address, &ssh.ClientConfig{
 		User: user,
 		Auth: []ssh.AuthMethod{
 			// Use a callback rather than PublicKeys
@@ -33,98 +95,292 @@ func NewClient(user, address string, keys []string) (*ssh.Client, error) {
 			// wants it.
 			ssh.PublicKeysCallback(ag.Signers),
 		},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		HostKeyCallback: hostKeyCallback,
 	})
 	if err != nil {
+		conn.Close()
+		closeAgent()
+		if strings.Contains(err.Error(), "unable to authenticate") {
+			return nil, authError(address, fmt.Errorf("%w (tried the keys of %s)", err, strings.Join(sources, ", ")))
+		}
 		return nil, err
 	}
+	client := ssh.NewClient(c, chans, reqs)
+	go func() {
+		client.Wait()
+		closeAgent()
+	}()
 	if err := agent.ForwardToAgent(client, ag); err != nil {
-		return nil, errors.Wrap(err, "failed to forward agent")
+		// Closing the client also closes the agent, once Wait returns.
+		client.Close()
+		return nil, fmt.Errorf("failed to forward agent: %w", err)
 	}
 	return client, nil
 }
 
 // Run uses an SSH client to execute commands.
 func Run(client *ssh.Client, command string) error {
+	return RunContext(context.Background(), client, command)
+}
+
+// RunContext is like Run, but closes the session, abandoning the command,
+// when ctx is done. The output of the command is logged at the debug level,
+// and its last lines are included in the returned error on failure.
+func RunContext(ctx context.Context, client *ssh.Client, command string) error {
+	return run(ctx, client, command, logrus.Debug)
+}
+
+// RunStreaming is like RunContext, but logs the output of the command at the
+// info level as it is produced, each line prefixed with prefix, so that the
+// progress of long commands is seen. The lines of concurrent commands are
+// logged whole.
+func RunStreaming(ctx context.Context, client *ssh.Client, command, prefix string) error {
+	return run(ctx, client, command, func(args ...interface{}) {
+		logrus.Info(prefix + fmt.Sprint(args...))
+	})
+}
+
+func run(ctx context.Context, client *ssh.Client, command string, print lineprinter.Print) error {
 	sess, err := client.NewSession()
 	if err != nil {
 		return err
 	}
 	defer sess.Close()
+	defer closeOnDone(ctx, sess)()
 	if err := agent.RequestAgentForwarding(sess); err != nil {
-		return errors.Wrap(err, "failed to setup request agent forwarding")
+		return fmt.Errorf("failed to setup request agent forwarding: %w", err)
+	}
+
+	// stdout and stderr are copied concurrently, so each has its own
+	// printer, for partial lines not to be mixed.
+	stdout := &lineprinter.LinePrinter{Print: (&lineprinter.Trimmer{WrappedPrint: print}).Print}
+	stderr := &lineprinter.LinePrinter{Print: (&lineprinter.Trimmer{WrappedPrint: print}).Print}
+	output := &tailBuffer{max: runOutputBytes}
+	sess.Stdout = io.MultiWriter(stdout, output)
+	sess.Stderr = io.MultiWriter(stderr, output)
+	err = sess.Run(wrapCommand(shell(ctx), command))
+	stdout.Close()
+	stderr.Close()
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if tail := output.lines(runOutputLines); tail != "" {
+			return fmt.Errorf("%w, the output ended with:\n%s", err, tail)
+		}
+		return err
+	}
+	return nil
+}
+
+const (
+	// runOutputLines is the number of the last lines of the output of a
+	// failed command included in its error.
+	runOutputLines = 20
+
+	// runOutputBytes bounds the output of a command kept for its error.
+	runOutputBytes = 64 * 1024
+)
+
+// tailBuffer keeps the last max bytes written to it. It is safe for
+// concurrent use.
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.max {
+		t.buf = append([]byte(nil), t.buf[len(t.buf)-t.max:]...)
+	}
+	return len(p), nil
+}
+
+// lines returns the last n lines written.
+func (t *tailBuffer) lines(n int) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lines := strings.Split(strings.TrimRight(string(t.buf), "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Output uses an SSH client to execute a command and returns its standard output.
+// On failure, the standard error of the command is included in the returned error.
+// The session is closed, abandoning the command, when ctx is done.
+func Output(ctx context.Context, client *ssh.Client, command string) ([]byte, error) {
+	return OutputWithShell(ctx, client, shell(ctx), command)
+}
+
+// OutputWithShell is like Output, but passes command to shell instead of
+// the shell of ctx. It is used for hosts which have no POSIX shell, like Windows.
+func OutputWithShell(ctx context.Context, client *ssh.Client, shell, command string) ([]byte, error) {
+	var stdout bytes.Buffer
+	err := stream(ctx, client, shell, command, &stdout)
+	return stdout.Bytes(), err
+}
+
+// Stream is like Output, but copies the standard output of the command to
+// w as it is produced, for output too large to be buffered.
+func Stream(ctx context.Context, client *ssh.Client, command string, w io.Writer) error {
+	return stream(ctx, client, shell(ctx), command, w)
+}
+
+func stream(ctx context.Context, client *ssh.Client, shell, command string, w io.Writer) error {
+	sess, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer sess.Close()
+	defer closeOnDone(ctx, sess)()
+
+	var stderr bytes.Buffer
+	sess.Stdout = w
+	sess.Stderr = &stderr
+	if err := sess.Run(wrapCommand(shell, command)); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %w", msg, err)
+		}
+		return err
 	}
+	return nil
+}
 
-	debugW := &lineprinter.LinePrinter{Print: (&lineprinter.Trimmer{WrappedPrint: logrus.Debug}).Print}
-	defer debugW.Close()
-	sess.Stdout = debugW
-	sess.Stderr = debugW
-	return sess.Run(command)
+// closeOnDone closes c when ctx is done, until the returned function is
+// called.
+func closeOnDone(ctx context.Context, c io.Closer) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Close()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+// wrapCommand returns the remote command passing command to shell as a
+// single quoted argument.
+func wrapCommand(shell, command string) string {
+	if shell == "" {
+		return command
+	}
+	return fmt.Sprintf("%s '%s'", shell, strings.Replace(command, "'", `'\''`, -1))
+}
+
+// HomeDir returns the home directory of user on the remote server, as
+// reported by the password database.
+func HomeDir(ctx context.Context, client *ssh.Client, user string) (string, error) {
+	out, err := Output(ctx, client, fmt.Sprintf("getent passwd %s", user))
+	if err != nil {
+		return "", fmt.Errorf("failed to look up user %q: %w", user, err)
+	}
+	fields := strings.Split(strings.TrimSpace(string(out)), ":")
+	if len(fields) < 6 || fields[5] == "" {
+		return "", fmt.Errorf("no home directory for user %q", user)
+	}
+	return fields[5], nil
 }
 
 // PullFileTo downloads the file from remote server using SSH connection and writes to localPath.
+// Failures are returned as an *Error matching ErrPullFailed.
 func PullFileTo(client *ssh.Client, remotePath, localPath string) error {
+	return PullFileToContext(context.Background(), client, remotePath, localPath)
+}
+
+// PullFileToContext is like PullFileTo, but abandons the download when ctx
+// is done, removing the partially written localPath.
+func PullFileToContext(ctx context.Context, client *ssh.Client, remotePath, localPath string) error {
+	if err := pullFileTo(ctx, client, remotePath, localPath); err != nil {
+		return pullError(client.RemoteAddr().String(), remotePath, err)
+	}
+	return nil
+}
+
+func pullFileTo(ctx context.Context, client *ssh.Client, remotePath, localPath string) error {
 	sc, err := sftp.NewClient(client)
 	if err != nil {
-		return errors.Wrap(err, "failed to initialize the sftp client")
+		return fmt.Errorf("failed to initialize the sftp client: %w", err)
 	}
 	defer sc.Close()
+	defer closeOnDone(ctx, sc)()
 
 	// Open the source file
 	rFile, err := sc.Open(remotePath)
 	if err != nil {
-		return errors.Wrap(err, "failed to open remote file")
+		return fmt.Errorf("failed to open remote file: %w", err)
 	}
 	defer rFile.Close()
 
 	lFile, err := os.Create(localPath)
 	if err != nil {
-		return errors.Wrap(err, "failed to create file")
+		return fmt.Errorf("failed to create file: %w", err)
 	}
 	defer lFile.Close()
 
 	if _, err := rFile.WriteTo(lFile); err != nil {
+		if ctx.Err() != nil {
+			lFile.Close()
+			os.Remove(localPath)
+			return ctx.Err()
+		}
 		return err
 	}
 	return nil
 }
 
-// defaultPrivateSSHKeys returns a list of all the PRIVATE SSH keys from user's home directory.
-// It does not return any intermediate errors if at least one private key was loaded.
-func defaultPrivateSSHKeys() ([]interface{}, error) {
-	d := filepath.Join(os.Getenv("HOME"), ".ssh")
-	paths, err := ioutil.ReadDir(d)
+// PushFile uses an SSH client to write the contents of r to remotePath,
+// with mode, replacing any file there.
+func PushFile(client *ssh.Client, r io.Reader, remotePath string, mode os.FileMode) error {
+	if err := pushFile(client, r, remotePath, mode); err != nil {
+		return &Error{Op: fmt.Sprintf("push %s", remotePath), Address: client.RemoteAddr().String(), Err: err}
+	}
+	return nil
+}
+
+func pushFile(client *ssh.Client, r io.Reader, remotePath string, mode os.FileMode) error {
+	sc, err := sftp.NewClient(client)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to read directory %q", d)
+		return fmt.Errorf("failed to initialize the sftp client: %w", err)
 	}
+	defer sc.Close()
 
-	var files []string
-	for _, path := range paths {
-		if path.IsDir() {
-			continue
-		}
-		files = append(files, filepath.Join(d, path.Name()))
+	rFile, err := sc.Create(remotePath)
+	if err != nil {
+		return fmt.Errorf("failed to create remote file: %w", err)
 	}
-	keys, err := LoadPrivateSSHKeys(files)
-	if keys != nil && len(keys) > 0 {
-		return keys, nil
+	defer rFile.Close()
+
+	if _, err := rFile.ReadFrom(r); err != nil {
+		return err
 	}
-	return nil, err
+	return rFile.Chmod(mode)
 }
 
 // LoadPrivateSSHKeys try to optimistically load PRIVATE SSH keys from the all paths.
-func LoadPrivateSSHKeys(paths []string) ([]interface{}, error) {
+// Encrypted keys are decrypted with passphrases, which may be nil for none.
+func LoadPrivateSSHKeys(paths []string, passphrases *Passphrases) ([]interface{}, error) {
 	var errs []error
 	var keys []interface{}
 	for _, path := range paths {
 		data, err := ioutil.ReadFile(path)
 		if err != nil {
-			errs = append(errs, errors.Wrapf(err, "failed to read %q", path))
+			errs = append(errs, fmt.Errorf("failed to read %q: %w", path, err))
 			continue
 		}
-		key, err := ssh.ParseRawPrivateKey(data)
+		key, err := parsePrivateKey(path, data, passphrases)
 		if err != nil {
-			errs = append(errs, errors.Wrapf(err, "failed to parse SSH private key from %q", path))
+			errs = append(errs, fmt.Errorf("failed to parse SSH private key from %q: %w", path, err))
 			continue
 		}
 		keys = append(keys, key)
@@ -0,0 +1,78 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapCommand(t *testing.T) {
+	cases := []struct {
+		name    string
+		shell   string
+		command string
+		output  string
+	}{
+		{
+			name:    "no shell",
+			command: "echo hello",
+			output:  "hello\n",
+		},
+		{
+			name:    "simple",
+			shell:   "/bin/sh -c",
+			command: "echo hello",
+			output:  "hello\n",
+		},
+		{
+			name:    "single quotes",
+			shell:   "/bin/sh -c",
+			command: `echo 'it'"'"'s' "a 'quoted' word"`,
+			output:  "it's a 'quoted' word\n",
+		},
+		{
+			name:    "multiple lines",
+			shell:   "/bin/sh -c",
+			command: "x='a b'\necho \"${x}\"",
+			output:  "a b\n",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// sshd passes the command to the login shell of the user.
+			out, err := exec.Command("/bin/sh", "-c", wrapCommand(tc.shell, tc.command)).Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.output, string(out))
+		})
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	tail := &tailBuffer{max: 64}
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(tail, "line %d\n", i)
+	}
+	assert.Equal(t, "line 28\nline 29\nline 30", tail.lines(3))
+	assert.True(t, strings.HasSuffix(tail.lines(100), "line 30"))
+	assert.True(t, len(tail.buf) <= 64)
+}
+
+func TestWithShell(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, DefaultShell, shell(ctx))
+	bash := WithShell(ctx, "/bin/bash -c")
+	assert.Equal(t, "/bin/bash -c", shell(bash))
+	assert.Equal(t, "", shell(WithShell(ctx, "")))
+
+	canceled, cancel := context.WithCancel(bash)
+	cancel()
+	cleanup := CleanupContext(canceled)
+	assert.NoError(t, cleanup.Err())
+	assert.Equal(t, "/bin/bash -c", shell(cleanup))
+}
@@ -0,0 +1,93 @@
+package gather
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// State records the hosts a gather collected, so that an interrupted
+// gather can be resumed without collecting them again.
+type State struct {
+	// Inputs identifies the hosts the gather was run against. See
+	// StateInputs.
+	Inputs string `json:"inputs"`
+
+	// Collected lists the hosts that were collected successfully.
+	Collected []string `json:"collected,omitempty"`
+
+	path string
+	mu   sync.Mutex
+}
+
+// StateInputs returns an identifier for a gather against addresses which
+// does not depend on their order.
+func StateInputs(addresses ...string) string {
+	sorted := append([]string(nil), addresses...)
+	sort.Strings(sorted)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(sorted, "\n"))))
+}
+
+// LoadState reads the state stored at path. If there is no state at path,
+// an empty state is returned. Either way, Save writes the state to path.
+func LoadState(path string) (*State, error) {
+	state := &State{path: path}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", path, err)
+	}
+	return state, nil
+}
+
+// IsCollected returns true if host was collected successfully.
+func (s *State) IsCollected(host string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isCollected(host)
+}
+
+func (s *State) isCollected(host string) bool {
+	for _, h := range s.Collected {
+		if h == host {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkCollected records that host was collected successfully and saves
+// the state.
+func (s *State) MarkCollected(host string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isCollected(host) {
+		s.Collected = append(s.Collected, host)
+	}
+	return s.save()
+}
+
+// Save writes the state to the path it was loaded from.
+func (s *State) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.save()
+}
+
+func (s *State) save() error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, data, 0644)
+}
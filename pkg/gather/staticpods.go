@@ -0,0 +1,113 @@
+package gather
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/installer/pkg/asset"
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+const (
+	// staticPodManifestsDir is where the kubelet reads static pods from.
+	staticPodManifestsDir = "/etc/kubernetes/manifests"
+
+	// staticPodResourcesDir is where the installer pods of the control
+	// plane operators write the resources of each static pod revision.
+	staticPodResourcesDir = "/etc/kubernetes/static-pod-resources"
+)
+
+// StaticPodsStep returns a step that pulls the static pod manifests read by
+// the kubelet and lists the static pod resources of each revision. Manifests
+// are redacted like the rendered manifests; the resources, which hold
+// secrets, are only listed.
+func StaticPodsStep() Step {
+	return Step{
+		Name: "static-pods",
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			out, err := gatherssh.Output(ctx, host.Client, fmt.Sprintf("sudo find %s -maxdepth 1 -type f", staticPodManifestsDir))
+			if err != nil {
+				return fmt.Errorf("failed to list the static pod manifests: %w", err)
+			}
+			manifestsDir := filepath.Join(dir, "manifests")
+			if err := os.MkdirAll(manifestsDir, 0755); err != nil {
+				return err
+			}
+			for _, remote := range strings.Fields(string(out)) {
+				local := filepath.Join(manifestsDir, path.Base(remote))
+				if err := pullPrivileged(ctx, host, remote, local, 0); err != nil {
+					return err
+				}
+				if err := redactFile(local, remote); err != nil {
+					return fmt.Errorf("failed to redact %s: %w", remote, err)
+				}
+			}
+
+			return writeOutput(ctx, host, fmt.Sprintf("sudo ls -lR --time-style=full-iso %s 2>&1 || true", staticPodResourcesDir), filepath.Join(dir, "resources.txt"))
+		},
+	}
+}
+
+// StaticPodsAnalyzer returns an analyzer that reports the revision of each
+// static pod pulled by StaticPodsStep, on the bootstrap host and on the
+// directly collected control plane hosts.
+func StaticPodsAnalyzer() Analyzer {
+	return Analyzer{
+		Name:  "static-pods",
+		Files: []string{"static-pods/manifests/*", "control-plane-live/*/static-pods/manifests/*"},
+		Analyze: func(files map[string][]byte, summary *Summary) {
+			revisions := map[string][]string{}
+			for name, data := range files {
+				pod := &corev1.Pod{}
+				if err := yaml.Unmarshal(data, pod); err != nil || pod.Kind != "Pod" {
+					continue
+				}
+				host := "bootstrap"
+				if strings.HasPrefix(name, "control-plane-live/") {
+					host = strings.SplitN(name, "/", 3)[1]
+				}
+				revision := pod.Labels["revision"]
+				if revision == "" {
+					revision = "none"
+				}
+				revisions[host] = append(revisions[host], fmt.Sprintf("%s revision %s", pod.Name, revision))
+			}
+
+			hosts := make([]string, 0, len(revisions))
+			for host := range revisions {
+				hosts = append(hosts, host)
+			}
+			sort.Strings(hosts)
+			for _, host := range hosts {
+				sort.Strings(revisions[host])
+				summary.Addf("static-pods: %s: %s", host, strings.Join(revisions[host], ", "))
+			}
+		},
+	}
+}
+
+// redactFile redacts the manifest at local, pulled from remote, in place.
+// It is removed when it may hold credentials but is not a Secret.
+func redactFile(local, remote string) error {
+	raw, err := ioutil.ReadFile(local)
+	if err != nil {
+		return err
+	}
+	data, err := redactManifest(&asset.File{Filename: remote, Data: raw})
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return os.Remove(local)
+	}
+	return ioutil.WriteFile(local, data, 0600)
+}
@@ -0,0 +1,81 @@
+package gather
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// storageUsageCommand reports, as key=value lines, the bytes used by
+// container storage and logs, and by the filesystem holding container
+// storage.
+const storageUsageCommand = `echo containers=$(sudo du --summarize --bytes /var/lib/containers 2>/dev/null | cut -f1)
+echo logs=$(sudo du --summarize --bytes /var/log 2>/dev/null | cut -f1)
+echo used=$(df --output=used --block-size=1 /var/lib/containers | tail -n 1)
+echo size=$(df --output=size --block-size=1 /var/lib/containers | tail -n 1)`
+
+const (
+	// fullUsage is the share of the size of a filesystem above which it is
+	// considered full.
+	fullUsage = 0.8
+
+	// dominantUsage is the share of the used bytes of a full filesystem
+	// above which container storage or logs are reported as filling it.
+	dominantUsage = 0.5
+)
+
+// StorageStep returns a step that records the disk usage of container
+// storage, images and logs.
+func StorageStep() Step {
+	return Step{
+		Name: "storage",
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			for file, command := range map[string]string{
+				"usage.txt":     storageUsageCommand,
+				"df.txt":        "df --human-readable",
+				"podman-df.txt": "sudo podman system df --verbose 2>&1 || true",
+				"images.txt":    "sudo crictl images 2>&1 || true",
+			} {
+				out, err := gatherssh.Output(ctx, host.Client, command)
+				if err != nil {
+					return fmt.Errorf("failed to collect %s: %w", file, err)
+				}
+				if err := ioutil.WriteFile(filepath.Join(dir, file), out, 0644); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// StorageAnalyzer returns an analyzer that reports when the filesystem
+// holding container storage is full, and whether container storage or logs
+// take up most of it, based on the usage recorded by StorageStep.
+func StorageAnalyzer() Analyzer {
+	return Analyzer{
+		Name:  "storage",
+		Files: []string{"storage/usage.txt"},
+		Analyze: func(files map[string][]byte, summary *Summary) {
+			for _, data := range files {
+				usage := parseKeyValues(data)
+				used, _ := strconv.ParseFloat(usage["used"], 64)
+				size, _ := strconv.ParseFloat(usage["size"], 64)
+				if size == 0 || used/size < fullUsage {
+					continue
+				}
+				summary.Addf("storage: the filesystem holding /var/lib/containers is %.0f%% full", 100*used/size)
+				for _, kind := range []string{"containers", "logs"} {
+					bytes, _ := strconv.ParseFloat(usage[kind], 64)
+					if bytes/used > dominantUsage {
+						summary.Addf("storage: %s take up %.0f%% of the used space", kind, 100*bytes/used)
+					}
+				}
+			}
+		},
+	}
+}
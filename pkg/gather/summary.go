@@ -0,0 +1,36 @@
+package gather
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+// Summary collects the notable findings from the gather steps so that the
+// most useful information is available without unpacking the bundle.
+type Summary struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+// Addf records a finding.
+func (s *Summary) Addf(format string, args ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = append(s.lines, fmt.Sprintf(format, args...))
+}
+
+// Lines returns the recorded findings in the order they were added.
+func (s *Summary) Lines() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.lines...)
+}
+
+// WriteFile writes the findings to path, one per line, after a header
+// naming the analyzer version.
+func (s *Summary) WriteFile(path string) error {
+	lines := append([]string{fmt.Sprintf("# analyzer version %d", AnalyzerVersion)}, s.Lines()...)
+	return ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
@@ -0,0 +1,119 @@
+package gather
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var roleRE = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Target is a host collected from directly, rather than through the
+// bootstrap host, with the role it has in the cluster.
+type Target struct {
+	// Role is the role of the host, like "etcd" or "infra". It names the
+	// bundle directory the host is collected into.
+	Role string
+
+	// Address is the hostname or IP used to connect to the host.
+	Address string
+}
+
+// ParseTarget parses a target given as role=address.
+func ParseTarget(s string) (Target, error) {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return Target{}, fmt.Errorf("%q is not of the form role=address", s)
+	}
+	if !roleRE.MatchString(parts[0]) {
+		return Target{}, fmt.Errorf("invalid role %q, roles must consist of lower case alphanumeric characters or '-'", parts[0])
+	}
+	address, port, err := ParseHost(parts[1])
+	if err != nil {
+		return Target{}, err
+	}
+	if port != 0 {
+		return Target{}, fmt.Errorf("%q has a port, the hosts are connected to on port 22", s)
+	}
+	return Target{Role: parts[0], Address: address}, nil
+}
+
+// String returns the target as role=address.
+func (t Target) String() string {
+	return t.Role + "=" + t.Address
+}
+
+// ParseHost parses a host given on the command line as a hostname or an IP,
+// optionally followed by :port, in which case IPv6 addresses are enclosed in
+// brackets. Surrounding whitespace and a URL scheme, like the one of
+// ssh://10.0.0.5, are stripped. The port is 0 when none is given.
+func ParseHost(s string) (host string, port int, err error) {
+	host = strings.TrimSpace(s)
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = strings.TrimSuffix(host[i+len("://"):], "/")
+	}
+	if host == "" {
+		return "", 0, errors.New("the host is empty")
+	}
+	if strings.Contains(host, "/") {
+		return "", 0, fmt.Errorf("%q is not a hostname or IP address", host)
+	}
+
+	switch {
+	case strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]"):
+		host = host[1 : len(host)-1]
+	case strings.HasPrefix(host, "[") || strings.Count(host, ":") == 1:
+		var portString string
+		if host, portString, err = net.SplitHostPort(host); err != nil {
+			return "", 0, err
+		}
+		if port, err = strconv.Atoi(portString); err != nil || port < 1 || port > 65535 {
+			return "", 0, fmt.Errorf("invalid port %q", portString)
+		}
+	}
+
+	if net.ParseIP(host) != nil {
+		return host, port, nil
+	}
+	if strings.Contains(host, ":") || strings.Trim(host, "0123456789.") == "" {
+		return "", 0, fmt.Errorf("%q is not a valid IP address", host)
+	}
+	if len(validation.IsDNS1123Subdomain(strings.ToLower(host))) > 0 {
+		return "", 0, fmt.Errorf("%q is not a valid hostname or IP address", host)
+	}
+	return host, port, nil
+}
+
+// NormalizeAddresses returns addresses without surrounding whitespace, empty
+// and duplicate entries, like those of hosts whose address is not assigned
+// yet, sorted with IP addresses in numeric order before hostnames.
+func NormalizeAddresses(addresses []string) []string {
+	seen := map[string]bool{}
+	normalized := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		address = strings.TrimSpace(address)
+		if address == "" || seen[address] {
+			continue
+		}
+		seen[address] = true
+		normalized = append(normalized, address)
+	}
+	sort.Slice(normalized, func(i, j int) bool {
+		a, b := net.ParseIP(normalized[i]), net.ParseIP(normalized[j])
+		switch {
+		case a != nil && b != nil:
+			return bytes.Compare(a.To16(), b.To16()) < 0
+		case a != nil || b != nil:
+			return a != nil
+		}
+		return normalized[i] < normalized[j]
+	})
+	return normalized
+}
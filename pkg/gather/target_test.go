@@ -0,0 +1,76 @@
+package gather
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHost(t *testing.T) {
+	cases := []struct {
+		value string
+		host  string
+		port  int
+		err   string
+	}{
+		{value: "10.0.0.5", host: "10.0.0.5"},
+		{value: " 10.0.0.5\n", host: "10.0.0.5"},
+		{value: "10.0.0.5:2200", host: "10.0.0.5", port: 2200},
+		{value: "https://10.0.0.5/", host: "10.0.0.5"},
+		{value: "fd00::5", host: "fd00::5"},
+		{value: "[fd00::5]", host: "fd00::5"},
+		{value: "[fd00::5]:2200", host: "fd00::5", port: 2200},
+		{value: "bootstrap.example.com", host: "bootstrap.example.com"},
+		{value: "Bootstrap.example.com:22", host: "Bootstrap.example.com", port: 22},
+		{value: "", err: "the host is empty"},
+		{value: "10.0.0.5:ssh", err: `invalid port "ssh"`},
+		{value: "10.0.0.5:70000", err: `invalid port "70000"`},
+		{value: "10.0.0.256", err: `"10.0.0.256" is not a valid IP address`},
+		{value: "fd00::5::1", err: `"fd00::5::1" is not a valid IP address`},
+		{value: "boot_strap", err: `"boot_strap" is not a valid hostname or IP address`},
+		{value: "10.0.0.5/24", err: `"10.0.0.5/24" is not a hostname or IP address`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			host, port, err := ParseHost(tc.value)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.host, host)
+			assert.Equal(t, tc.port, port)
+		})
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	target, err := ParseTarget("etcd=[fd00::7]")
+	assert.NoError(t, err)
+	assert.Equal(t, Target{Role: "etcd", Address: "fd00::7"}, target)
+
+	_, err = ParseTarget("etcd")
+	assert.EqualError(t, err, `"etcd" is not of the form role=address`)
+
+	_, err = ParseTarget("etcd=10.0.0.7:2200")
+	assert.EqualError(t, err, `"etcd=10.0.0.7:2200" has a port, the hosts are connected to on port 22`)
+}
+
+func TestNormalizeAddresses(t *testing.T) {
+	cases := []struct {
+		name      string
+		addresses []string
+		expected  []string
+	}{
+		{name: "empty", addresses: nil, expected: []string{}},
+		{name: "unassigned", addresses: []string{"", " ", "\t"}, expected: []string{}},
+		{name: "duplicates", addresses: []string{"10.0.0.6", "10.0.0.5", " 10.0.0.6 ", "10.0.0.5"}, expected: []string{"10.0.0.5", "10.0.0.6"}},
+		{name: "numeric order", addresses: []string{"10.0.0.10", "10.0.0.9", "fd00::1"}, expected: []string{"10.0.0.9", "10.0.0.10", "fd00::1"}},
+		{name: "hostnames", addresses: []string{"master-1.example.com", "10.0.0.5", "master-0.example.com"}, expected: []string{"10.0.0.5", "master-0.example.com", "master-1.example.com"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, NormalizeAddresses(tc.addresses))
+		})
+	}
+}
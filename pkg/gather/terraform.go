@@ -0,0 +1,126 @@
+package gather
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// logfmtMessage matches the message of a logrus text formatter line.
+	logfmtMessage = regexp.MustCompile(`\blevel=(\w+) msg="((?:[^"\\]|\\.)*)"`)
+
+	// terraformResourceRange matches the source range terraform reports
+	// with a diagnostic about a resource.
+	terraformResourceRange = regexp.MustCompile(`^\s*on (\S+) line \d+, in resource "([^"]+)" "([^"]+)":`)
+)
+
+// TerraformStep returns a step that records the last terraform error logged
+// in the installer log at installLog, and copies the terraform debug log
+// at debugLog into the bundle. debugLog may be empty, in which case only
+// the error is recorded. The host is not used.
+func TerraformStep(installLog, debugLog string) Step {
+	return Step{
+		Name: "terraform",
+		Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+			if debugLog != "" {
+				if err := copyFile(debugLog, filepath.Join(dir, "terraform.log")); err != nil {
+					if !os.IsNotExist(err) {
+						return fmt.Errorf("failed to copy the terraform debug log: %w", err)
+					}
+					summary.Addf("terraform: there is no debug log at %s, run the installer with TF_LOG=debug and TF_LOG_PATH=%s to create it", debugLog, debugLog)
+				}
+			}
+
+			f, err := os.Open(installLog)
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			defer f.Close()
+			resource, message, err := terraformFailure(f)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", installLog, err)
+			}
+			if message == "" {
+				return nil
+			}
+			data := fmt.Sprintf("resource=%s\nerror=%s\n", resource, message)
+			return ioutil.WriteFile(filepath.Join(dir, "failure.txt"), []byte(data), 0644)
+		},
+	}
+}
+
+// TerraformAnalyzer returns an analyzer that reports the resource that
+// terraform failed to create, as recorded by TerraformStep.
+func TerraformAnalyzer() Analyzer {
+	return Analyzer{
+		Name:  "terraform",
+		Files: []string{"terraform/failure.txt"},
+		Analyze: func(files map[string][]byte, summary *Summary) {
+			for _, data := range files {
+				failure := parseKeyValues(data)
+				if failure["resource"] == "" {
+					summary.Addf("terraform: infra creation failed: %s", failure["error"])
+					continue
+				}
+				summary.Addf("terraform: infra creation failed at resource %s: %s", failure["resource"], failure["error"])
+			}
+		},
+	}
+}
+
+// terraformFailure returns the last error terraform logged to the installer
+// log read from r, and the address of the resource it is about, if any.
+func terraformFailure(r io.Reader) (resource, message string, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		match := logfmtMessage.FindStringSubmatch(scanner.Text())
+		if match == nil || match[1] != "error" {
+			continue
+		}
+		msg, err := strconv.Unquote(`"` + match[2] + `"`)
+		if err != nil {
+			continue
+		}
+		if strings.HasPrefix(msg, "Error: ") {
+			resource, message = "", strings.TrimSpace(strings.TrimPrefix(msg, "Error: "))
+			continue
+		}
+		if m := terraformResourceRange.FindStringSubmatch(msg); m != nil && message != "" && resource == "" {
+			resource = m[2] + "." + m[3]
+			if module := filepath.Base(filepath.Dir(m[1])); !strings.HasPrefix(module, "openshift-install-") {
+				resource = "module." + module + "." + resource
+			}
+		}
+	}
+	return resource, message, scanner.Err()
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,56 @@
+package gather
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerraformFailure(t *testing.T) {
+	cases := []struct {
+		name     string
+		log      string
+		resource string
+		message  string
+	}{
+		{
+			name: "no error",
+			log:  `time="2019-10-01T10:00:00Z" level=info msg="Creating infrastructure resources..."`,
+		},
+		{
+			name: "module resource",
+			log: `time="2019-10-01T10:00:00Z" level=info msg="Creating infrastructure resources..."
+time="2019-10-01T10:05:00Z" level=error
+time="2019-10-01T10:05:00Z" level=error msg="Error: Error creating VPC: VpcLimitExceeded: The maximum number of VPCs has been reached."
+time="2019-10-01T10:05:00Z" level=error
+time="2019-10-01T10:05:00Z" level=error msg="  on ../tmp/openshift-install-348626978/vpc/vpc.tf line 1, in resource \"aws_vpc\" \"new_vpc\":"
+time="2019-10-01T10:05:00Z" level=error msg="   1: resource \"aws_vpc\" \"new_vpc\" {"
+time="2019-10-01T10:05:00Z" level=fatal msg="failed to fetch Cluster: failed to generate asset \"Cluster\": failed to create cluster: failed to apply using Terraform"`,
+			resource: "module.vpc.aws_vpc.new_vpc",
+			message:  "Error creating VPC: VpcLimitExceeded: The maximum number of VPCs has been reached.",
+		},
+		{
+			name: "root resource",
+			log: `time="2019-10-01T10:05:00Z" level=error msg="Error: Error creating IAM role: EntityAlreadyExists"
+time="2019-10-01T10:05:00Z" level=error msg="  on /tmp/openshift-install-348626978/main.tf line 9, in resource \"aws_iam_role\" \"bootstrap\":"`,
+			resource: "aws_iam_role.bootstrap",
+			message:  "Error creating IAM role: EntityAlreadyExists",
+		},
+		{
+			name: "last error without a resource",
+			log: `time="2019-10-01T10:05:00Z" level=error msg="Error: Error creating VPC: VpcLimitExceeded"
+time="2019-10-01T10:05:00Z" level=error msg="  on ../tmp/openshift-install-348626978/vpc/vpc.tf line 1, in resource \"aws_vpc\" \"new_vpc\":"
+time="2019-10-01T11:05:00Z" level=error msg="Error: error configuring Terraform AWS Provider: no valid credential sources found"`,
+			message: "error configuring Terraform AWS Provider: no valid credential sources found",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resource, message, err := terraformFailure(strings.NewReader(tc.log))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.resource, resource)
+			assert.Equal(t, tc.message, message)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package gather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// UploadURL is an object store location bundles are uploaded to, given as
+// s3://bucket/prefix or gs://bucket/prefix.
+type UploadURL struct {
+	// Scheme is "s3" or "gs".
+	Scheme string
+	Bucket string
+	Prefix string
+}
+
+// Uploader uploads the contents of r as the object key of bucket.
+type Uploader func(ctx context.Context, bucket, key string, r io.Reader) error
+
+// ParseUploadURL parses an s3:// or gs:// URL of a bucket, with an optional
+// prefix that the uploaded objects are put under.
+func ParseUploadURL(s string) (*UploadURL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "s3" && u.Scheme != "gs" {
+		return nil, fmt.Errorf("unsupported scheme %q, use s3:// or gs://", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, errors.New("the bucket is empty")
+	}
+	return &UploadURL{Scheme: u.Scheme, Bucket: u.Host, Prefix: strings.Trim(u.Path, "/")}, nil
+}
+
+// UploadBundle uploads the file at file with upload into the prefix of u,
+// keeping its name, and returns the URL of the uploaded object. The file
+// is left in place.
+func UploadBundle(ctx context.Context, upload Uploader, u *UploadURL, file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	key := path.Join(u.Prefix, filepath.Base(file))
+	object := fmt.Sprintf("%s://%s/%s", u.Scheme, u.Bucket, key)
+	if err := upload(ctx, u.Bucket, key, f); err != nil {
+		return "", fmt.Errorf("failed to upload %s to %s: %w", file, object, err)
+	}
+	return object, nil
+}
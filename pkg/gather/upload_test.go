@@ -0,0 +1,67 @@
+package gather
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUploadURL(t *testing.T) {
+	cases := []struct {
+		url      string
+		expected *UploadURL
+		err      string
+	}{
+		{url: "s3://bundles", expected: &UploadURL{Scheme: "s3", Bucket: "bundles"}},
+		{url: "s3://bundles/ci/run-1/", expected: &UploadURL{Scheme: "s3", Bucket: "bundles", Prefix: "ci/run-1"}},
+		{url: "gs://bundles/ci", expected: &UploadURL{Scheme: "gs", Bucket: "bundles", Prefix: "ci"}},
+		{url: "https://bundles/ci", err: `unsupported scheme "https", use s3:// or gs://`},
+		{url: "s3:///ci", err: "the bucket is empty"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.url, func(t *testing.T) {
+			u, err := ParseUploadURL(tc.url)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, u)
+		})
+	}
+}
+
+func TestUploadBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "log-bundle-20201015070000.tar.gz")
+	if err := ioutil.WriteFile(file, []byte("bundle"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	u := &UploadURL{Scheme: "gs", Bucket: "bundles", Prefix: "ci"}
+
+	uploaded := map[string]string{}
+	object, err := UploadBundle(context.Background(), func(_ context.Context, bucket, key string, r io.Reader) error {
+		data, err := ioutil.ReadAll(r)
+		uploaded[bucket+"/"+key] = string(data)
+		return err
+	}, u, file)
+	assert.NoError(t, err)
+	assert.Equal(t, "gs://bundles/ci/log-bundle-20201015070000.tar.gz", object)
+	assert.Equal(t, map[string]string{"bundles/ci/log-bundle-20201015070000.tar.gz": "bundle"}, uploaded)
+
+	_, err = UploadBundle(context.Background(), func(context.Context, string, string, io.Reader) error {
+		return errors.New("access denied")
+	}, u, file)
+	assert.EqualError(t, err, "failed to upload "+file+" to gs://bundles/ci/log-bundle-20201015070000.tar.gz: access denied")
+	assert.FileExists(t, file)
+}
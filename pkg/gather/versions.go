@@ -0,0 +1,61 @@
+package gather
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// VersionsFileName is the name of the file in the bundle recording the
+// versions the cluster was installed with.
+const VersionsFileName = "versions.txt"
+
+// VersionUnavailable is recorded for the versions which could not be read.
+const VersionUnavailable = "unavailable"
+
+// Versions are the versions of the installer, the release and the operating
+// system of the bootstrap host the cluster was installed with.
+type Versions struct {
+	Installer    string
+	ReleaseImage string
+
+	// OSRelease is /etc/os-release of the bootstrap host.
+	OSRelease string
+
+	// RPMOstreeStatus is the output of rpm-ostree status on the bootstrap
+	// host, which names the deployed boot image.
+	RPMOstreeStatus string
+}
+
+// ReadHost reads the operating system versions of host, recording
+// VersionUnavailable for those which cannot be read.
+func (v *Versions) ReadHost(ctx context.Context, host *Host) {
+	v.OSRelease = hostOutput(ctx, host, "cat /etc/os-release")
+	v.RPMOstreeStatus = hostOutput(ctx, host, "rpm-ostree status")
+}
+
+func hostOutput(ctx context.Context, host *Host, command string) string {
+	out, err := gatherssh.Output(ctx, host.Client, command)
+	if err != nil || len(strings.TrimSpace(string(out))) == 0 {
+		return VersionUnavailable
+	}
+	return strings.TrimRight(string(out), "\n")
+}
+
+// WriteFile writes the versions to path, the installer and release image on
+// a line each, followed by the output read from the bootstrap host. Empty
+// versions are written as VersionUnavailable.
+func (v *Versions) WriteFile(path string) error {
+	value := func(s string) string {
+		if s == "" {
+			return VersionUnavailable
+		}
+		return s
+	}
+	content := fmt.Sprintf("installer: %s\nrelease image: %s\n\n# /etc/os-release\n%s\n\n# rpm-ostree status\n%s\n",
+		value(v.Installer), value(v.ReleaseImage), value(v.OSRelease), value(v.RPMOstreeStatus))
+	return ioutil.WriteFile(path, []byte(content), 0644)
+}
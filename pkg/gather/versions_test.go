@@ -0,0 +1,40 @@
+package gather
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionsWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "versions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, VersionsFileName)
+
+	versions := &Versions{
+		Installer:    "v4.2.0",
+		ReleaseImage: "quay.io/openshift-release-dev/ocp-release:4.2.0",
+		OSRelease:    `NAME="Red Hat Enterprise Linux CoreOS"` + "\nVERSION=\"42.80.20191002.0\"",
+	}
+	if !assert.NoError(t, versions.WriteFile(path)) {
+		return
+	}
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, `installer: v4.2.0
+release image: quay.io/openshift-release-dev/ocp-release:4.2.0
+
+# /etc/os-release
+NAME="Red Hat Enterprise Linux CoreOS"
+VERSION="42.80.20191002.0"
+
+# rpm-ostree status
+unavailable
+`, string(data))
+}
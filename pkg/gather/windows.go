@@ -0,0 +1,72 @@
+package gather
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	gatherssh "github.com/openshift/installer/pkg/gather/ssh"
+)
+
+// WindowsUser is the user the Windows Machine Config Operator configures
+// for SSH access to Windows nodes.
+const WindowsUser = "Administrator"
+
+// maxWindowsEvents is the number of most recent entries collected from
+// each Windows event log.
+const maxWindowsEvents = 1000
+
+// windowsLogs are the logs written by the components the Windows Machine
+// Config Operator installs.
+var windowsLogs = map[string]string{
+	"kubelet.log":        `C:\var\log\kubelet\kubelet.log`,
+	"kube-proxy.log":     `C:\var\log\kube-proxy\kube-proxy.log`,
+	"hybrid-overlay.log": `C:\var\log\hybrid-overlay\hybrid-overlay.log`,
+}
+
+// WindowsSteps returns the steps collecting data from a Windows node over
+// the Windows OpenSSH server.
+func WindowsSteps() []Step {
+	return []Step{
+		{
+			Name: "events",
+			Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+				for _, log := range []string{"System", "Application"} {
+					command := fmt.Sprintf("Get-WinEvent -LogName %s -MaxEvents %d | Format-List TimeCreated,Id,LevelDisplayName,ProviderName,Message", log, maxWindowsEvents)
+					if err := writePowerShellOutput(ctx, host, command, filepath.Join(dir, strings.ToLower(log)+".txt")); err != nil {
+						return fmt.Errorf("failed to collect the %s event log: %w", log, err)
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Name: "wmco",
+			Run: func(ctx context.Context, host *Host, dir string, summary *Summary) error {
+				command := "Get-Service kubelet,kube-proxy,hybrid-overlay-node -ErrorAction SilentlyContinue | Format-Table -AutoSize Name,Status,StartType"
+				if err := writePowerShellOutput(ctx, host, command, filepath.Join(dir, "services.txt")); err != nil {
+					return fmt.Errorf("failed to collect the service status: %w", err)
+				}
+				for file, path := range windowsLogs {
+					command := fmt.Sprintf("if (Test-Path '%s') { Get-Content -Tail 10000 '%s' }", path, path)
+					if err := writePowerShellOutput(ctx, host, command, filepath.Join(dir, file)); err != nil {
+						return fmt.Errorf("failed to collect %s: %w", path, err)
+					}
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// writePowerShellOutput runs a PowerShell command on host and writes its
+// output to file.
+func writePowerShellOutput(ctx context.Context, host *Host, command, file string) error {
+	out, err := gatherssh.OutputWithShell(ctx, host.Client, "", fmt.Sprintf(`powershell -NoProfile -NonInteractive -Command "%s"`, command))
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, out, 0644)
+}
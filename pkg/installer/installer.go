@@ -0,0 +1,227 @@
+// Package installer creates and destroys clusters, and gathers the logs of
+// those failing to bootstrap, like the openshift-install commands, for the
+// programs embedding the installer. Its functions log through the standard
+// logrus logger, and report their progress through the callbacks of their
+// options.
+package installer
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/openshift/installer/pkg/asset"
+	assetstore "github.com/openshift/installer/pkg/asset/store"
+	targetassets "github.com/openshift/installer/pkg/asset/targets"
+	"github.com/openshift/installer/pkg/destroy"
+	_ "github.com/openshift/installer/pkg/destroy/azure"
+	destroybootstrap "github.com/openshift/installer/pkg/destroy/bootstrap"
+	_ "github.com/openshift/installer/pkg/destroy/libvirt"
+	_ "github.com/openshift/installer/pkg/destroy/openstack"
+	gatherbootstrap "github.com/openshift/installer/pkg/gather/bootstrap"
+)
+
+// Phase is a phase of Create, Destroy or GatherBootstrap.
+type Phase string
+
+const (
+	// PhaseInfrastructure generates the assets and creates the
+	// infrastructure of the cluster with Terraform.
+	PhaseInfrastructure Phase = "infrastructure"
+
+	// PhaseBootstrap waits for the cluster to bootstrap.
+	PhaseBootstrap Phase = "bootstrap"
+
+	// PhaseDestroyBootstrap removes the bootstrap resources.
+	PhaseDestroyBootstrap Phase = "destroy-bootstrap"
+
+	// PhaseInstall waits for the cluster to initialize.
+	PhaseInstall Phase = "install"
+
+	// PhaseDestroy destroys the cluster.
+	PhaseDestroy Phase = "destroy"
+
+	// PhaseGather gathers the bootstrap logs.
+	PhaseGather Phase = "gather"
+
+	// PhaseComplete is the phase of the last event of a successful call.
+	PhaseComplete Phase = "complete"
+)
+
+// Event is the start of a phase, reported to a ProgressFunc.
+type Event struct {
+	Phase   Phase
+	Message string
+	Time    time.Time
+}
+
+// ProgressFunc is called with the events of a function as it goes through
+// its phases.
+type ProgressFunc func(Event)
+
+func (f ProgressFunc) report(phase Phase, message string) {
+	if f != nil {
+		f(Event{Phase: phase, Message: message, Time: time.Now()})
+	}
+}
+
+// CreateOptions are the options of Create.
+type CreateOptions struct {
+	// Progress, if set, is called at the start of each phase.
+	Progress ProgressFunc
+
+	// OnBootstrapFailure, if set, is called with the error when the
+	// cluster fails to bootstrap, before Create returns it, like to
+	// gather the bootstrap logs while the bootstrap host still exists.
+	OnBootstrapFailure func(ctx context.Context, err error)
+}
+
+// Create creates the cluster of the install config in the assets directory
+// dir, like create cluster, and returns the URL of its console. The
+// kubeconfig and the kubeadmin password are written under auth in dir.
+// Generating the assets and running Terraform are not interrupted by ctx,
+// the waits for the cluster are.
+func Create(ctx context.Context, dir string, opts CreateOptions) (string, error) {
+	opts.Progress.report(PhaseInfrastructure, "Generating the assets and creating the infrastructure")
+	if err := FetchAssets(dir, targetassets.Cluster...); err != nil {
+		return "", err
+	}
+
+	config, err := clientcmd.BuildConfigFromFlags("", filepath.Join(dir, "auth", "kubeconfig"))
+	if err != nil {
+		return "", errors.Wrap(err, "loading kubeconfig")
+	}
+
+	if err := opts.waitForBootstrap(ctx, config, dir); err != nil {
+		return "", err
+	}
+
+	opts.Progress.report(PhaseDestroyBootstrap, "Destroying the bootstrap resources")
+	logrus.Info("Destroying the bootstrap resources...")
+	if err := destroybootstrap.Destroy(dir); err != nil {
+		return "", err
+	}
+
+	opts.Progress.report(PhaseInstall, "Waiting for the cluster to initialize")
+	consoleURL, err := WaitForInstallComplete(ctx, config, dir)
+	if err != nil {
+		return "", err
+	}
+	opts.Progress.report(PhaseComplete, "The cluster is installed, with its console at "+consoleURL)
+	return consoleURL, nil
+}
+
+// waitForBootstrap waits for the cluster of config to bootstrap, like
+// WaitForBootstrapComplete, and calls OnBootstrapFailure with the error
+// when it does not.
+func (opts CreateOptions) waitForBootstrap(ctx context.Context, config *rest.Config, dir string) error {
+	opts.Progress.report(PhaseBootstrap, "Waiting for the cluster to bootstrap")
+	if err := WaitForBootstrapComplete(ctx, config, dir); err != nil {
+		if opts.OnBootstrapFailure != nil {
+			opts.OnBootstrapFailure(ctx, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// FetchAssets generates the targets, along with the assets they depend on,
+// in the assets directory dir, and writes them to it. A target is written
+// even when it fails to generate, like the Terraform state of a cluster
+// whose creation failed.
+func FetchAssets(dir string, targets ...asset.WritableAsset) error {
+	assetStore, err := assetstore.NewStore(dir)
+	if err != nil {
+		return errors.Wrap(err, "failed to create asset store")
+	}
+
+	for _, a := range targets {
+		err := assetStore.Fetch(a, targets...)
+		if err != nil {
+			err = errors.Wrapf(err, "failed to fetch %s", a.Name())
+		}
+
+		if err2 := asset.PersistToFile(a, dir); err2 != nil {
+			err2 = errors.Wrapf(err2, "failed to write asset (%s) to disk", a.Name())
+			if err != nil {
+				logrus.Error(err2)
+				return err
+			}
+			return err2
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DestroyOptions are the options of Destroy.
+type DestroyOptions struct {
+	// Progress, if set, is called at the start of each phase.
+	Progress ProgressFunc
+}
+
+// Destroy destroys the cluster of the assets directory dir, like destroy
+// cluster, and removes its assets from dir. The platform destroyers are
+// not interrupted by ctx, which is only checked before they run.
+func Destroy(ctx context.Context, dir string, opts DestroyOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	opts.Progress.report(PhaseDestroy, "Destroying the cluster")
+	destroyer, err := destroy.New(logrus.StandardLogger(), dir)
+	if err != nil {
+		return errors.Wrap(err, "Failed while preparing to destroy cluster")
+	}
+	if err := destroyer.Run(); err != nil {
+		return errors.Wrap(err, "Failed to destroy cluster")
+	}
+
+	store, err := assetstore.NewStore(dir)
+	if err != nil {
+		return errors.Wrap(err, "failed to create asset store")
+	}
+	for _, asset := range targetassets.Cluster {
+		if err := store.Destroy(asset); err != nil {
+			return errors.Wrapf(err, "failed to destroy asset %q", asset.Name())
+		}
+	}
+	// delete the state file as well
+	err = store.DestroyState()
+	if err != nil {
+		return errors.Wrap(err, "failed to remove state file")
+	}
+
+	opts.Progress.report(PhaseComplete, "The cluster is destroyed")
+	return nil
+}
+
+// GatherOptions are the options of GatherBootstrap.
+type GatherOptions struct {
+	gatherbootstrap.Options
+
+	// Progress, if set, is called at the start of each phase.
+	Progress ProgressFunc
+}
+
+// GatherBootstrap gathers the logs of the bootstrap and control plane hosts
+// of the cluster of the assets directory dir, like gather bootstrap, and
+// returns the path of the log bundle. The errors map to the exit codes of
+// gather bootstrap with gather.ExitCode.
+func GatherBootstrap(ctx context.Context, dir string, opts GatherOptions) (string, error) {
+	opts.Progress.report(PhaseGather, "Gathering the bootstrap logs")
+	bundle, err := gatherbootstrap.Gather(ctx, dir, opts.Options)
+	if err != nil {
+		return bundle, err
+	}
+	opts.Progress.report(PhaseComplete, "The bootstrap logs are gathered in "+bundle)
+	return bundle, nil
+}
@@ -0,0 +1,70 @@
+package installer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+func TestWaitForBootstrap(t *testing.T) {
+	cases := []struct {
+		name      string
+		responses map[string]interface{}
+		failed    bool
+	}{
+		{
+			name: "bootstrapped",
+			responses: map[string]interface{}{
+				versionPath:    version.Info{GitVersion: "v1.16.0"},
+				configMapsPath: bootstrapConfigMaps("complete"),
+			},
+		},
+		{
+			name:      "bootstrap failed",
+			responses: map[string]interface{}{},
+			failed:    true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, stop := fakeAPIServer(t, tc.responses)
+			defer stop()
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+
+			var phases []Phase
+			var failures []error
+			opts := CreateOptions{
+				Progress: func(event Event) {
+					phases = append(phases, event.Phase)
+				},
+				OnBootstrapFailure: func(hookCtx context.Context, err error) {
+					assert.Equal(t, ctx, hookCtx)
+					failures = append(failures, err)
+				},
+			}
+			err := opts.waitForBootstrap(ctx, config, "")
+			assert.Equal(t, []Phase{PhaseBootstrap}, phases)
+			if !tc.failed {
+				assert.NoError(t, err)
+				assert.Empty(t, failures, "the hook was called without a bootstrap failure")
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Equal(t, []error{err}, failures, "the hook was not called with the bootstrap failure")
+			}
+		})
+	}
+}
+
+func TestWaitForBootstrapWithoutHook(t *testing.T) {
+	config, stop := fakeAPIServer(t, map[string]interface{}{})
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	assert.Error(t, CreateOptions{}.waitForBootstrap(ctx, config, ""))
+}
@@ -0,0 +1,220 @@
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	versionPath         = "/version"
+	configMapsPath      = "/api/v1/namespaces/kube-system/configmaps"
+	clusterVersionsPath = "/apis/config.openshift.io/v1/clusterversions"
+	routesPath          = "/apis/route.openshift.io/v1/namespaces/openshift-console/routes"
+)
+
+// fakeAPIServer serves responses, by path, as the Kubernetes API, and
+// watches which send no events until the client is done. The other paths
+// are not found. It returns the config of a client of the server, and the
+// function stopping it.
+func fakeAPIServer(t *testing.T, responses map[string]interface{}) (*rest.Config, func()) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("watch") == "true" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+			case <-done:
+			}
+			return
+		}
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			t.Errorf("failed to encode the response to %s: %v", r.URL.Path, err)
+		}
+	}))
+	return &rest.Config{Host: server.URL}, func() {
+		close(done)
+		server.Close()
+	}
+}
+
+func bootstrapConfigMaps(status string) *corev1.ConfigMapList {
+	return &corev1.ConfigMapList{
+		TypeMeta: metav1.TypeMeta{Kind: "ConfigMapList", APIVersion: "v1"},
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items: []corev1.ConfigMap{{
+			ObjectMeta: metav1.ObjectMeta{Name: "bootstrap", Namespace: "kube-system"},
+			Data:       map[string]string{"status": status},
+		}},
+	}
+}
+
+func TestWaitForBootstrapComplete(t *testing.T) {
+	cases := []struct {
+		name      string
+		responses map[string]interface{}
+		err       string
+	}{
+		{
+			name: "complete",
+			responses: map[string]interface{}{
+				versionPath:    version.Info{GitVersion: "v1.16.0"},
+				configMapsPath: bootstrapConfigMaps("complete"),
+			},
+		},
+		{
+			name:      "API down",
+			responses: map[string]interface{}{},
+			err:       "waiting for Kubernetes API: context deadline exceeded",
+		},
+		{
+			name: "bootstrapping",
+			responses: map[string]interface{}{
+				versionPath:    version.Info{GitVersion: "v1.16.0"},
+				configMapsPath: bootstrapConfigMaps("progressing"),
+			},
+			err: "failed to wait for bootstrapping to complete",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, stop := fakeAPIServer(t, tc.responses)
+			defer stop()
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+
+			err := WaitForBootstrapComplete(ctx, config, "")
+			if tc.err == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.err)
+			}
+		})
+	}
+}
+
+func clusterVersions(conditions ...configv1.ClusterOperatorStatusCondition) *configv1.ClusterVersionList {
+	return &configv1.ClusterVersionList{
+		TypeMeta: metav1.TypeMeta{Kind: "ClusterVersionList", APIVersion: "config.openshift.io/v1"},
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items: []configv1.ClusterVersion{{
+			ObjectMeta: metav1.ObjectMeta{Name: "version"},
+			Status:     configv1.ClusterVersionStatus{Conditions: conditions},
+		}},
+	}
+}
+
+func TestWaitForInitializedCluster(t *testing.T) {
+	cases := []struct {
+		name      string
+		responses map[string]interface{}
+		err       string
+	}{
+		{
+			name: "available",
+			responses: map[string]interface{}{
+				clusterVersionsPath: clusterVersions(configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue}),
+			},
+		},
+		{
+			name: "failing",
+			responses: map[string]interface{}{
+				clusterVersionsPath: clusterVersions(configv1.ClusterOperatorStatusCondition{Type: "Failing", Status: configv1.ConditionTrue, Message: "Cluster operator network is still updating"}),
+			},
+			err: "failed to initialize the cluster: Cluster operator network is still updating",
+		},
+		{
+			name:      "no cluster version",
+			responses: map[string]interface{}{},
+			err:       "failed to initialize the cluster",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, stop := fakeAPIServer(t, tc.responses)
+			defer stop()
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+
+			err := waitForInitializedCluster(ctx, config)
+			if tc.err == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.err)
+			}
+		})
+	}
+}
+
+func consoleRoutes(names ...string) *routev1.RouteList {
+	routes := &routev1.RouteList{
+		TypeMeta: metav1.TypeMeta{Kind: "RouteList", APIVersion: "route.openshift.io/v1"},
+	}
+	for _, name := range names {
+		routes.Items = append(routes.Items, routev1.Route{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "openshift-console"},
+			Spec:       routev1.RouteSpec{Host: name + "-openshift-console.apps.example.com"},
+		})
+	}
+	return routes
+}
+
+func TestWaitForConsole(t *testing.T) {
+	cases := []struct {
+		name      string
+		responses map[string]interface{}
+		url       string
+		err       string
+	}{
+		{
+			name:      "console",
+			responses: map[string]interface{}{routesPath: consoleRoutes("downloads", "console")},
+			url:       "https://console-openshift-console.apps.example.com",
+		},
+		{
+			name:      "no routes",
+			responses: map[string]interface{}{routesPath: consoleRoutes()},
+			err:       "waiting for openshift-console URL: context deadline exceeded",
+		},
+		{
+			name:      "no console route",
+			responses: map[string]interface{}{routesPath: consoleRoutes("downloads")},
+			err:       "could not get openshift-console URL",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, stop := fakeAPIServer(t, tc.responses)
+			defer stop()
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+
+			url, err := waitForConsole(ctx, config, "")
+			assert.Equal(t, tc.url, url)
+			if tc.err == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.err)
+			}
+		})
+	}
+}
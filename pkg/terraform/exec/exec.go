@@ -35,10 +35,22 @@ var commands = map[string]cmdFunc{
 func runner(cmd string, dir string, args []string, stdout, stderr io.Writer) int {
 	lf := ioutil.Discard
 	if level := logging.LogLevel(); level != "" {
+		// Like terraform, send the logs to TF_LOG_PATH when it is set
+		// instead of mixing them into the installer output.
+		w := stdout
+		if path := os.Getenv(logging.EnvLogFile); path != "" {
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error opening %s for the Terraform logs: %v", path, err)
+				return 1
+			}
+			defer f.Close()
+			w = f
+		}
 		lf = &logutils.LevelFilter{
 			Levels:   logging.ValidLevels,
 			MinLevel: logutils.LogLevel(level),
-			Writer:   stdout,
+			Writer:   w,
 		}
 	}
 	log.SetOutput(lf)
@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"os"
 
+	backendInit "github.com/hashicorp/terraform/backend/init"
 	"github.com/hashicorp/terraform/states/statefile"
+	"github.com/hashicorp/terraform/states/statemgr"
 	"github.com/pkg/errors"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
 )
 
 // ReadState reads the terraform state from file and returns the contents in bytes
@@ -30,3 +33,45 @@ func ReadState(file string) ([]byte, error) {
 	}
 	return out.Bytes(), nil
 }
+
+// ReadRemoteState reads the terraform state of workspace from the backend of
+// backendType, like "s3" or "gcs", configured with config, a JSON object of
+// the backend arguments, and returns the contents like ReadState.
+func ReadRemoteState(backendType string, config []byte, workspace string) ([]byte, error) {
+	backendInit.Init(nil)
+	newBackend := backendInit.Backend(backendType)
+	if newBackend == nil {
+		return nil, errors.Errorf("unsupported backend %q", backendType)
+	}
+	b := newBackend()
+
+	value, err := ctyjson.Unmarshal(config, b.ConfigSchema().ImpliedType())
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s backend config", backendType)
+	}
+	value, diags := b.PrepareConfig(value)
+	if diags.HasErrors() {
+		return nil, errors.Wrapf(diags.Err(), "invalid %s backend config", backendType)
+	}
+	if diags := b.Configure(value); diags.HasErrors() {
+		return nil, errors.Wrapf(diags.Err(), "failed to configure the %s backend", backendType)
+	}
+
+	mgr, err := b.StateMgr(workspace)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open the state of workspace %q", workspace)
+	}
+	state, err := statemgr.RefreshAndRead(mgr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the state of workspace %q", workspace)
+	}
+	if state == nil {
+		return nil, errors.Errorf("workspace %q has no state", workspace)
+	}
+
+	out := bytes.Buffer{}
+	if err := statefile.Write(statefile.New(state, "", 0), &out); err != nil {
+		return nil, errors.Wrapf(err, "failed to write statefile")
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,27 @@
+package exec
+
+import (
+	"context"
+	"io"
+
+	"cloud.google.com/go/storage"
+	"github.com/pkg/errors"
+)
+
+// UploadGCS uploads the contents of r as the object key of the GCS bucket,
+// with the client of the terraform gcs backend, using the application
+// default credentials.
+func UploadGCS(ctx context.Context, bucket, key string, r io.Reader) error {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the GCS client")
+	}
+	defer client.Close()
+
+	w := client.Bucket(bucket).Object(key).NewWriter(ctx)
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
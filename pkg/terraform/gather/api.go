@@ -0,0 +1,97 @@
+package gather
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultAPIConcurrency is the number of cloud API calls the platform
+// gatherers make at the same time, unless --api-concurrency is given.
+const DefaultAPIConcurrency = 4
+
+// APIErrorClass is the class of an error returned by a cloud API, which
+// decides whether the call is retried.
+type APIErrorClass int
+
+const (
+	// APIErrorOther is an error which is returned as is.
+	APIErrorOther APIErrorClass = iota
+
+	// APIErrorThrottled is a rate limit error, the call is retried after a
+	// backoff.
+	APIErrorThrottled
+
+	// APIErrorAuth is an authentication or authorization error, which no
+	// retry fixes, so it is returned at once.
+	APIErrorAuth
+)
+
+// APIErrorClassifier returns the class of an error returned by the API of a
+// provider.
+type APIErrorClassifier func(err error) APIErrorClass
+
+// APILimiter bounds the number of cloud API calls in flight, and retries
+// the calls the provider throttles with an exponential backoff, so
+// discovering the hosts of large clusters, or of many clusters at once in
+// CI, does not trip the rate limits of the account.
+type APILimiter struct {
+	slots chan struct{}
+
+	// Retries is the number of times a throttled call is retried.
+	Retries int
+
+	// Backoff is the delay before the first retry, doubled for each of
+	// the next ones up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// NewAPILimiter returns a limiter which lets concurrency calls run at the
+// same time, retrying throttled calls 5 times starting from one second.
+func NewAPILimiter(concurrency int) *APILimiter {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &APILimiter{
+		slots:      make(chan struct{}, concurrency),
+		Retries:    5,
+		Backoff:    time.Second,
+		MaxBackoff: 30 * time.Second,
+	}
+}
+
+// Call runs call once a slot of l is free, and runs it again after a
+// backoff while classify says it was throttled, up to l.Retries times.
+// Authentication errors and the other errors are returned at once, as is
+// the error of the last attempt when the retries are exhausted.
+func (l *APILimiter) Call(ctx context.Context, classify APIErrorClassifier, call func(context.Context) error) error {
+	backoff := l.Backoff
+	for attempt := 0; ; attempt++ {
+		err := l.call(ctx, call)
+		if err == nil || attempt >= l.Retries || classify(err) != APIErrorThrottled {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		if backoff *= 2; l.MaxBackoff > 0 && backoff > l.MaxBackoff {
+			backoff = l.MaxBackoff
+		}
+	}
+}
+
+// call runs call in a slot of l, which is freed while waiting to retry.
+func (l *APILimiter) call(ctx context.Context, call func(context.Context) error) error {
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-l.slots }()
+	return call(ctx)
+}
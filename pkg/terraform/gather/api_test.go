@@ -0,0 +1,111 @@
+package gather
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errThrottled = errors.New("Throttling: Rate exceeded")
+	errAuth      = errors.New("AuthFailure: AWS was not able to validate the provided access credentials")
+	errOther     = errors.New("InvalidParameterValue")
+)
+
+func classifyTestError(err error) APIErrorClass {
+	switch err {
+	case errThrottled:
+		return APIErrorThrottled
+	case errAuth:
+		return APIErrorAuth
+	}
+	return APIErrorOther
+}
+
+func TestAPILimiterCall(t *testing.T) {
+	cases := []struct {
+		name     string
+		errs     []error
+		expected error
+		calls    int
+	}{
+		{name: "success", errs: []error{nil}, calls: 1},
+		{name: "throttled then success", errs: []error{errThrottled, errThrottled, nil}, calls: 3},
+		{name: "throttled past the retries", errs: []error{errThrottled, errThrottled, errThrottled, errThrottled, nil}, expected: errThrottled, calls: 4},
+		{name: "auth error fails fast", errs: []error{errAuth, nil}, expected: errAuth, calls: 1},
+		{name: "auth error after throttling", errs: []error{errThrottled, errAuth, nil}, expected: errAuth, calls: 2},
+		{name: "other error is not retried", errs: []error{errOther, nil}, expected: errOther, calls: 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := NewAPILimiter(1)
+			l.Retries, l.Backoff = 3, time.Millisecond
+			calls := 0
+			err := l.Call(context.Background(), classifyTestError, func(context.Context) error {
+				err := tc.errs[calls]
+				calls++
+				return err
+			})
+			assert.Equal(t, tc.expected, err)
+			assert.Equal(t, tc.calls, calls)
+		})
+	}
+}
+
+func TestAPILimiterBackoff(t *testing.T) {
+	l := NewAPILimiter(1)
+	l.Retries, l.Backoff, l.MaxBackoff = 4, 10*time.Millisecond, 20*time.Millisecond
+	var attempts []time.Time
+	err := l.Call(context.Background(), classifyTestError, func(context.Context) error {
+		attempts = append(attempts, time.Now())
+		return errThrottled
+	})
+	assert.Equal(t, errThrottled, err)
+	if assert.Len(t, attempts, 5) {
+		for i, min := range []time.Duration{10, 20, 20, 20} {
+			assert.True(t, attempts[i+1].Sub(attempts[i]) >= min*time.Millisecond, "retry %d came too early", i+1)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l.Backoff = time.Hour
+	calls := 0
+	err = l.Call(ctx, classifyTestError, func(context.Context) error {
+		calls++
+		cancel()
+		return errThrottled
+	})
+	assert.Equal(t, errThrottled, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestAPILimiterConcurrency(t *testing.T) {
+	l := NewAPILimiter(2)
+	var mu sync.Mutex
+	var running, max int
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Call(context.Background(), classifyTestError, func(context.Context) error {
+				mu.Lock()
+				if running++; running > max {
+					max = running
+				}
+				mu.Unlock()
+				time.Sleep(5 * time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 2, max)
+}
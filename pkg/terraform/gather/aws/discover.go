@@ -0,0 +1,171 @@
+package aws
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pkg/errors"
+
+	awsconfig "github.com/openshift/installer/pkg/asset/installconfig/aws"
+	"github.com/openshift/installer/pkg/terraform/gather"
+	"github.com/openshift/installer/pkg/types"
+)
+
+// authErrorCodes are the codes of the EC2 API errors which no retry fixes.
+var authErrorCodes = map[string]bool{
+	"AuthFailure":           true,
+	"UnauthorizedOperation": true,
+	"InvalidClientTokenId":  true,
+	"SignatureDoesNotMatch": true,
+	"OptInRequired":         true,
+}
+
+// instanceLister is the part of the EC2 API the hosts are discovered with.
+type instanceLister interface {
+	DescribeInstancesPagesWithContext(ctx aws.Context, input *ec2.DescribeInstancesInput, fn func(*ec2.DescribeInstancesOutput, bool) bool, opts ...request.Option) error
+}
+
+// DiscoverHostsFromMetadata returns the addresses of the bootstrap and
+// control plane instances of the cluster of metadata, looked up with the
+// EC2 API by the tags the installer puts on them, for when the terraform
+// state is gone. Like with the terraform state, the bootstrap address is
+// its public IP and the control plane addresses are their private IPs. The
+// bootstrap address is empty when the instance is already destroyed. The
+// API calls are made through limiter, and their retries stop when ctx is
+// done.
+func DiscoverHostsFromMetadata(ctx context.Context, metadata *types.ClusterMetadata, limiter *gather.APILimiter) (bootstrap string, masters []string, err error) {
+	if metadata.AWS == nil {
+		return "", nil, errors.New("no AWS metadata found")
+	}
+	if metadata.InfraID == "" {
+		return "", nil, errors.New("no infrastructure ID found in the metadata")
+	}
+	client, err := newEC2Client(metadata.AWS.Region)
+	if err != nil {
+		return "", nil, err
+	}
+	bootstrap, masters, err = discoverInstances(ctx, limiter, client, metadata.InfraID)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to list the instances of %s in %s", metadata.InfraID, metadata.AWS.Region)
+	}
+	if bootstrap == "" && len(masters) == 0 {
+		return "", nil, errors.Errorf("no bootstrap or control plane instances of %s found in %s", metadata.InfraID, metadata.AWS.Region)
+	}
+	return bootstrap, masters, nil
+}
+
+// BootstrapIPFromAPI returns the public IP of the bootstrap instance of the
+// cluster with infraID in the region of config, looked up with the EC2 API
+// by its tags through limiter, for when the terraform state holds none.
+func BootstrapIPFromAPI(ctx context.Context, limiter *gather.APILimiter, config *types.InstallConfig, infraID string) (string, error) {
+	client, err := newEC2Client(config.Platform.AWS.Region)
+	if err != nil {
+		return "", err
+	}
+	bootstrap, _, err := discoverInstances(ctx, limiter, client, infraID)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list the instances of %s in %s", infraID, config.Platform.AWS.Region)
+	}
+	if bootstrap == "" {
+		return "", errors.Errorf("no bootstrap instance of %s found in %s", infraID, config.Platform.AWS.Region)
+	}
+	return bootstrap, nil
+}
+
+// ControlPlaneIPsFromAPI returns the private IPs of the control plane
+// instances of the cluster with infraID in the region of config, looked up
+// with the EC2 API by their tags through limiter, for when the terraform
+// state holds none.
+func ControlPlaneIPsFromAPI(ctx context.Context, limiter *gather.APILimiter, config *types.InstallConfig, infraID string) ([]string, error) {
+	client, err := newEC2Client(config.Platform.AWS.Region)
+	if err != nil {
+		return nil, err
+	}
+	_, masters, err := discoverInstances(ctx, limiter, client, infraID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the instances of %s in %s", infraID, config.Platform.AWS.Region)
+	}
+	if len(masters) == 0 {
+		return nil, errors.Errorf("no control plane instances of %s found in %s", infraID, config.Platform.AWS.Region)
+	}
+	return masters, nil
+}
+
+func newEC2Client(region string) (*ec2.EC2, error) {
+	ssn, err := awsconfig.GetSession()
+	if err != nil {
+		return nil, err
+	}
+	return ec2.New(ssn, aws.NewConfig().WithRegion(region)), nil
+}
+
+// discoverInstances returns the public IP of the bootstrap instance of the
+// cluster with infraID and the private IPs of its control plane instances,
+// sorted by name, found by the kubernetes.io/cluster/<infraID> and Name
+// tags the installer puts on them. The bootstrap address is empty when
+// there is no bootstrap instance. The listing is made through limiter,
+// which retries it when it is throttled.
+func discoverInstances(ctx context.Context, limiter *gather.APILimiter, client instanceLister, infraID string) (bootstrap string, masters []string, err error) {
+	var names map[string]string
+	err = limiter.Call(ctx, classifyAPIError, func(ctx context.Context) error {
+		bootstrap, masters, names = "", nil, map[string]string{}
+		return listInstances(ctx, client, infraID, func(name string, instance *ec2.Instance) {
+			if name == infraID+"-bootstrap" {
+				bootstrap = aws.StringValue(instance.PublicIpAddress)
+			} else if ip := aws.StringValue(instance.PrivateIpAddress); ip != "" {
+				names[ip] = name
+				masters = append(masters, ip)
+			}
+		})
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	sort.Slice(masters, func(i, j int) bool {
+		return strings.Compare(names[masters[i]], names[masters[j]]) < 0
+	})
+	return bootstrap, masters, nil
+}
+
+// listInstances calls fn with the name and the instance of each running
+// bootstrap or control plane instance of the cluster with infraID.
+func listInstances(ctx context.Context, client instanceLister, infraID string, fn func(name string, instance *ec2.Instance)) error {
+	return client.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("tag:kubernetes.io/cluster/" + infraID), Values: aws.StringSlice([]string{"owned"})},
+			{Name: aws.String("tag:Name"), Values: aws.StringSlice([]string{infraID + "-bootstrap", infraID + "-master-*"})},
+			{Name: aws.String("instance-state-name"), Values: aws.StringSlice([]string{"pending", "running"})},
+		},
+	}, func(out *ec2.DescribeInstancesOutput, _ bool) bool {
+		for _, reservation := range out.Reservations {
+			for _, instance := range reservation.Instances {
+				var name string
+				for _, tag := range instance.Tags {
+					if aws.StringValue(tag.Key) == "Name" {
+						name = aws.StringValue(tag.Value)
+					}
+				}
+				fn(name, instance)
+			}
+		}
+		return true
+	})
+}
+
+// classifyAPIError tells the throttling errors of the EC2 API, retried by
+// the gather.APILimiter, from the authentication and authorization ones,
+// which fail the discovery at once.
+func classifyAPIError(err error) gather.APIErrorClass {
+	if request.IsErrorThrottle(err) {
+		return gather.APIErrorThrottled
+	}
+	if aerr, ok := err.(awserr.Error); ok && (authErrorCodes[aerr.Code()] || request.IsErrorExpiredCreds(err)) {
+		return gather.APIErrorAuth
+	}
+	return gather.APIErrorOther
+}
@@ -0,0 +1,116 @@
+package aws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/installer/pkg/terraform/gather"
+)
+
+// fakeInstanceLister fails with the first of errs not returned yet, and
+// then returns pages of instances.
+type fakeInstanceLister struct {
+	pages []*ec2.DescribeInstancesOutput
+	errs  []error
+	calls int
+	input *ec2.DescribeInstancesInput
+}
+
+func (f *fakeInstanceLister) DescribeInstancesPagesWithContext(_ aws.Context, input *ec2.DescribeInstancesInput, fn func(*ec2.DescribeInstancesOutput, bool) bool, _ ...request.Option) error {
+	f.input = input
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return err
+	}
+	for i, page := range f.pages {
+		if !fn(page, i == len(f.pages)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func instance(name, publicIP, privateIP string) *ec2.Instance {
+	return &ec2.Instance{
+		Tags:             []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String(name)}},
+		PublicIpAddress:  aws.String(publicIP),
+		PrivateIpAddress: aws.String(privateIP),
+	}
+}
+
+func TestDiscoverInstances(t *testing.T) {
+	limiter := gather.NewAPILimiter(1)
+	limiter.Retries, limiter.Backoff = 2, time.Millisecond
+
+	master0 := &ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{{Instances: []*ec2.Instance{
+		instance("test-x7k2p-master-0", "", "10.0.1.7"),
+	}}}}
+	throttled := awserr.New("RequestLimitExceeded", "Request limit exceeded.", nil)
+
+	cases := []struct {
+		name      string
+		lister    *fakeInstanceLister
+		bootstrap string
+		masters   []string
+		err       string
+		calls     int
+	}{{
+		name: "bootstrap and masters",
+		lister: &fakeInstanceLister{pages: []*ec2.DescribeInstancesOutput{
+			{Reservations: []*ec2.Reservation{{Instances: []*ec2.Instance{
+				instance("test-x7k2p-master-2", "", "10.0.1.9"),
+				instance("test-x7k2p-bootstrap", "3.4.5.6", "10.0.0.5"),
+			}}}},
+			{Reservations: []*ec2.Reservation{{Instances: []*ec2.Instance{
+				instance("test-x7k2p-master-0", "", "10.0.1.7"),
+				instance("test-x7k2p-master-1", "", "10.0.1.8"),
+			}}}},
+		}},
+		bootstrap: "3.4.5.6",
+		masters:   []string{"10.0.1.7", "10.0.1.8", "10.0.1.9"},
+		calls:     1,
+	}, {
+		name:    "no bootstrap",
+		lister:  &fakeInstanceLister{pages: []*ec2.DescribeInstancesOutput{master0}},
+		masters: []string{"10.0.1.7"},
+		calls:   1,
+	}, {
+		name:    "throttled",
+		lister:  &fakeInstanceLister{pages: []*ec2.DescribeInstancesOutput{master0}, errs: []error{throttled, awserr.New("Throttling", "Rate exceeded", nil)}},
+		masters: []string{"10.0.1.7"},
+		calls:   3,
+	}, {
+		name:   "throttled past the retries",
+		lister: &fakeInstanceLister{pages: []*ec2.DescribeInstancesOutput{master0}, errs: []error{throttled, throttled, throttled}},
+		err:    "RequestLimitExceeded: Request limit exceeded.",
+		calls:  3,
+	}, {
+		name:   "auth error",
+		lister: &fakeInstanceLister{pages: []*ec2.DescribeInstancesOutput{master0}, errs: []error{awserr.New("UnauthorizedOperation", "You are not authorized to perform this operation.", nil)}},
+		err:    "UnauthorizedOperation: You are not authorized to perform this operation.",
+		calls:  1,
+	}}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			bootstrap, masters, err := discoverInstances(context.Background(), limiter, tc.lister, "test-x7k2p")
+			assert.Equal(t, tc.calls, tc.lister.calls)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.bootstrap, bootstrap)
+			assert.Equal(t, tc.masters, masters)
+			assert.Equal(t, "tag:kubernetes.io/cluster/test-x7k2p", aws.StringValue(tc.lister.input.Filters[0].Name))
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package aws
+
+import (
+	"net"
+
+	"github.com/openshift/installer/pkg/terraform"
+	"github.com/openshift/installer/pkg/terraform/gather"
+)
+
+// Gatherer implements gather.Gatherer for AWS.
+type Gatherer struct {
+	// MachineCIDR is the network the addresses of hosts with several
+	// addresses are preferred from. It may be nil.
+	MachineCIDR *net.IPNet
+}
+
+var _ gather.Gatherer = Gatherer{}
+
+// BootstrapHost returns the bootstrap host.
+func (g Gatherer) BootstrapHost(tfs *terraform.State) (gather.Host, error) {
+	address, err := BootstrapIP(tfs, g.MachineCIDR)
+	return gather.Host{Name: gather.BootstrapHostName, Address: address}, err
+}
+
+// ControlPlaneHosts returns the control plane hosts.
+func (g Gatherer) ControlPlaneHosts(tfs *terraform.State) ([]gather.Host, error) {
+	addresses, err := ControlPlaneIPs(tfs, g.MachineCIDR)
+	return gather.MasterHosts(addresses), err
+}
+
+// SSHUser returns gather.DefaultSSHUser.
+func (Gatherer) SSHUser() string {
+	return gather.DefaultSSHUser
+}
+
+// SSHPort returns gather.DefaultSSHPort.
+func (Gatherer) SSHPort() int {
+	return gather.DefaultSSHPort
+}
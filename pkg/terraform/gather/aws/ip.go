@@ -3,15 +3,20 @@
 package aws
 
 import (
+	"net"
+
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/openshift/installer/pkg/terraform"
+	"github.com/openshift/installer/pkg/terraform/gather"
 )
 
-// BootstrapIP returns the ip address for bootstrap host.
-func BootstrapIP(tfs *terraform.State) (string, error) {
+// BootstrapIP returns the ip address for bootstrap host, its public IPv4
+// address or, on IPv6 clusters, its IPv6 address in machineCIDR, which may be
+// nil.
+func BootstrapIP(tfs *terraform.State, machineCIDR *net.IPNet) (string, error) {
 	br, err := terraform.LookupResource(tfs, "module.bootstrap", "aws_instance", "bootstrap")
 	if err != nil {
 		return "", errors.Wrap(err, "failed to lookup bootstrap")
@@ -19,15 +24,17 @@ func BootstrapIP(tfs *terraform.State) (string, error) {
 	if len(br.Instances) == 0 {
 		return "", errors.New("no bootstrap instance found")
 	}
-	bootstrap, _, err := unstructured.NestedString(br.Instances[0].Attributes, "public_ip")
-	if err != nil {
+	bootstrap := instanceIP(br.Instances[0].Attributes, "public_ip", machineCIDR)
+	if bootstrap == "" {
 		return "", errors.New("no public_ip found for bootstrap")
 	}
 	return bootstrap, nil
 }
 
-// ControlPlaneIPs returns the ip addresses for control plane hosts.
-func ControlPlaneIPs(tfs *terraform.State) ([]string, error) {
+// ControlPlaneIPs returns the ip addresses for control plane hosts, their
+// private IPv4 addresses or, on IPv6 clusters, their IPv6 addresses in
+// machineCIDR, which may be nil.
+func ControlPlaneIPs(tfs *terraform.State, machineCIDR *net.IPNet) ([]string, error) {
 	mrs, err := terraform.LookupResource(tfs, "module.masters", "aws_instance", "master")
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to lookup masters")
@@ -35,11 +42,19 @@ func ControlPlaneIPs(tfs *terraform.State) ([]string, error) {
 	var errs []error
 	var masters []string
 	for idx, inst := range mrs.Instances {
-		master, _, err := unstructured.NestedString(inst.Attributes, "private_ip")
-		if err != nil {
-			errs = append(errs, errors.Wrapf(err, "no private_ip for master.%d", idx))
+		master := instanceIP(inst.Attributes, "private_ip", machineCIDR)
+		if master == "" {
+			errs = append(errs, errors.Errorf("no private_ip for master.%d", idx))
 		}
 		masters = append(masters, master)
 	}
 	return masters, utilerrors.NewAggregate(errs)
 }
+
+// instanceIP returns the IPv4 address of an aws_instance in the attribute
+// ipv4 or one of its ipv6_addresses, whichever is preferred in machineCIDR.
+func instanceIP(attr map[string]interface{}, ipv4 string, machineCIDR *net.IPNet) string {
+	address, _, _ := unstructured.NestedString(attr, ipv4)
+	ipv6, _, _ := unstructured.NestedStringSlice(attr, "ipv6_addresses")
+	return gather.PreferredAddress(append([]string{address}, ipv6...), machineCIDR)
+}
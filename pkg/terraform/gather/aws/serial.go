@@ -0,0 +1,78 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	awsconfig "github.com/openshift/installer/pkg/asset/installconfig/aws"
+	"github.com/openshift/installer/pkg/terraform"
+	"github.com/openshift/installer/pkg/terraform/gather"
+)
+
+// SerialConsole returns the serial console output of the bootstrap and
+// control plane instances in the terraform state, keyed by "bootstrap" and
+// "master-<index>", using the AWS credentials of the installer. EC2 keeps
+// the output even for instances which never booted far enough to accept SSH.
+// The API calls are made through limiter, and their retries stop when ctx is
+// done. The instances whose output cannot be fetched are left out, and the
+// errors returned.
+func SerialConsole(ctx context.Context, tfs *terraform.State, limiter *gather.APILimiter) (map[string][]byte, error) {
+	instances := map[string]map[string]interface{}{}
+	if br, err := terraform.LookupResource(tfs, "module.bootstrap", "aws_instance", "bootstrap"); err == nil && len(br.Instances) > 0 {
+		instances["bootstrap"] = br.Instances[0].Attributes
+	}
+	if mrs, err := terraform.LookupResource(tfs, "module.masters", "aws_instance", "master"); err == nil {
+		for idx, inst := range mrs.Instances {
+			instances[fmt.Sprintf("master-%d", idx)] = inst.Attributes
+		}
+	}
+	if len(instances) == 0 {
+		return nil, errors.New("no instances found")
+	}
+
+	ssn, err := awsconfig.GetSession()
+	if err != nil {
+		return nil, err
+	}
+	clients := map[string]*ec2.EC2{}
+	outputs := map[string][]byte{}
+	var errs []error
+	for name, attributes := range instances {
+		id, _, _ := unstructured.NestedString(attributes, "id")
+		instanceARN, _, _ := unstructured.NestedString(attributes, "arn")
+		parsed, err := arn.Parse(instanceARN)
+		if id == "" || err != nil {
+			errs = append(errs, errors.Errorf("no id or arn found for %s", name))
+			continue
+		}
+		client, ok := clients[parsed.Region]
+		if !ok {
+			client = ec2.New(ssn, aws.NewConfig().WithRegion(parsed.Region))
+			clients[parsed.Region] = client
+		}
+		var out *ec2.GetConsoleOutputOutput
+		err = limiter.Call(ctx, classifyAPIError, func(ctx context.Context) (err error) {
+			out, err = client.GetConsoleOutputWithContext(ctx, &ec2.GetConsoleOutputInput{InstanceId: aws.String(id)})
+			return err
+		})
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to get the console output of %s (%s)", name, id))
+			continue
+		}
+		output, err := base64.StdEncoding.DecodeString(aws.StringValue(out.Output))
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to decode the console output of %s (%s)", name, id))
+			continue
+		}
+		outputs[name] = output
+	}
+	return outputs, utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,191 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest"
+	azureenv "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+
+	azureconfig "github.com/openshift/installer/pkg/asset/installconfig/azure"
+	"github.com/openshift/installer/pkg/terraform/gather"
+	"github.com/openshift/installer/pkg/types"
+	"github.com/openshift/installer/pkg/version"
+)
+
+// networkAPIVersion is the version of the network API the addresses are
+// looked up with.
+const networkAPIVersion = "2019-06-01"
+
+// publicIPAddress is the part of a Microsoft.Network/publicIPAddresses
+// resource holding its address.
+type publicIPAddress struct {
+	Properties struct {
+		IPAddress string `json:"ipAddress"`
+	} `json:"properties"`
+}
+
+// networkInterfaces is a page of Microsoft.Network/networkInterfaces
+// resources, with the parts holding their names and private addresses.
+type networkInterfaces struct {
+	Value []struct {
+		Name       string `json:"name"`
+		Properties struct {
+			IPConfigurations []struct {
+				Properties struct {
+					PrivateIPAddress string `json:"privateIPAddress"`
+				} `json:"properties"`
+			} `json:"ipConfigurations"`
+		} `json:"properties"`
+	} `json:"value"`
+	NextLink string `json:"nextLink"`
+}
+
+// apiClient looks up the resources of the resource group of a cluster,
+// making its requests through limiter.
+type apiClient struct {
+	client         autorest.Client
+	limiter        *gather.APILimiter
+	baseURL        string
+	subscriptionID string
+}
+
+// BootstrapIPFromAPI returns the address of the public IP of the cluster
+// with infraID, which the public load balancer forwards SSH to the
+// bootstrap host on, looked up with the Azure API by the name the installer
+// gives it through limiter, for when the terraform state holds none.
+func BootstrapIPFromAPI(ctx context.Context, limiter *gather.APILimiter, config *types.InstallConfig, infraID string) (string, error) {
+	client, err := newAPIClient(limiter)
+	if err != nil {
+		return "", err
+	}
+	return client.bootstrapIP(ctx, infraID)
+}
+
+// ControlPlaneIPsFromAPI returns the private addresses of the network
+// interfaces of the control plane hosts of the cluster with infraID, sorted
+// by name, looked up with the Azure API by the names the installer gives
+// them through limiter, for when the terraform state holds none.
+func ControlPlaneIPsFromAPI(ctx context.Context, limiter *gather.APILimiter, config *types.InstallConfig, infraID string) ([]string, error) {
+	client, err := newAPIClient(limiter)
+	if err != nil {
+		return nil, err
+	}
+	return client.controlPlaneIPs(ctx, infraID)
+}
+
+func newAPIClient(limiter *gather.APILimiter) (*apiClient, error) {
+	session, err := azureconfig.GetSession()
+	if err != nil {
+		return nil, err
+	}
+	client := autorest.NewClientWithUserAgent(fmt.Sprintf("OpenShift/4.x Installer/%s", version.Raw))
+	client.Authorizer = session.Authorizer
+	return &apiClient{
+		client:         client,
+		limiter:        limiter,
+		baseURL:        azureenv.PublicCloud.ResourceManagerEndpoint,
+		subscriptionID: session.Credentials.SubscriptionID,
+	}, nil
+}
+
+func (c *apiClient) bootstrapIP(ctx context.Context, infraID string) (string, error) {
+	var ip publicIPAddress
+	path := fmt.Sprintf("%s/providers/Microsoft.Network/publicIPAddresses/%s-pip", c.resourceGroup(infraID), infraID)
+	if err := c.get(ctx, &ip, c.resource(path)...); err != nil {
+		return "", errors.Wrapf(err, "failed to get the public IP of %s", infraID)
+	}
+	if ip.Properties.IPAddress == "" {
+		return "", errors.Errorf("the public IP of %s has no address", infraID)
+	}
+	return ip.Properties.IPAddress, nil
+}
+
+func (c *apiClient) controlPlaneIPs(ctx context.Context, infraID string) ([]string, error) {
+	addresses := map[string]string{}
+	var names []string
+	next := c.resource(c.resourceGroup(infraID) + "/providers/Microsoft.Network/networkInterfaces")
+	for next != nil {
+		var page networkInterfaces
+		if err := c.get(ctx, &page, next...); err != nil {
+			return nil, errors.Wrapf(err, "failed to list the network interfaces of %s", infraID)
+		}
+		for _, nic := range page.Value {
+			if !strings.HasPrefix(nic.Name, infraID+"-master") || len(nic.Properties.IPConfigurations) == 0 {
+				continue
+			}
+			if address := nic.Properties.IPConfigurations[0].Properties.PrivateIPAddress; address != "" {
+				addresses[nic.Name] = address
+				names = append(names, nic.Name)
+			}
+		}
+		next = nil
+		if page.NextLink != "" {
+			next = []autorest.PrepareDecorator{autorest.WithBaseURL(page.NextLink)}
+		}
+	}
+	if len(names) == 0 {
+		return nil, errors.Errorf("no control plane network interfaces of %s found", infraID)
+	}
+	sort.Strings(names)
+	masters := make([]string, 0, len(names))
+	for _, name := range names {
+		masters = append(masters, addresses[name])
+	}
+	return masters, nil
+}
+
+func (c *apiClient) resourceGroup(infraID string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s-rg", c.subscriptionID, infraID)
+}
+
+// resource returns the decorators of the URL of the network resource at
+// path.
+func (c *apiClient) resource(path string) []autorest.PrepareDecorator {
+	return []autorest.PrepareDecorator{
+		autorest.WithBaseURL(c.baseURL),
+		autorest.WithPath(path),
+		autorest.WithQueryParameters(map[string]interface{}{"api-version": networkAPIVersion}),
+	}
+}
+
+// get unmarshals the resource at the URL of decorators into v. The request
+// is made through the limiter of c, which retries it when it is throttled.
+func (c *apiClient) get(ctx context.Context, v interface{}, url ...autorest.PrepareDecorator) error {
+	decorators := append([]autorest.PrepareDecorator{autorest.AsGet()}, url...)
+	return c.limiter.Call(ctx, classifyAPIError, func(ctx context.Context) error {
+		req, err := autorest.Prepare((&http.Request{}).WithContext(ctx), append(decorators, c.client.WithAuthorization())...)
+		if err != nil {
+			return err
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return err
+		}
+		return autorest.Respond(resp,
+			azureenv.WithErrorUnlessStatusCode(http.StatusOK),
+			autorest.ByUnmarshallingJSON(v),
+			autorest.ByClosing())
+	})
+}
+
+// classifyAPIError tells the 429 responses of the Azure API, retried by
+// the gather.APILimiter, from the 401 and 403 ones, which fail the
+// discovery at once.
+func classifyAPIError(err error) gather.APIErrorClass {
+	rerr, ok := err.(*azureenv.RequestError)
+	if !ok {
+		return gather.APIErrorOther
+	}
+	switch rerr.StatusCode {
+	case http.StatusTooManyRequests:
+		return gather.APIErrorThrottled
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return gather.APIErrorAuth
+	}
+	return gather.APIErrorOther
+}
@@ -0,0 +1,131 @@
+package azure
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/installer/pkg/terraform/gather"
+)
+
+// fakeAPIClient returns an apiClient whose requests are answered with the
+// bodies of responses, by URL, and with 404 for the other URLs.
+func fakeAPIClient(responses map[string]string) *apiClient {
+	sender := autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+		body, ok := responses[req.URL.String()]
+		status := http.StatusOK
+		if !ok {
+			status, body = http.StatusNotFound, `{"error": {"code": "ResourceNotFound"}}`
+		}
+		return &http.Response{
+			StatusCode: status,
+			Status:     http.StatusText(status),
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+	return &apiClient{
+		client:         autorest.Client{Sender: sender},
+		limiter:        gather.NewAPILimiter(gather.DefaultAPIConcurrency),
+		baseURL:        "https://management.example.com",
+		subscriptionID: "sub",
+	}
+}
+
+func TestBootstrapIPFromAPI(t *testing.T) {
+	client := fakeAPIClient(map[string]string{
+		"https://management.example.com/subscriptions/sub/resourceGroups/test-x7k2p-rg/providers/Microsoft.Network/publicIPAddresses/test-x7k2p-pip?api-version=2019-06-01": `{"properties": {"ipAddress": "20.1.2.3"}}`,
+	})
+	bootstrap, err := client.bootstrapIP(context.Background(), "test-x7k2p")
+	assert.NoError(t, err)
+	assert.Equal(t, "20.1.2.3", bootstrap)
+
+	_, err = client.bootstrapIP(context.Background(), "other")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "failed to get the public IP of other")
+	}
+}
+
+func TestControlPlaneIPsFromAPI(t *testing.T) {
+	client := fakeAPIClient(map[string]string{
+		"https://management.example.com/subscriptions/sub/resourceGroups/test-x7k2p-rg/providers/Microsoft.Network/networkInterfaces?api-version=2019-06-01": `{
+  "value": [
+    {"name": "test-x7k2p-master2-nic", "properties": {"ipConfigurations": [{"properties": {"privateIPAddress": "10.0.0.8"}}]}},
+    {"name": "test-x7k2p-bootstrap-nic", "properties": {"ipConfigurations": [{"properties": {"privateIPAddress": "10.0.0.4"}}]}}
+  ],
+  "nextLink": "https://management.example.com/subscriptions/sub/resourceGroups/test-x7k2p-rg/providers/Microsoft.Network/networkInterfaces?api-version=2019-06-01&%24skiptoken=page2"
+}`,
+		"https://management.example.com/subscriptions/sub/resourceGroups/test-x7k2p-rg/providers/Microsoft.Network/networkInterfaces?api-version=2019-06-01&%24skiptoken=page2": `{
+  "value": [
+    {"name": "test-x7k2p-master0-nic", "properties": {"ipConfigurations": [{"properties": {"privateIPAddress": "10.0.0.6"}}]}},
+    {"name": "test-x7k2p-master1-nic", "properties": {"ipConfigurations": [{"properties": {"privateIPAddress": "10.0.0.7"}}]}},
+    {"name": "test-x7k2p-worker-nic", "properties": {"ipConfigurations": [{"properties": {"privateIPAddress": "10.0.32.4"}}]}}
+  ]
+}`,
+	})
+	masters, err := client.controlPlaneIPs(context.Background(), "test-x7k2p")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.6", "10.0.0.7", "10.0.0.8"}, masters)
+
+	_, err = client.controlPlaneIPs(context.Background(), "other")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "failed to list the network interfaces of other")
+	}
+}
+
+func TestAPIThrottling(t *testing.T) {
+	limiter := gather.NewAPILimiter(1)
+	limiter.Retries, limiter.Backoff = 2, time.Millisecond
+
+	cases := []struct {
+		name     string
+		statuses []int
+		err      string
+		calls    int
+	}{
+		{name: "throttled", statuses: []int{http.StatusTooManyRequests, http.StatusTooManyRequests}, calls: 3},
+		{name: "throttled past the retries", statuses: []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests}, err: "Status=429", calls: 3},
+		{name: "forbidden", statuses: []int{http.StatusForbidden}, err: "Status=403", calls: 1},
+		{name: "unauthorized after throttling", statuses: []int{http.StatusTooManyRequests, http.StatusUnauthorized}, err: "Status=401", calls: 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fakeAPIClient(map[string]string{
+				"https://management.example.com/subscriptions/sub/resourceGroups/test-x7k2p-rg/providers/Microsoft.Network/publicIPAddresses/test-x7k2p-pip?api-version=2019-06-01": `{"properties": {"ipAddress": "20.1.2.3"}}`,
+			})
+			client.limiter = limiter
+			calls := 0
+			sender := client.client.Sender
+			client.client.Sender = autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+				calls++
+				if calls <= len(tc.statuses) {
+					status := tc.statuses[calls-1]
+					return &http.Response{
+						StatusCode: status,
+						Status:     http.StatusText(status),
+						Body:       ioutil.NopCloser(strings.NewReader(`{}`)),
+						Request:    req,
+					}, nil
+				}
+				return sender.Do(req)
+			})
+
+			bootstrap, err := client.bootstrapIP(context.Background(), "test-x7k2p")
+			assert.Equal(t, tc.calls, calls)
+			if tc.err != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "20.1.2.3", bootstrap)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package azure
+
+import (
+	"net"
+
+	"github.com/openshift/installer/pkg/terraform"
+	"github.com/openshift/installer/pkg/terraform/gather"
+)
+
+// Gatherer implements gather.Gatherer for Azure.
+type Gatherer struct {
+	// MachineCIDR is the network the addresses of hosts with several
+	// addresses are preferred from. It may be nil.
+	MachineCIDR *net.IPNet
+}
+
+var _ gather.Gatherer = Gatherer{}
+
+// BootstrapHost returns the bootstrap host.
+func (Gatherer) BootstrapHost(tfs *terraform.State) (gather.Host, error) {
+	address, err := BootstrapIP(tfs)
+	return gather.Host{Name: gather.BootstrapHostName, Address: address}, err
+}
+
+// ControlPlaneHosts returns the control plane hosts.
+func (g Gatherer) ControlPlaneHosts(tfs *terraform.State) ([]gather.Host, error) {
+	addresses, err := ControlPlaneIPs(tfs, g.MachineCIDR)
+	return gather.MasterHosts(addresses), err
+}
+
+// SSHUser returns gather.DefaultSSHUser.
+func (Gatherer) SSHUser() string {
+	return gather.DefaultSSHUser
+}
+
+// SSHPort returns 2200, the port the public load balancer forwards to SSH on
+// the bootstrap host.
+func (Gatherer) SSHPort() int {
+	return 2200
+}
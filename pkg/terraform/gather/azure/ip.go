@@ -3,11 +3,14 @@
 package azure
 
 import (
+	"net"
+
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/openshift/installer/pkg/terraform"
+	"github.com/openshift/installer/pkg/terraform/gather"
 )
 
 // BootstrapIP returns the ip address for bootstrap host.
@@ -26,8 +29,10 @@ func BootstrapIP(tfs *terraform.State) (string, error) {
 	return bootstrap, nil
 }
 
-// ControlPlaneIPs returns the ip addresses for control plane hosts.
-func ControlPlaneIPs(tfs *terraform.State) ([]string, error) {
+// ControlPlaneIPs returns the ip addresses for control plane hosts, preferring
+// the private addresses of their network interfaces in machineCIDR, which may
+// be nil, for dual-stack interfaces.
+func ControlPlaneIPs(tfs *terraform.State, machineCIDR *net.IPNet) ([]string, error) {
 	mrs, err := terraform.LookupResource(tfs, "module.master", "azurerm_network_interface", "master")
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to lookup masters")
@@ -35,9 +40,11 @@ func ControlPlaneIPs(tfs *terraform.State) ([]string, error) {
 	var errs []error
 	var masters []string
 	for idx, inst := range mrs.Instances {
-		master, _, err := unstructured.NestedString(inst.Attributes, "private_ip_address")
-		if err != nil {
-			errs = append(errs, errors.Wrapf(err, "no private_ip for master.%d", idx))
+		address, _, _ := unstructured.NestedString(inst.Attributes, "private_ip_address")
+		addresses, _, _ := unstructured.NestedStringSlice(inst.Attributes, "private_ip_addresses")
+		master := gather.PreferredAddress(append([]string{address}, addresses...), machineCIDR)
+		if master == "" {
+			errs = append(errs, errors.Errorf("no private_ip for master.%d", idx))
 		}
 		masters = append(masters, master)
 	}
@@ -0,0 +1,120 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/Azure/go-autorest/autorest"
+	azureenv "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	azureconfig "github.com/openshift/installer/pkg/asset/installconfig/azure"
+	"github.com/openshift/installer/pkg/terraform"
+	"github.com/openshift/installer/pkg/terraform/gather"
+	"github.com/openshift/installer/pkg/version"
+)
+
+// bootDiagnosticsAPIVersion is the first compute API version with
+// retrieveBootDiagnosticsData.
+const bootDiagnosticsAPIVersion = "2020-06-01"
+
+// bootDiagnosticsData holds the SAS URIs returned by
+// retrieveBootDiagnosticsData.
+type bootDiagnosticsData struct {
+	SerialConsoleLogBlobURI string `json:"serialConsoleLogBlobUri"`
+}
+
+// SerialConsole returns the boot diagnostics serial log of the bootstrap and
+// control plane virtual machines in the terraform state, keyed by
+// "bootstrap" and "master-<index>", using the Azure credentials of the
+// installer. Azure keeps the log even for machines which never booted far
+// enough to accept SSH. The API calls are made through limiter, and their
+// retries stop when ctx is done. The machines whose log cannot be fetched
+// are left out, and the errors returned.
+func SerialConsole(ctx context.Context, tfs *terraform.State, limiter *gather.APILimiter) (map[string][]byte, error) {
+	vms := map[string]string{}
+	if br, err := terraform.LookupResource(tfs, "module.bootstrap", "azurerm_virtual_machine", "bootstrap"); err == nil && len(br.Instances) > 0 {
+		vms["bootstrap"], _, _ = unstructured.NestedString(br.Instances[0].Attributes, "id")
+	}
+	if mrs, err := terraform.LookupResource(tfs, "module.master", "azurerm_virtual_machine", "master"); err == nil {
+		for idx, inst := range mrs.Instances {
+			vms[fmt.Sprintf("master-%d", idx)], _, _ = unstructured.NestedString(inst.Attributes, "id")
+		}
+	}
+	if len(vms) == 0 {
+		return nil, errors.New("no virtual machines found")
+	}
+
+	session, err := azureconfig.GetSession()
+	if err != nil {
+		return nil, err
+	}
+	client := autorest.NewClientWithUserAgent(fmt.Sprintf("OpenShift/4.x Installer/%s", version.Raw))
+	client.Authorizer = session.Authorizer
+
+	logs := map[string][]byte{}
+	var errs []error
+	for name, id := range vms {
+		if id == "" {
+			errs = append(errs, errors.Errorf("no id found for %s", name))
+			continue
+		}
+		log, err := serialLog(ctx, limiter, client, id)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to get the serial log of %s", name))
+			continue
+		}
+		logs[name] = log
+	}
+	return logs, utilerrors.NewAggregate(errs)
+}
+
+// serialLog returns the boot diagnostics serial log of the virtual machine
+// with the resource id, through limiter.
+func serialLog(ctx context.Context, limiter *gather.APILimiter, client autorest.Client, id string) ([]byte, error) {
+	var data bootDiagnosticsData
+	err := limiter.Call(ctx, classifyAPIError, func(ctx context.Context) error {
+		req, err := autorest.Prepare((&http.Request{}).WithContext(ctx),
+			autorest.AsPost(),
+			autorest.WithBaseURL(azureenv.PublicCloud.ResourceManagerEndpoint),
+			autorest.WithPath(id+"/retrieveBootDiagnosticsData"),
+			autorest.WithQueryParameters(map[string]interface{}{"api-version": bootDiagnosticsAPIVersion}),
+			client.WithAuthorization())
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		return autorest.Respond(resp,
+			azureenv.WithErrorUnlessStatusCode(http.StatusOK),
+			autorest.ByUnmarshallingJSON(&data),
+			autorest.ByClosing())
+	})
+	if err != nil {
+		return nil, err
+	}
+	if data.SerialConsoleLogBlobURI == "" {
+		return nil, errors.New("boot diagnostics hold no serial log")
+	}
+
+	// The blob URI is signed, so it is fetched without authorization.
+	blobReq, err := http.NewRequest(http.MethodGet, data.SerialConsoleLogBlobURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := http.DefaultClient.Do(blobReq.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Body.Close()
+	if blob.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %s fetching the serial log", blob.Status)
+	}
+	return ioutil.ReadAll(blob.Body)
+}
@@ -0,0 +1,61 @@
+// Package gather contains utilities that help gather platform specific
+// information from terraform state.
+package gather
+
+import (
+	"net"
+
+	"github.com/openshift/installer/pkg/terraform"
+)
+
+const (
+	// DefaultSSHUser is the user the installer logs into RHCOS hosts as.
+	DefaultSSHUser = "core"
+
+	// DefaultSSHPort is the port SSH listens on.
+	DefaultSSHPort = 22
+)
+
+// Gatherer finds the hosts of a platform in its terraform state, and
+// declares how to reach the bootstrap host over SSH.
+type Gatherer interface {
+	// BootstrapHost returns the bootstrap host.
+	BootstrapHost(tfs *terraform.State) (Host, error)
+
+	// ControlPlaneHosts returns the control plane hosts, named by their
+	// index in the state, with an empty address for those without one.
+	ControlPlaneHosts(tfs *terraform.State) ([]Host, error)
+
+	// SSHUser returns the user to log into the hosts as.
+	SSHUser() string
+
+	// SSHPort returns the port to connect to the bootstrap host on.
+	SSHPort() int
+}
+
+// PreferredAddress returns the address of a host with several addresses which
+// is in machineCIDR, or else the first one of the address family of
+// machineCIDR, so the IPv6 address of a dual-stack host is used on IPv6
+// clusters. Without machineCIDR, or a matching address, the first non-empty
+// address is returned.
+func PreferredAddress(addresses []string, machineCIDR *net.IPNet) string {
+	if machineCIDR != nil {
+		for _, address := range addresses {
+			if ip := net.ParseIP(address); ip != nil && machineCIDR.Contains(ip) {
+				return address
+			}
+		}
+		v6 := machineCIDR.IP.To4() == nil
+		for _, address := range addresses {
+			if ip := net.ParseIP(address); ip != nil && (ip.To4() == nil) == v6 {
+				return address
+			}
+		}
+	}
+	for _, address := range addresses {
+		if address != "" {
+			return address
+		}
+	}
+	return ""
+}
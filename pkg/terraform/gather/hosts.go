@@ -0,0 +1,79 @@
+package gather
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BootstrapHostName is the name of the bootstrap host.
+const BootstrapHostName = "bootstrap"
+
+// Host is a host of the cluster found by a Gatherer.
+type Host struct {
+	// Name identifies the host in the cluster: BootstrapHostName, or
+	// "master-<index>" for the control plane hosts, by their index in the
+	// terraform state.
+	Name    string
+	Address string
+}
+
+// MasterHosts names the control plane hosts at addresses, in the order of
+// the terraform state, "master-<index>". The empty addresses of the hosts
+// without one keep their index.
+func MasterHosts(addresses []string) []Host {
+	hosts := make([]Host, 0, len(addresses))
+	for idx, address := range addresses {
+		hosts = append(hosts, Host{Name: fmt.Sprintf("master-%d", idx), Address: address})
+	}
+	return hosts
+}
+
+// Addresses returns the addresses of hosts.
+func Addresses(hosts []Host) []string {
+	addresses := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		addresses = append(addresses, host.Address)
+	}
+	return addresses
+}
+
+// FilterHosts returns the hosts, with an address, whose name or address is
+// one of only, which is an error when there are none. Names are matched
+// case-insensitively, and IP addresses in any of their notations.
+func FilterHosts(hosts []Host, only []string) ([]Host, error) {
+	var selected, available []Host
+	for _, host := range hosts {
+		if host.Address == "" {
+			continue
+		}
+		available = append(available, host)
+		for _, value := range only {
+			if matchesHost(host, strings.TrimSpace(value)) {
+				selected = append(selected, host)
+				break
+			}
+		}
+	}
+	if len(selected) == 0 {
+		names := make([]string, 0, len(available))
+		for _, host := range available {
+			names = append(names, fmt.Sprintf("%s (%s)", host.Name, host.Address))
+		}
+		if len(names) == 0 {
+			return nil, errors.Errorf("no host matches %s: no hosts were found", strings.Join(only, ", "))
+		}
+		return nil, errors.Errorf("no host matches %s, the hosts are %s", strings.Join(only, ", "), strings.Join(names, ", "))
+	}
+	return selected, nil
+}
+
+func matchesHost(host Host, value string) bool {
+	if strings.EqualFold(host.Name, value) || host.Address == value {
+		return true
+	}
+	ip := net.ParseIP(value)
+	return ip != nil && ip.Equal(net.ParseIP(host.Address))
+}
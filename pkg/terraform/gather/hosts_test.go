@@ -0,0 +1,56 @@
+package gather
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterHosts(t *testing.T) {
+	hosts := append([]Host{{Name: BootstrapHostName, Address: "3.4.5.6"}},
+		MasterHosts([]string{"10.0.1.7", "", "fd00::9"})...)
+	cases := []struct {
+		name     string
+		only     []string
+		expected []Host
+		err      string
+	}{{
+		name:     "bootstrap",
+		only:     []string{"bootstrap"},
+		expected: []Host{{Name: "bootstrap", Address: "3.4.5.6"}},
+	}, {
+		name:     "master by name",
+		only:     []string{"Master-2"},
+		expected: []Host{{Name: "master-2", Address: "fd00::9"}},
+	}, {
+		name:     "by address",
+		only:     []string{"10.0.1.7", "fd00:0::9"},
+		expected: []Host{{Name: "master-0", Address: "10.0.1.7"}, {Name: "master-2", Address: "fd00::9"}},
+	}, {
+		name:     "bootstrap and a master",
+		only:     []string{"master-0", " bootstrap "},
+		expected: []Host{{Name: "bootstrap", Address: "3.4.5.6"}, {Name: "master-0", Address: "10.0.1.7"}},
+	}, {
+		name: "master without an address",
+		only: []string{"master-1"},
+		err:  "no host matches master-1, the hosts are bootstrap (3.4.5.6), master-0 (10.0.1.7), master-2 (fd00::9)",
+	}, {
+		name: "no match",
+		only: []string{"master-5", "10.0.1.8"},
+		err:  "no host matches master-5, 10.0.1.8, the hosts are bootstrap (3.4.5.6), master-0 (10.0.1.7), master-2 (fd00::9)",
+	}}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			selected, err := FilterHosts(hosts, tc.only)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, selected)
+		})
+	}
+
+	_, err := FilterHosts(nil, []string{"bootstrap"})
+	assert.EqualError(t, err, "no host matches bootstrap: no hosts were found")
+}
@@ -0,0 +1,33 @@
+package libvirt
+
+import (
+	"github.com/openshift/installer/pkg/terraform"
+	"github.com/openshift/installer/pkg/terraform/gather"
+)
+
+// Gatherer implements gather.Gatherer for libvirt.
+type Gatherer struct{}
+
+var _ gather.Gatherer = Gatherer{}
+
+// BootstrapHost returns the bootstrap host.
+func (Gatherer) BootstrapHost(tfs *terraform.State) (gather.Host, error) {
+	address, err := BootstrapIP(tfs)
+	return gather.Host{Name: gather.BootstrapHostName, Address: address}, err
+}
+
+// ControlPlaneHosts returns the control plane hosts.
+func (Gatherer) ControlPlaneHosts(tfs *terraform.State) ([]gather.Host, error) {
+	addresses, err := ControlPlaneIPs(tfs)
+	return gather.MasterHosts(addresses), err
+}
+
+// SSHUser returns gather.DefaultSSHUser.
+func (Gatherer) SSHUser() string {
+	return gather.DefaultSSHUser
+}
+
+// SSHPort returns gather.DefaultSSHPort.
+func (Gatherer) SSHPort() int {
+	return gather.DefaultSSHPort
+}
@@ -0,0 +1,108 @@
+// Package metal contains utilities that help gather bare metal specific
+// information from terraform state.
+package metal
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/openshift/installer/pkg/terraform"
+)
+
+// HasDevices returns whether tfs is the state of the UPI terraform for
+// bare metal on Packet, with the control plane devices or their addresses.
+func HasDevices(tfs *terraform.State) bool {
+	if _, err := terraform.LookupResource(tfs, "", "packet_device", "masters"); err == nil {
+		return true
+	}
+	_, ok := stringsOutput(tfs, "master_ips")
+	return ok
+}
+
+// BootstrapIP returns the public IPv4 address of the bootstrap device of the
+// UPI terraform for bare metal on Packet, or else the bootstrap_ip output of
+// the state, for states whose resources were left out.
+func BootstrapIP(tfs *terraform.State) (string, error) {
+	br, err := terraform.LookupResource(tfs, "module.bootstrap", "packet_device", "bootstrap")
+	if err != nil {
+		if output, ok := stringOutput(tfs, "bootstrap_ip"); ok {
+			return output, nil
+		}
+		return "", errors.Wrap(err, "failed to lookup bootstrap")
+	}
+	if len(br.Instances) == 0 {
+		return "", errors.New("no bootstrap instance found")
+	}
+	bootstrap := deviceIP(br.Instances[0].Attributes)
+	if bootstrap == "" {
+		return "", errors.New("no public ip found for bootstrap")
+	}
+	return bootstrap, nil
+}
+
+// ControlPlaneIPs returns the public IPv4 addresses of the control plane
+// devices of the UPI terraform for bare metal on Packet, or else the
+// master_ips output of the state.
+func ControlPlaneIPs(tfs *terraform.State) ([]string, error) {
+	mrs, err := terraform.LookupResource(tfs, "", "packet_device", "masters")
+	if err != nil {
+		if output, ok := stringsOutput(tfs, "master_ips"); ok {
+			return output, nil
+		}
+		return nil, errors.Wrap(err, "failed to lookup masters")
+	}
+	var errs []error
+	var masters []string
+	for idx, inst := range mrs.Instances {
+		master := deviceIP(inst.Attributes)
+		if master == "" {
+			errs = append(errs, errors.Errorf("no public ip for masters.%d", idx))
+		}
+		masters = append(masters, master)
+	}
+	return masters, utilerrors.NewAggregate(errs)
+}
+
+// deviceIP returns the access_public_ipv4 of a packet_device, or else the
+// address of its first network, which is its public IPv4 network.
+func deviceIP(attr map[string]interface{}) string {
+	if address, _, _ := unstructured.NestedString(attr, "access_public_ipv4"); address != "" {
+		return address
+	}
+	networks, _, _ := unstructured.NestedSlice(attr, "network")
+	if len(networks) == 0 {
+		return ""
+	}
+	network, ok := networks[0].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	address, _, _ := unstructured.NestedString(network, "address")
+	return address
+}
+
+// stringOutput returns the value of the string output name of tfs, and
+// whether it is a non-empty string.
+func stringOutput(tfs *terraform.State, name string) (string, bool) {
+	value, ok := tfs.Outputs[name].Value.(string)
+	return value, ok && value != ""
+}
+
+// stringsOutput returns the value of the list output name of tfs, and
+// whether it is a non-empty list of strings.
+func stringsOutput(tfs *terraform.State, name string) ([]string, bool) {
+	values, ok := tfs.Outputs[name].Value.([]interface{})
+	if !ok || len(values) == 0 {
+		return nil, false
+	}
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		str, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		strs = append(strs, str)
+	}
+	return strs, true
+}
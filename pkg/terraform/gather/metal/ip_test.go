@@ -0,0 +1,42 @@
+package metal
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/installer/pkg/terraform"
+)
+
+func TestHostIPs(t *testing.T) {
+	// The state of terraform-0.12.24.tfstate was written by a terraform
+	// newer than the vendored one, like the UPI states of users.
+	for _, file := range []string{"terraform.tfstate", "terraform-0.12.24.tfstate"} {
+		t.Run(file, func(t *testing.T) {
+			tfs, err := terraform.ReadState(filepath.Join("testdata", file))
+			if !assert.NoError(t, err) {
+				return
+			}
+			bootstrap, err := BootstrapIP(tfs)
+			assert.NoError(t, err)
+			assert.Equal(t, "147.75.90.11", bootstrap)
+			masters, err := ControlPlaneIPs(tfs)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"147.75.90.21", "147.75.90.23", "147.75.90.25"}, masters)
+
+			outputs := &terraform.State{Outputs: tfs.Outputs}
+			bootstrap, err = BootstrapIP(outputs)
+			assert.NoError(t, err)
+			assert.Equal(t, "147.75.90.11", bootstrap)
+			masters, err = ControlPlaneIPs(outputs)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"147.75.90.21", "147.75.90.23", "147.75.90.25"}, masters)
+		})
+	}
+
+	_, err := BootstrapIP(&terraform.State{})
+	assert.EqualError(t, err, "failed to lookup bootstrap: resource not found")
+	_, err = ControlPlaneIPs(&terraform.State{})
+	assert.EqualError(t, err, "failed to lookup masters: resource not found")
+}
@@ -3,15 +3,20 @@
 package openstack
 
 import (
+	"net"
+
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/openshift/installer/pkg/terraform"
+	"github.com/openshift/installer/pkg/terraform/gather"
 )
 
-// BootstrapIP returns the ip address for bootstrap host.
-func BootstrapIP(tfs *terraform.State) (string, error) {
+// BootstrapIP returns the ip address for bootstrap host, its access_ip_v6
+// instead of its access_ip_v4 on IPv6 clusters, as told by machineCIDR, which
+// may be nil.
+func BootstrapIP(tfs *terraform.State, machineCIDR *net.IPNet) (string, error) {
 	br, err := terraform.LookupResource(tfs, "module.bootstrap", "openstack_compute_instance_v2", "bootstrap")
 	if err != nil {
 		return "", errors.Wrap(err, "failed to lookup bootstrap")
@@ -19,15 +24,17 @@ func BootstrapIP(tfs *terraform.State) (string, error) {
 	if len(br.Instances) == 0 {
 		return "", errors.New("no bootstrap instance found")
 	}
-	bootstrap, _, err := unstructured.NestedString(br.Instances[0].Attributes, "access_ip_v4")
-	if err != nil {
+	bootstrap := accessIP(br.Instances[0].Attributes, machineCIDR)
+	if bootstrap == "" {
 		return "", errors.New("no public_ip found for bootstrap")
 	}
 	return bootstrap, nil
 }
 
-// ControlPlaneIPs returns the ip addresses for control plane hosts.
-func ControlPlaneIPs(tfs *terraform.State) ([]string, error) {
+// ControlPlaneIPs returns the ip addresses for control plane hosts, their
+// access_ip_v6 instead of their access_ip_v4 on IPv6 clusters, as told by
+// machineCIDR, which may be nil.
+func ControlPlaneIPs(tfs *terraform.State, machineCIDR *net.IPNet) ([]string, error) {
 	mrs, err := terraform.LookupResource(tfs, "module.masters", "openstack_compute_instance_v2", "master_conf")
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to lookup masters")
@@ -35,11 +42,19 @@ func ControlPlaneIPs(tfs *terraform.State) ([]string, error) {
 	var errs []error
 	var masters []string
 	for idx, inst := range mrs.Instances {
-		master, _, err := unstructured.NestedString(inst.Attributes, "access_ip_v4")
-		if err != nil {
-			errs = append(errs, errors.Wrapf(err, "no access_ip_v4 for master_conf.%d", idx))
+		master := accessIP(inst.Attributes, machineCIDR)
+		if master == "" {
+			errs = append(errs, errors.Errorf("no access_ip_v4 or access_ip_v6 for master_conf.%d", idx))
 		}
 		masters = append(masters, master)
 	}
 	return masters, utilerrors.NewAggregate(errs)
 }
+
+// accessIP returns the access_ip_v4 or access_ip_v6 of an instance, whichever
+// is preferred in machineCIDR.
+func accessIP(attr map[string]interface{}, machineCIDR *net.IPNet) string {
+	ipv4, _, _ := unstructured.NestedString(attr, "access_ip_v4")
+	ipv6, _, _ := unstructured.NestedString(attr, "access_ip_v6")
+	return gather.PreferredAddress([]string{ipv4, ipv6}, machineCIDR)
+}
@@ -0,0 +1,74 @@
+package openstack
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"github.com/gophercloud/utils/openstack/clientconfig"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/openshift/installer/pkg/terraform"
+	"github.com/openshift/installer/pkg/terraform/gather"
+)
+
+// SerialConsole returns the serial console output of the bootstrap and
+// control plane instances in the terraform state, keyed by "bootstrap" and
+// "master-<index>", using the credentials of cloud in clouds.yaml. Nova
+// keeps the console log even for instances which never booted far enough to
+// accept SSH. The API calls are made through limiter, and their retries stop
+// when ctx is done. The instances whose output cannot be fetched are left
+// out, and the errors returned.
+func SerialConsole(ctx context.Context, tfs *terraform.State, cloud string, limiter *gather.APILimiter) (map[string][]byte, error) {
+	ids := map[string]string{}
+	if br, err := terraform.LookupResource(tfs, "module.bootstrap", "openstack_compute_instance_v2", "bootstrap"); err == nil && len(br.Instances) > 0 {
+		ids["bootstrap"], _, _ = unstructured.NestedString(br.Instances[0].Attributes, "id")
+	}
+	if mrs, err := terraform.LookupResource(tfs, "module.masters", "openstack_compute_instance_v2", "master_conf"); err == nil {
+		for idx, inst := range mrs.Instances {
+			ids[fmt.Sprintf("master-%d", idx)], _, _ = unstructured.NestedString(inst.Attributes, "id")
+		}
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("no instances found")
+	}
+
+	client, err := clientconfig.NewServiceClient("compute", &clientconfig.ClientOpts{Cloud: cloud})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the compute client")
+	}
+	outputs := map[string][]byte{}
+	var errs []error
+	for name, id := range ids {
+		if id == "" {
+			errs = append(errs, errors.Errorf("no id found for %s", name))
+			continue
+		}
+		var output string
+		err := limiter.Call(ctx, classifyAPIError, func(context.Context) (err error) {
+			output, err = servers.ShowConsoleOutput(client, id, servers.ShowConsoleOutputOpts{}).Extract()
+			return err
+		})
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to get the console output of %s (%s)", name, id))
+			continue
+		}
+		outputs[name] = []byte(output)
+	}
+	return outputs, utilerrors.NewAggregate(errs)
+}
+
+// classifyAPIError tells the 429 responses of the OpenStack API, retried by
+// the gather.APILimiter, from the 401 and 403 ones, which fail at once.
+func classifyAPIError(err error) gather.APIErrorClass {
+	switch err.(type) {
+	case gophercloud.ErrDefault429:
+		return gather.APIErrorThrottled
+	case gophercloud.ErrDefault401, gophercloud.ErrDefault403:
+		return gather.APIErrorAuth
+	}
+	return gather.APIErrorOther
+}
@@ -0,0 +1,76 @@
+// Package vsphere contains utilities that help gather vSphere specific
+// information from terraform state.
+package vsphere
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/openshift/installer/pkg/terraform"
+)
+
+// BootstrapIP returns the ip address for bootstrap host, preferring an
+// address in machineCIDR, which may be nil.
+func BootstrapIP(tfs *terraform.State, machineCIDR *net.IPNet) (string, error) {
+	br, err := terraform.LookupResource(tfs, "module.bootstrap", "vsphere_virtual_machine", "vm")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to lookup bootstrap")
+	}
+	if len(br.Instances) == 0 {
+		return "", errors.New("no bootstrap instance found")
+	}
+	bootstrap, err := guestIP(br.Instances[0].Attributes, machineCIDR)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to lookup the bootstrap ip")
+	}
+	return bootstrap, nil
+}
+
+// ControlPlaneIPs returns the ip addresses for control plane hosts,
+// preferring addresses in machineCIDR, which may be nil.
+func ControlPlaneIPs(tfs *terraform.State, machineCIDR *net.IPNet) ([]string, error) {
+	mrs, err := terraform.LookupResource(tfs, "module.control_plane", "vsphere_virtual_machine", "vm")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to lookup masters")
+	}
+	var errs []error
+	var masters []string
+	for idx, inst := range mrs.Instances {
+		master, err := guestIP(inst.Attributes, machineCIDR)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to lookup the ip for master.%d", idx))
+		}
+		masters = append(masters, master)
+	}
+	return masters, utilerrors.NewAggregate(errs)
+}
+
+// guestIP returns the address of a virtual machine reported by VMware Tools,
+// across all of its NICs. The first address in machineCIDR is preferred,
+// then the default address of the virtual machine, then its first IPv4
+// address.
+func guestIP(attr map[string]interface{}, machineCIDR *net.IPNet) (string, error) {
+	addresses, _, err := unstructured.NestedStringSlice(attr, "guest_ip_addresses")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to lookup guest_ip_addresses")
+	}
+	if machineCIDR != nil {
+		for _, address := range addresses {
+			if ip := net.ParseIP(address); ip != nil && machineCIDR.Contains(ip) {
+				return address, nil
+			}
+		}
+	}
+	if address, _, _ := unstructured.NestedString(attr, "default_ip_address"); address != "" {
+		return address, nil
+	}
+	for _, address := range addresses {
+		if ip := net.ParseIP(address); ip != nil && ip.To4() != nil && !ip.IsLinkLocalUnicast() {
+			return address, nil
+		}
+	}
+	return "", errors.New("no guest ip address found, check that VMware Tools runs on the host")
+}
@@ -0,0 +1,57 @@
+package vsphere
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/installer/pkg/terraform"
+)
+
+func TestHostIPs(t *testing.T) {
+	_, machineCIDR, err := net.ParseCIDR("192.168.100.0/24")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cases := []struct {
+		name        string
+		machineCIDR *net.IPNet
+		bootstrap   string
+		masters     []string
+	}{{
+		name:        "machine network",
+		machineCIDR: machineCIDR,
+		bootstrap:   "192.168.100.10",
+		masters:     []string{"192.168.100.20", "192.168.100.21", "10.0.50.22"},
+	}, {
+		name:      "default address",
+		bootstrap: "192.168.100.10",
+		masters:   []string{"10.0.50.20", "10.0.50.21", "10.0.50.22"},
+	}}
+	// The state of terraform-0.12.24.tfstate was written by a terraform
+	// newer than the vendored one, like the UPI states of users.
+	for _, file := range []string{"terraform.tfstate", "terraform-0.12.24.tfstate"} {
+		tfs, err := terraform.ReadState(filepath.Join("testdata", file))
+		if !assert.NoError(t, err) {
+			continue
+		}
+		for _, tc := range cases {
+			t.Run(file+"/"+tc.name, func(t *testing.T) {
+				bootstrap, err := BootstrapIP(tfs, tc.machineCIDR)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.bootstrap, bootstrap)
+				masters, err := ControlPlaneIPs(tfs, tc.machineCIDR)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.masters, masters)
+			})
+		}
+	}
+}
+
+func TestGuestIPNoAddress(t *testing.T) {
+	_, err := guestIP(map[string]interface{}{"guest_ip_addresses": []interface{}{"fe80::1"}}, nil)
+	assert.EqualError(t, err, "no guest ip address found, check that VMware Tools runs on the host")
+}
@@ -0,0 +1,108 @@
+package terraform
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	tfexec "github.com/openshift/installer/pkg/terraform/exec"
+)
+
+// BackendConfigFileName is the file in the .terraform directory where
+// `terraform init` records the backend the state is stored in.
+const BackendConfigFileName = "terraform.tfstate"
+
+// RemoteState locates a terraform state stored in a remote backend.
+type RemoteState struct {
+	// Backend is the type of the backend, like "s3" or "gcs".
+	Backend string
+
+	// Config holds the arguments of the backend.
+	Config map[string]interface{}
+
+	// Workspace is the workspace the state belongs to.
+	Workspace string
+}
+
+// backendConfigFile is the sparse representation of the backend
+// configuration file written by `terraform init`.
+type backendConfigFile struct {
+	Backend *struct {
+		Type   string                 `json:"type"`
+		Config map[string]interface{} `json:"config"`
+	} `json:"backend"`
+}
+
+// ReadBackendConfig returns the remote state recorded in file, the backend
+// configuration `terraform init` writes to .terraform/terraform.tfstate, for
+// the default workspace. It returns nil when file records no backend.
+func ReadBackendConfig(file string) (*RemoteState, error) {
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %q", file)
+	}
+	var config backendConfigFile
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal %q", file)
+	}
+	if config.Backend == nil || config.Backend.Type == "" || config.Backend.Type == "local" {
+		return nil, nil
+	}
+	return &RemoteState{Backend: config.Backend.Type, Config: config.Backend.Config, Workspace: "default"}, nil
+}
+
+// ParseStateURL returns the remote state at the URL of an S3 object,
+// s3://bucket/key, or of a GCS object, gs://bucket/prefix/workspace.tfstate,
+// which is the layout of the gcs backend. The query parameters of the URL
+// are passed to the backend as arguments, e.g. ?region=us-east-1.
+func ParseStateURL(value string) (*RemoteState, error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	object := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || object == "" {
+		return nil, errors.Errorf("%q does not name a bucket and an object", value)
+	}
+
+	state := &RemoteState{Config: map[string]interface{}{"bucket": u.Host}, Workspace: "default"}
+	switch u.Scheme {
+	case "s3":
+		state.Backend = "s3"
+		state.Config["key"] = object
+	case "gs":
+		if path.Ext(object) != ".tfstate" {
+			return nil, errors.Errorf("GCS state %q is not a .tfstate object", value)
+		}
+		prefix := path.Dir(object)
+		if prefix == "." {
+			prefix = ""
+		}
+		state.Backend = "gcs"
+		state.Config["prefix"] = prefix
+		state.Workspace = strings.TrimSuffix(path.Base(object), ".tfstate")
+	default:
+		return nil, errors.Errorf("unsupported state URL scheme %q, use s3 or gs", u.Scheme)
+	}
+	for key, values := range u.Query() {
+		state.Config[key] = values[len(values)-1]
+	}
+	return state, nil
+}
+
+// Read downloads the remote state from its backend.
+func (s *RemoteState) Read() (*State, error) {
+	config, err := json.Marshal(s.Config)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := tfexec.ReadRemoteState(s.Backend, config, s.Workspace)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download the state from the %s backend", s.Backend)
+	}
+	return unmarshalState(raw, s.Backend+" backend")
+}
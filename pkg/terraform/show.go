@@ -0,0 +1,69 @@
+package terraform
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// showOutput is the sparse representation of the output of
+// `terraform show -json` that includes the fields read by ReadShowJSON.
+type showOutput struct {
+	Values struct {
+		Outputs    map[string]StateOutput `json:"outputs"`
+		RootModule showModule             `json:"root_module"`
+	} `json:"values"`
+}
+
+type showModule struct {
+	Address      string         `json:"address"`
+	Resources    []showResource `json:"resources"`
+	ChildModules []showModule   `json:"child_modules"`
+}
+
+type showResource struct {
+	Mode   string                 `json:"mode"`
+	Type   string                 `json:"type"`
+	Name   string                 `json:"name"`
+	Values map[string]interface{} `json:"values"`
+}
+
+// ReadShowJSON returns the terraform state from a file holding the output of
+// `terraform show -json`, as an alternative to ReadState. Only the managed
+// resources under values.root_module and its child_modules are read, using
+// the address of their module and their type, name and values. Each
+// instance of a resource with count is read into an instance of the
+// returned resource, in order. The outputs of the root module are read
+// too.
+func ReadShowJSON(file string) (*State, error) {
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %q", file)
+	}
+
+	var show showOutput
+	if err := json.Unmarshal(raw, &show); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal %q", file)
+	}
+	state := &State{Outputs: show.Values.Outputs}
+	addShowModule(state, show.Values.RootModule)
+	return state, nil
+}
+
+func addShowModule(state *State, module showModule) {
+	for _, r := range module.Resources {
+		if r.Mode != "managed" {
+			continue
+		}
+		resource, err := LookupResource(state, module.Address, r.Type, r.Name)
+		if err != nil {
+			state.Resources = append(state.Resources, StateResource{Module: module.Address, Type: r.Type, Name: r.Name})
+			resource = &state.Resources[len(state.Resources)-1]
+		}
+		resource.Instances = append(resource.Instances, StateResourceInstance{Attributes: r.Values})
+	}
+	for _, child := range module.ChildModules {
+		addShowModule(state, child)
+	}
+}
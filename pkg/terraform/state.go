@@ -2,6 +2,7 @@ package terraform
 
 import (
 	"encoding/json"
+	"io/ioutil"
 
 	"github.com/pkg/errors"
 
@@ -11,7 +12,13 @@ import (
 // State in local sparse representation of terraform state that includes
 // the fields important to installer.
 type State struct {
-	Resources []StateResource `json:"resources"`
+	Resources []StateResource        `json:"resources"`
+	Outputs   map[string]StateOutput `json:"outputs"`
+}
+
+// StateOutput is an output of the root module in the terraform state.
+type StateOutput struct {
+	Value interface{} `json:"value"`
 }
 
 // StateResource is local sparse representation of terraform state resource that includes
@@ -46,16 +53,48 @@ func LookupResource(state *State, module, t, name string) (*StateResource, error
 	return nil, ErrResourceNotFound
 }
 
-// ReadState returns that terraform state from the file.
+// ReadState returns that terraform state from the file. The states written
+// by a terraform newer than the vendored one, which its reader rejects, are
+// read as JSON when they are in the same version of the format, like the
+// states of the UPI terraform run by users with their own terraform.
 func ReadState(file string) (*State, error) {
 	sfRaw, err := tfexec.ReadState(file)
 	if err != nil {
+		if state, rawErr := readRawState(file); rawErr == nil {
+			return state, nil
+		}
 		return nil, errors.Wrapf(err, "failed to read %q", file)
 	}
+	return unmarshalState(sfRaw, file)
+}
 
-	var tfstate State
-	if err := json.Unmarshal(sfRaw, &tfstate); err != nil {
+// stateFormatVersion is the version of the format of the terraform states
+// which are read as JSON by readRawState.
+const stateFormatVersion = 4
+
+// readRawState reads the terraform state in file as JSON, without the
+// terraform reader, when it is in version stateFormatVersion of the format.
+func readRawState(file string) (*State, error) {
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
 		return nil, errors.Wrapf(err, "failed to unmarshal %q", file)
 	}
+	if header.Version != stateFormatVersion {
+		return nil, errors.Errorf("%q is in version %d of the state format, not %d", file, header.Version, stateFormatVersion)
+	}
+	return unmarshalState(raw, file)
+}
+
+func unmarshalState(raw []byte, source string) (*State, error) {
+	var tfstate State
+	if err := json.Unmarshal(raw, &tfstate); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal %q", source)
+	}
 	return &tfstate, nil
 }